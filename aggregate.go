@@ -0,0 +1,209 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// aggregateKey 聚合时合并记录的键：同一主机（RecordHost）在同一时间点的记录合并为一条
+type aggregateKey struct {
+	host      string
+	timestamp time.Time
+}
+
+// aggregateMean 将同一主机时间戳相同的记录（例如同一主机被多个atop实例采集、或多份日志重叠的时段）
+// 合并为各字段的平均值，不同主机的记录保持为各自的序列。采样间隔、PSI、CPU、PAG只在带有该项数据的记录间取平均，
+// 磁盘、网络接口和单核CPU按设备名/接口名/核心编号分别取平均。结果按时间排序，同一时间点按主机名排序；
+// 要求各来源的采样时间对齐，时间戳不同的记录不会被合并
+func aggregateMean(data []atopparse.MemoryRecord) []atopparse.MemoryRecord {
+	type group struct {
+		sum             atopparse.MemoryRecord
+		source          string // 组内记录的来源文件，来源不同时为空
+		count           int
+		intervalCount   int // 带有采样间隔的记录数
+		psiCount        int // 带有PSI数据的记录数，PSI只在这些记录间取平均
+		cpuCount        int // 带有CPU数据的记录数
+		waitCount       int // 带有CPU wait字段的记录数
+		pagCount        int // 带有PAG数据的记录数
+		disks           map[string]*atopparse.DiskRecord
+		diskCount       map[string]int
+		throughputCount map[string]int // 各设备带有吞吐量数据的记录数
+		diskOrder       []string
+		nets            map[string]*atopparse.NetRecord
+		netCount        map[string]int
+		netOrder        []string
+		cores           map[int]*atopparse.CoreRecord
+		coreCount       map[int]int
+		coreOrder       []int
+	}
+
+	groups := make(map[aggregateKey]*group)
+	for _, record := range data {
+		key := aggregateKey{atopparse.RecordHost(record), record.Timestamp}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				sum:             atopparse.MemoryRecord{Timestamp: record.Timestamp, Hostname: key.host},
+				source:          record.Source,
+				disks:           make(map[string]*atopparse.DiskRecord),
+				diskCount:       make(map[string]int),
+				throughputCount: make(map[string]int),
+				nets:            make(map[string]*atopparse.NetRecord),
+				netCount:        make(map[string]int),
+				cores:           make(map[int]*atopparse.CoreRecord),
+				coreCount:       make(map[int]int),
+			}
+			groups[key] = g
+		}
+		if record.Source != g.source {
+			g.source = ""
+		}
+		g.sum.MemTotal += record.MemTotal
+		g.sum.MemFree += record.MemFree
+		g.sum.MemCache += record.MemCache
+		g.sum.MemBuff += record.MemBuff
+		g.sum.MemSlabRec += record.MemSlabRec
+		g.sum.SwapTotal += record.SwapTotal
+		g.sum.SwapFree += record.SwapFree
+		g.count++
+		if record.Interval > 0 {
+			g.sum.Interval += record.Interval
+			g.intervalCount++
+		}
+		if record.HasPSI {
+			g.sum.PSIMemSome += record.PSIMemSome
+			g.sum.PSIMemFull += record.PSIMemFull
+			g.psiCount++
+		}
+		if record.HasCPU {
+			g.sum.CPUSys += record.CPUSys
+			g.sum.CPUUser += record.CPUUser
+			g.sum.CPUIdle += record.CPUIdle
+			g.cpuCount++
+		}
+		if record.HasCPUWait {
+			g.sum.CPUWait += record.CPUWait
+			g.waitCount++
+		}
+		if record.HasPAG {
+			g.sum.SwapIn += record.SwapIn
+			g.sum.SwapOut += record.SwapOut
+			g.pagCount++
+		}
+		for _, disk := range record.Disks {
+			sum, ok := g.disks[disk.Device]
+			if !ok {
+				sum = &atopparse.DiskRecord{Timestamp: record.Timestamp, Device: disk.Device}
+				g.disks[disk.Device] = sum
+				g.diskOrder = append(g.diskOrder, disk.Device)
+			}
+			sum.Busy += disk.Busy
+			sum.Read += disk.Read
+			sum.Write += disk.Write
+			if disk.HasThroughput {
+				sum.HasThroughput = true
+				sum.ReadMBps += disk.ReadMBps
+				sum.WriteMBps += disk.WriteMBps
+				g.throughputCount[disk.Device]++
+			}
+			g.diskCount[disk.Device]++
+		}
+		for _, net := range record.Nets {
+			sum, ok := g.nets[net.Interface]
+			if !ok {
+				sum = &atopparse.NetRecord{Timestamp: record.Timestamp, Interface: net.Interface}
+				g.nets[net.Interface] = sum
+				g.netOrder = append(g.netOrder, net.Interface)
+			}
+			sum.PacketsIn += net.PacketsIn
+			sum.PacketsOut += net.PacketsOut
+			sum.InMbps += net.InMbps
+			sum.OutMbps += net.OutMbps
+			g.netCount[net.Interface]++
+		}
+		for _, core := range record.Cores {
+			sum, ok := g.cores[core.Core]
+			if !ok {
+				sum = &atopparse.CoreRecord{Core: core.Core}
+				g.cores[core.Core] = sum
+				g.coreOrder = append(g.coreOrder, core.Core)
+			}
+			sum.Busy += core.Busy
+			g.coreCount[core.Core]++
+		}
+	}
+
+	aggregated := make([]atopparse.MemoryRecord, 0, len(groups))
+	for _, g := range groups {
+		n := float64(g.count)
+		record := atopparse.MemoryRecord{
+			Timestamp:  g.sum.Timestamp,
+			Hostname:   g.sum.Hostname,
+			Source:     g.source,
+			MemTotal:   g.sum.MemTotal / n,
+			MemFree:    g.sum.MemFree / n,
+			MemCache:   g.sum.MemCache / n,
+			MemBuff:    g.sum.MemBuff / n,
+			MemSlabRec: g.sum.MemSlabRec / n,
+			SwapTotal:  g.sum.SwapTotal / n,
+			SwapFree:   g.sum.SwapFree / n,
+		}
+		if g.intervalCount > 0 {
+			record.Interval = g.sum.Interval / time.Duration(g.intervalCount)
+		}
+		if g.psiCount > 0 {
+			record.HasPSI = true
+			record.PSIMemSome = g.sum.PSIMemSome / float64(g.psiCount)
+			record.PSIMemFull = g.sum.PSIMemFull / float64(g.psiCount)
+		}
+		if g.cpuCount > 0 {
+			record.HasCPU = true
+			record.CPUSys = g.sum.CPUSys / float64(g.cpuCount)
+			record.CPUUser = g.sum.CPUUser / float64(g.cpuCount)
+			record.CPUIdle = g.sum.CPUIdle / float64(g.cpuCount)
+		}
+		if g.waitCount > 0 {
+			record.HasCPUWait = true
+			record.CPUWait = g.sum.CPUWait / float64(g.waitCount)
+		}
+		if g.pagCount > 0 {
+			record.HasPAG = true
+			record.SwapIn = g.sum.SwapIn / float64(g.pagCount)
+			record.SwapOut = g.sum.SwapOut / float64(g.pagCount)
+		}
+		for _, device := range g.diskOrder {
+			disk, count := *g.disks[device], float64(g.diskCount[device])
+			disk.Busy /= count
+			disk.Read /= count
+			disk.Write /= count
+			if disk.HasThroughput {
+				disk.ReadMBps /= float64(g.throughputCount[device])
+				disk.WriteMBps /= float64(g.throughputCount[device])
+			}
+			record.Disks = append(record.Disks, disk)
+		}
+		for _, iface := range g.netOrder {
+			net, count := *g.nets[iface], float64(g.netCount[iface])
+			net.PacketsIn /= count
+			net.PacketsOut /= count
+			net.InMbps /= count
+			net.OutMbps /= count
+			record.Nets = append(record.Nets, net)
+		}
+		for _, id := range g.coreOrder {
+			core := *g.cores[id]
+			core.Busy /= float64(g.coreCount[id])
+			record.Cores = append(record.Cores, core)
+		}
+		aggregated = append(aggregated, record)
+	}
+	sort.Slice(aggregated, func(i, j int) bool {
+		if !aggregated[i].Timestamp.Equal(aggregated[j].Timestamp) {
+			return aggregated[i].Timestamp.Before(aggregated[j].Timestamp)
+		}
+		return aggregated[i].Hostname < aggregated[j].Hostname
+	})
+	return aggregated
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+func TestAggregateMean(t *testing.T) {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	record := func(host, source string, free float64) atopparse.MemoryRecord {
+		return atopparse.MemoryRecord{Timestamp: ts, Hostname: host, Source: source, MemTotal: 16, MemFree: free,
+			Interval: 10 * time.Second}
+	}
+	withPAG := func(r atopparse.MemoryRecord, in, out float64) atopparse.MemoryRecord {
+		r.HasPAG, r.SwapIn, r.SwapOut = true, in, out
+		return r
+	}
+	withDisk := func(r atopparse.MemoryRecord, disks ...atopparse.DiskRecord) atopparse.MemoryRecord {
+		r.Disks = disks
+		return r
+	}
+	withNet := func(r atopparse.MemoryRecord, nets ...atopparse.NetRecord) atopparse.MemoryRecord {
+		r.Nets = nets
+		return r
+	}
+
+	tests := []struct {
+		name string
+		data []atopparse.MemoryRecord
+		want []atopparse.MemoryRecord
+	}{
+		{
+			name: "same host and timestamp",
+			data: []atopparse.MemoryRecord{
+				withPAG(record("web1", "a.txt", 4), 100, 10),
+				withPAG(record("web1", "b.txt", 2), 300, 30),
+			},
+			want: []atopparse.MemoryRecord{
+				withPAG(record("web1", "", 3), 200, 20),
+			},
+		},
+		{
+			name: "hosts stay separate",
+			data: []atopparse.MemoryRecord{record("web2", "b.txt", 2), record("web1", "a.txt", 4)},
+			want: []atopparse.MemoryRecord{record("web1", "a.txt", 4), record("web2", "b.txt", 2)},
+		},
+		{
+			name: "interval pag disks and nets averaged where present",
+			data: []atopparse.MemoryRecord{
+				withNet(withDisk(withPAG(record("web1", "a.txt", 4), 100, 0),
+					atopparse.DiskRecord{Device: "sda", Busy: 10, Read: 100, Write: 200},
+					atopparse.DiskRecord{Device: "sdb", Busy: 50, Read: 10, Write: 20}),
+					atopparse.NetRecord{Interface: "eth0", PacketsIn: 100, PacketsOut: 200, InMbps: 1, OutMbps: 2}),
+				withNet(withDisk(atopparse.MemoryRecord{Timestamp: ts, Hostname: "web1", Source: "a.txt", MemTotal: 16, MemFree: 2},
+					atopparse.DiskRecord{Device: "sda", Busy: 30, Read: 300, Write: 400}),
+					atopparse.NetRecord{Interface: "eth0", PacketsIn: 300, PacketsOut: 400, InMbps: 3, OutMbps: 4}),
+			},
+			want: []atopparse.MemoryRecord{
+				withNet(withDisk(withPAG(record("web1", "a.txt", 3), 100, 0),
+					atopparse.DiskRecord{Timestamp: ts, Device: "sda", Busy: 20, Read: 200, Write: 300},
+					atopparse.DiskRecord{Timestamp: ts, Device: "sdb", Busy: 50, Read: 10, Write: 20}),
+					atopparse.NetRecord{Timestamp: ts, Interface: "eth0", PacketsIn: 200, PacketsOut: 300, InMbps: 2, OutMbps: 3}),
+			},
+		},
+		{
+			name: "host from source file name",
+			data: []atopparse.MemoryRecord{record("", "/logs/db1.txt", 4), record("", "/logs/db1.txt", 2)},
+			want: []atopparse.MemoryRecord{record("db1", "/logs/db1.txt", 3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateMean(tt.data)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("聚合结果\n%+v\n期望\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateMeanMath(t *testing.T) {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	record := func(at time.Time, source string, free, swapFree float64) atopparse.MemoryRecord {
+		return atopparse.MemoryRecord{Timestamp: at, Hostname: "web1", Source: source, MemTotal: 16, MemFree: free, SwapTotal: 2, SwapFree: swapFree}
+	}
+	withCPU := func(r atopparse.MemoryRecord, sys, user, idle float64) atopparse.MemoryRecord {
+		r.HasCPU, r.CPUSys, r.CPUUser, r.CPUIdle = true, sys, user, idle
+		return r
+	}
+	withPSI := func(r atopparse.MemoryRecord, some, full float64) atopparse.MemoryRecord {
+		r.HasPSI, r.PSIMemSome, r.PSIMemFull = true, some, full
+		return r
+	}
+	withCores := func(r atopparse.MemoryRecord, cores ...atopparse.CoreRecord) atopparse.MemoryRecord {
+		r.Cores = cores
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		data  []atopparse.MemoryRecord
+		check func(t *testing.T, got []atopparse.MemoryRecord)
+	}{
+		{
+			name: "mean of three sources",
+			data: []atopparse.MemoryRecord{record(ts, "a.txt", 4, 1), record(ts, "b.txt", 2, 0.5), record(ts, "c.txt", 1, 0)},
+			check: func(t *testing.T, got []atopparse.MemoryRecord) {
+				if len(got) != 1 || math.Abs(got[0].MemFree-7.0/3) > 1e-9 || math.Abs(got[0].SwapFree-0.5) > 1e-9 || got[0].MemTotal != 16 {
+					t.Errorf("聚合结果为 %+v，期望 mem_free 7/3、swp_free 0.5", got)
+				}
+			},
+		},
+		{
+			name: "unaligned timestamps not merged",
+			data: []atopparse.MemoryRecord{record(ts, "a.txt", 4, 1), record(ts.Add(time.Second), "b.txt", 2, 1)},
+			check: func(t *testing.T, got []atopparse.MemoryRecord) {
+				if len(got) != 2 || got[0].MemFree != 4 || got[1].MemFree != 2 {
+					t.Errorf("时间戳不同的记录不应合并: %+v", got)
+				}
+			},
+		},
+		{
+			name: "cpu and psi only averaged where present",
+			data: []atopparse.MemoryRecord{
+				withPSI(withCPU(record(ts, "a.txt", 4, 1), 10, 20, 370), 2, 1),
+				withCPU(record(ts, "b.txt", 2, 1), 30, 40, 330),
+				record(ts, "c.txt", 3, 1),
+			},
+			check: func(t *testing.T, got []atopparse.MemoryRecord) {
+				r := got[0]
+				if !r.HasCPU || r.CPUSys != 20 || r.CPUUser != 30 || r.CPUIdle != 350 {
+					t.Errorf("CPU应只在带有CPU数据的两条记录间取平均: %+v", r)
+				}
+				if !r.HasPSI || r.PSIMemSome != 2 || r.PSIMemFull != 1 {
+					t.Errorf("PSI应只取带有PSI数据的记录: %+v", r)
+				}
+				if r.MemFree != 3 {
+					t.Errorf("mem_free为 %v，期望 3", r.MemFree)
+				}
+			},
+		},
+		{
+			name: "cores averaged per core",
+			data: []atopparse.MemoryRecord{
+				withCores(record(ts, "a.txt", 4, 1), atopparse.CoreRecord{Core: 0, Busy: 10}, atopparse.CoreRecord{Core: 1, Busy: 90}),
+				withCores(record(ts, "b.txt", 2, 1), atopparse.CoreRecord{Core: 0, Busy: 30}),
+			},
+			check: func(t *testing.T, got []atopparse.MemoryRecord) {
+				want := []atopparse.CoreRecord{{Core: 0, Busy: 20}, {Core: 1, Busy: 90}}
+				if !reflect.DeepEqual(got[0].Cores, want) {
+					t.Errorf("核心利用率为 %+v，期望 %+v", got[0].Cores, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, aggregateMean(tt.data))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package atopparse
+
+import "sort"
+
+// usedMemoryPercentile 返回已用内存（MemTotal-MemFree）的第p百分位数，插值方式与统计摘要相同
+func usedMemoryPercentile(data []MemoryRecord, p float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	used := make([]float64, len(data))
+	for i, record := range data {
+		used[i] = record.MemTotal - record.MemFree
+	}
+	sort.Float64s(used)
+	return percentile(used, p)
+}
+
+// anomalyFlags 返回与HTML图表数据点一一对应的标记：已用内存高于threshold的样本为true，
+// 分段之间插入的空值位置为false
+func anomalyFlags(segments [][]MemoryRecord, threshold float64) []bool {
+	var flags []bool
+	for i, segment := range segments {
+		if i > 0 {
+			flags = append(flags, false)
+		}
+		for _, record := range segment {
+			flags = append(flags, record.MemTotal-record.MemFree > threshold)
+		}
+	}
+	return flags
+}
+
+// countAnomalies 返回被标记的样本数
+func countAnomalies(flags []bool) int {
+	count := 0
+	for _, flag := range flags {
+		if flag {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1 @@
+/* atop_parser: Chart.js not vendored. Run `go generate ./atopparse` to download it before building with --html-offline support. */
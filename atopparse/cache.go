@@ -0,0 +1,138 @@
+package atopparse
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// cacheEntry 单个日志文件的解析结果及其修改时间和大小
+type cacheEntry struct {
+	ModTime time.Time
+	Size    int64
+	Records []MemoryRecord
+	Info    DetectionInfo
+}
+
+// RecordCache 按文件修改时间缓存解析结果，未变化的文件不再重新解析
+type RecordCache struct {
+	Options string // 影响解析结果的选项，不一致时整个缓存失效
+	Entries map[string]cacheEntry
+
+	path         string
+	log          io.Writer // 命中统计的输出位置，取自LoadRecordCache的解析选项
+	hits, misses int
+	dirty        bool
+	mu           sync.Mutex // 目录模式下多个文件并发解析时保护Entries和计数
+}
+
+// cacheVersion 在记录内容（例如新增解析的字段）变化时递增，使旧缓存失效
+const cacheVersion = 16
+
+// cacheKey 返回缓存格式版本和影响解析结果的选项，与解析性能相关的选项不计入
+func cacheKey(opts ParseOptions) string {
+	return fmt.Sprintf("v%d trim=%d date=%q mem=%s swap=%s journald=%t strict=%t", cacheVersion, opts.TrimWarmup, opts.DateLayout, opts.MemLines, opts.SwapLines, opts.Journald, opts.Strict)
+}
+
+// LoadRecordCache 读取缓存文件；文件不存在、无法读取或解析选项变化时返回空缓存
+func LoadRecordCache(path string, opts ParseOptions) *RecordCache {
+	fresh := &RecordCache{Options: cacheKey(opts), Entries: make(map[string]cacheEntry), path: path, log: opts.console()}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(opts.console(), Tr("警告: 无法读取缓存 %s: %v，将重新解析所有文件\n"), path, err)
+		}
+		return fresh
+	}
+	defer file.Close()
+
+	var cache RecordCache
+	if err := gob.NewDecoder(file).Decode(&cache); err != nil {
+		fmt.Fprintf(opts.console(), Tr("警告: 缓存 %s 已损坏: %v，将重新解析所有文件\n"), path, err)
+		return fresh
+	}
+	if cache.Options != fresh.Options {
+		fmt.Fprintf(opts.console(), Tr("解析选项与缓存 %s 不一致，缓存失效\n"), path)
+		fresh.dirty = true
+		return fresh
+	}
+	cache.path = path
+	cache.log = fresh.log
+	return &cache
+}
+
+// Parse 返回文件的解析结果：文件修改时间和大小与缓存一致时直接使用缓存，
+// 否则重新解析并更新缓存。c为nil、文件为远程地址或标准输入时不使用缓存
+func (c *RecordCache) Parse(filePath string, opts ParseOptions, info *DetectionInfo) ([]MemoryRecord, error) {
+	if c == nil || IsRemoteLog(filePath) || filePath == StdinPath {
+		return ParseLog(filePath, opts, info)
+	}
+
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return ParseLog(filePath, opts, info)
+	}
+	c.mu.Lock()
+	entry, ok := c.Entries[filePath]
+	if ok && entry.ModTime.Equal(stat.ModTime()) && entry.Size == stat.Size() {
+		c.hits++
+		c.mu.Unlock()
+		info.merge(&entry.Info)
+		return entry.Records, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// 解析期间不持有锁，其他文件可以同时解析
+	fileInfo := NewDetectionInfo()
+	records, err := ParseLog(filePath, opts, fileInfo)
+	info.merge(fileInfo)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.Entries, filePath)
+		return nil, err
+	}
+	c.Entries[filePath] = cacheEntry{ModTime: stat.ModTime(), Size: stat.Size(), Records: records, Info: *fileInfo}
+	c.dirty = true
+	return records, nil
+}
+
+// Save 删除源文件已不存在的条目，有变化时写回缓存文件
+func (c *RecordCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	for filePath := range c.Entries {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			delete(c.Entries, filePath)
+			c.dirty = true
+		}
+	}
+	fmt.Fprintf(c.log, Tr("缓存: %d 个文件命中，%d 个文件重新解析\n"), c.hits, c.misses)
+	if !c.dirty {
+		return nil
+	}
+
+	// 先写临时文件再改名，避免中途失败留下损坏的缓存
+	tmpPath := c.path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(file).Encode(c); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
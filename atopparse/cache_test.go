@@ -0,0 +1,149 @@
+package atopparse
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordCache(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		change     func(t *testing.T, dir, cachePath string) // 两次运行之间对文件或缓存的修改
+		opts       ParseOptions                              // 第二次运行的解析选项
+		wantHits   int
+		wantMisses int
+		wantCount  map[string]int // 第二次运行各文件解析出的记录数
+	}{
+		{
+			name:       "unchanged files hit",
+			change:     func(t *testing.T, dir, cachePath string) {},
+			wantHits:   2,
+			wantMisses: 0,
+			wantCount:  map[string]int{"a.txt": 3, "b.txt": 4},
+		},
+		{
+			name: "modified file re-parsed",
+			change: func(t *testing.T, dir, cachePath string) {
+				path := filepath.Join(dir, "b.txt")
+				if err := os.WriteFile(path, []byte(blocksLog("web1", start, 6, 10*time.Second)), 0644); err != nil {
+					t.Fatal(err)
+				}
+				// 确保修改时间与缓存中的不同
+				mtime := time.Now().Add(time.Hour)
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantHits:   1,
+			wantMisses: 1,
+			wantCount:  map[string]int{"a.txt": 3, "b.txt": 6},
+		},
+		{
+			name: "touched file re-parsed",
+			change: func(t *testing.T, dir, cachePath string) {
+				mtime := time.Now().Add(time.Hour)
+				if err := os.Chtimes(filepath.Join(dir, "a.txt"), mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantHits:   1,
+			wantMisses: 1,
+			wantCount:  map[string]int{"a.txt": 3, "b.txt": 4},
+		},
+		{
+			name:       "parse options changed",
+			change:     func(t *testing.T, dir, cachePath string) {},
+			opts:       ParseOptions{TrimWarmup: 1},
+			wantHits:   0,
+			wantMisses: 2,
+			wantCount:  map[string]int{"a.txt": 2, "b.txt": 3},
+		},
+		{
+			name: "corrupted cache",
+			change: func(t *testing.T, dir, cachePath string) {
+				if err := os.WriteFile(cachePath, []byte("not a gob"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantHits:   0,
+			wantMisses: 2,
+			wantCount:  map[string]int{"a.txt": 3, "b.txt": 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cachePath := filepath.Join(dir, "records.gob")
+			for name, n := range map[string]int{"a.txt": 3, "b.txt": 4} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(blocksLog("web1", start, n, 10*time.Second)), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 第一次运行：缓存不存在，所有文件都重新解析
+			first := LoadRecordCache(cachePath, ParseOptions{Log: io.Discard})
+			for _, name := range []string{"a.txt", "b.txt"} {
+				if _, err := first.Parse(filepath.Join(dir, name), ParseOptions{}, NewDetectionInfo()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if first.hits != 0 || first.misses != 2 {
+				t.Fatalf("第一次运行命中 %d、重新解析 %d，期望 0 和 2", first.hits, first.misses)
+			}
+			if err := first.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.change(t, dir, cachePath)
+
+			opts := tt.opts
+			opts.Log = io.Discard
+			second := LoadRecordCache(cachePath, opts)
+			for _, name := range []string{"a.txt", "b.txt"} {
+				data, err := second.Parse(filepath.Join(dir, name), opts, NewDetectionInfo())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(data) != tt.wantCount[name] {
+					t.Errorf("%s 解析出 %d 条记录，期望 %d 条", name, len(data), tt.wantCount[name])
+				}
+			}
+			if second.hits != tt.wantHits || second.misses != tt.wantMisses {
+				t.Errorf("第二次运行命中 %d、重新解析 %d，期望 %d 和 %d", second.hits, second.misses, tt.wantHits, tt.wantMisses)
+			}
+		})
+	}
+}
+
+func TestRecordCacheDropsDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "records.gob")
+	logPath := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(logPath, []byte(blocksLog("web1", time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), 2, 10*time.Second)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := LoadRecordCache(cachePath, ParseOptions{Log: io.Discard})
+	if _, err := cache.Parse(logPath, ParseOptions{}, NewDetectionInfo()); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(logPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// 源文件删除后，保存时去掉对应条目
+	cache = LoadRecordCache(cachePath, ParseOptions{Log: io.Discard})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if entries := LoadRecordCache(cachePath, ParseOptions{Log: io.Discard}).Entries; len(entries) != 0 {
+		t.Errorf("缓存中仍有 %d 个条目，期望 0 个", len(entries))
+	}
+}
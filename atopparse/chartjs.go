@@ -0,0 +1,34 @@
+package atopparse
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// chartJSVersion 是--html-offline内嵌的Chart.js版本，与go:generate下载的版本一致
+const chartJSVersion = "4.4.1"
+
+//go:generate curl -sSfL -o assets/chart.umd.min.js https://cdn.jsdelivr.net/npm/chart.js@4.4.1/dist/chart.umd.min.js
+
+// chartJS 是内嵌的Chart.js。源码树中只有占位文件，构建前需要运行go generate下载
+//
+//go:embed assets/chart.umd.min.js
+var chartJS string
+
+// chartJSPlaceholder 是占位文件的开头，用于判断是否已经下载了真正的Chart.js
+const chartJSPlaceholder = "/* atop_parser: Chart.js not vendored."
+
+// ChartJSEmbedded 判断二进制中是否内嵌了Chart.js，未内嵌时无法使用--html-offline
+func ChartJSEmbedded() bool {
+	return !strings.HasPrefix(chartJS, chartJSPlaceholder)
+}
+
+// chartJSTag 返回HTML报告中引用Chart.js的<script>标签：offline时内联内嵌的库，否则从CDN加载
+func chartJSTag(offline bool) string {
+	if !offline {
+		return `<script src="https://cdn.jsdelivr.net/npm/chart.js"></script>`
+	}
+	// 避免库代码中的 "</script" 提前结束标签
+	return fmt.Sprintf("<script>/* Chart.js %s */\n%s\n</script>", chartJSVersion, strings.ReplaceAll(chartJS, "</script", `<\/script`))
+}
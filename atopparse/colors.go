@@ -0,0 +1,124 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LineColors 内存/交换空间图表中六条曲线的颜色，用于PNG、HTML、SVG和Vega-Lite图表。
+// 为零值的颜色使用各图表自己的默认颜色
+type LineColors struct {
+	MemTotal  color.RGBA
+	MemFree   color.RGBA
+	MemCache  color.RGBA
+	MemBuff   color.RGBA
+	SwapTotal color.RGBA
+	SwapFree  color.RGBA
+}
+
+// defaultLineColors PNG、HTML和Vega-Lite图表的默认颜色
+var defaultLineColors = LineColors{
+	MemTotal:  color.RGBA{R: 255, A: 255},
+	MemFree:   color.RGBA{G: 255, A: 255},
+	MemCache:  color.RGBA{R: 255, G: 165, A: 255},
+	MemBuff:   color.RGBA{R: 160, G: 32, B: 240, A: 255},
+	SwapTotal: color.RGBA{B: 255, A: 255},
+	SwapFree:  color.RGBA{R: 255, G: 255, A: 255},
+}
+
+// svgLineColors SVG图表的默认颜色，空闲内存和空闲交换空间的绿色、黄色较深，在白色背景上更清楚
+var svgLineColors = LineColors{
+	MemTotal:  color.RGBA{R: 255, A: 255},
+	MemFree:   color.RGBA{G: 200, A: 255},
+	MemCache:  color.RGBA{R: 255, G: 165, A: 255},
+	MemBuff:   color.RGBA{R: 160, G: 32, B: 240, A: 255},
+	SwapTotal: color.RGBA{B: 255, A: 255},
+	SwapFree:  color.RGBA{R: 200, G: 200, A: 255},
+}
+
+// palettes --palette 可选的预设配色。default为各图表的默认颜色；
+// colorblind为Okabe-Ito配色，红绿色盲也能区分各条曲线
+var palettes = map[string]LineColors{
+	"default": {},
+	"colorblind": {
+		MemTotal:  color.RGBA{R: 0xD5, G: 0x5E, A: 255},
+		MemFree:   color.RGBA{G: 0x9E, B: 0x73, A: 255},
+		MemCache:  color.RGBA{R: 0xE6, G: 0x9F, A: 255},
+		MemBuff:   color.RGBA{R: 0xCC, G: 0x79, B: 0xA7, A: 255},
+		SwapTotal: color.RGBA{G: 0x72, B: 0xB2, A: 255},
+		SwapFree:  color.RGBA{R: 0x56, G: 0xB4, B: 0xE9, A: 255},
+	},
+}
+
+// PaletteColors 返回预设配色name的曲线颜色
+func PaletteColors(name string) (LineColors, error) {
+	colors, ok := palettes[name]
+	if !ok {
+		names := make([]string, 0, len(palettes))
+		for name := range palettes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return LineColors{}, fmt.Errorf(Tr("未知的配色 %s，可选: %s"), name, strings.Join(names, ", "))
+	}
+	return colors, nil
+}
+
+// ParseHexColor 解析 #RRGGBB 或 #RGB 格式的颜色，# 可以省略
+func ParseHexColor(value string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf(Tr("无效的颜色 %q，应为 #RRGGBB 或 #RGB 格式"), value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf(Tr("无效的颜色 %q，应为 #RRGGBB 或 #RGB 格式"), value)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
+
+// withDefaults 将c中为零值的颜色替换为defaults中对应的颜色
+func (c LineColors) withDefaults(defaults LineColors) LineColors {
+	pick := func(value, fallback color.RGBA) color.RGBA {
+		if value == (color.RGBA{}) {
+			return fallback
+		}
+		return value
+	}
+	return LineColors{
+		MemTotal:  pick(c.MemTotal, defaults.MemTotal),
+		MemFree:   pick(c.MemFree, defaults.MemFree),
+		MemCache:  pick(c.MemCache, defaults.MemCache),
+		MemBuff:   pick(c.MemBuff, defaults.MemBuff),
+		SwapTotal: pick(c.SwapTotal, defaults.SwapTotal),
+		SwapFree:  pick(c.SwapFree, defaults.SwapFree),
+	}
+}
+
+// cssColor 将颜色格式化为CSS的 rgb(r, g, b)
+func cssColor(c color.RGBA) string {
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.R, c.G, c.B)
+}
+
+// htmlLineColors HTML报告中各曲线的CSS颜色，模板在脚本中将其写为字符串
+type htmlLineColors struct {
+	MemTotal, MemFree, MemCache, MemBuff, SwapTotal, SwapFree string
+}
+
+// newHTMLLineColors 将曲线颜色转换为HTML报告使用的CSS颜色
+func newHTMLLineColors(c LineColors) htmlLineColors {
+	return htmlLineColors{
+		MemTotal:  cssColor(c.MemTotal),
+		MemFree:   cssColor(c.MemFree),
+		MemCache:  cssColor(c.MemCache),
+		MemBuff:   cssColor(c.MemBuff),
+		SwapTotal: cssColor(c.SwapTotal),
+		SwapFree:  cssColor(c.SwapFree),
+	}
+}
@@ -0,0 +1,186 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// ComparePeriod 参与对比的一段采集数据，Label用于图例和对比表
+type ComparePeriod struct {
+	Label string
+	Data  []MemoryRecord // 按时间排序
+}
+
+// compareColors 两个时段曲线的颜色，分别对应A和B
+var compareColors = []color.Color{
+	color.RGBA{B: 220, A: 255},
+	color.RGBA{R: 255, G: 140, A: 255},
+}
+
+// compareMetric 对比模式可以叠加的一个指标，取值复用规则和派生指标中的计算
+type compareMetric struct {
+	column string // 对比表中的名称，与CSV列名一致
+	label  string // 图表标题和Y轴中的名称
+	unit   string
+	value  func(MemoryRecord) float64
+}
+
+// CompareMetricNames 是 --compare-metric 可选的指标，第一个为默认值
+var CompareMetricNames = []string{"used", "free", "swap_used", "used_pct"}
+
+// compareMetrics 各对比指标的取值和标注
+var compareMetrics = map[string]compareMetric{
+	"used":      {"mem_used", "Used Memory", "GB", func(r MemoryRecord) float64 { return RuleMetricValue(r, "mem_used") }},
+	"free":      {"mem_free", "Free Memory", "GB", func(r MemoryRecord) float64 { return RuleMetricValue(r, "mem_free") }},
+	"swap_used": {"swp_used", "Used Swap", "GB", func(r MemoryRecord) float64 { return RuleMetricValue(r, "swp_used") }},
+	"used_pct":  {"mem_used_pct", "Used Memory", "%", MemoryRecord.MemUsedPct},
+}
+
+// ValidateCompareMetric 检查name是否为可选的对比指标，为空表示默认值
+func ValidateCompareMetric(name string) error {
+	if _, ok := compareMetrics[name]; name != "" && !ok {
+		return fmt.Errorf(Tr("未知的对比指标 %q，可选: %s"), name, strings.Join(CompareMetricNames, ","))
+	}
+	return nil
+}
+
+// lookupCompareMetric 返回name对应的对比指标，为空时为默认的已用内存
+func lookupCompareMetric(name string) compareMetric {
+	if name == "" {
+		name = CompareMetricNames[0]
+	}
+	return compareMetrics[name]
+}
+
+// periodDuration 返回一段数据从第一个到最后一个样本的时长
+func periodDuration(data []MemoryRecord) time.Duration {
+	return data[len(data)-1].Timestamp.Sub(data[0].Timestamp)
+}
+
+// GenerateComparison 对比两段采集数据：生成 <前缀>_compare.png（NoPNG时不生成），
+// 两条opts.CompareMetric指标（默认已用内存）曲线各自从第一个样本起按经过时间绘制，长度不同时各自画到自己的结束时间；
+// 并输出和保存平均值、峰值的对比表 <前缀>_compare.txt
+func GenerateComparison(a, b ComparePeriod, outputPrefix string, opts ReportOptions) error {
+	if len(a.Data) == 0 || len(b.Data) == 0 {
+		return fmt.Errorf(Tr("对比的两个时段都需要有数据"))
+	}
+	if err := ValidateCompareMetric(opts.CompareMetric); err != nil {
+		return err
+	}
+	metric := lookupCompareMetric(opts.CompareMetric)
+
+	if !opts.NoPNG {
+		chartFile := outputPrefix + "_compare.png"
+		if err := generateCompareChart(a, b, metric, chartFile, opts.Chart.MaxPoints); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存时段对比图表: %s\n"), chartFile)
+	}
+
+	table := formatComparison(a, b, metric, opts.Precision)
+	fmt.Fprint(opts.console(), table)
+	tableFile := outputPrefix + "_compare.txt"
+	if err := os.WriteFile(tableFile, []byte(table), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(opts.console(), Tr("已保存时段对比表: %s\n"), tableFile)
+	return nil
+}
+
+// compareLegend 返回曲线的图例，包含时段名、起始时间和时长，例如 "A: before (06-11 00:00, 12:00:00)"
+func compareLegend(name string, period ComparePeriod) string {
+	return fmt.Sprintf("%s: %s (%s, %s)", name, period.Label,
+		period.Data[0].Timestamp.Format("01-02 15:04"), formatElapsed(periodDuration(period.Data)))
+}
+
+// generateCompareChart 绘制两个时段的对比指标曲线，X轴为距各自第一个样本的经过时间
+func generateCompareChart(a, b ComparePeriod, metric compareMetric, outputFile string, maxPoints int) error {
+	p := plot.New()
+	p.Title.Text = metric.label + ": Period A vs Period B"
+	p.X.Label.Text = "Elapsed (HH:MM:SS)"
+	p.Y.Label.Text = fmt.Sprintf("%s (%s)", metric.label, metric.unit)
+	p.X.Tick.Marker = elapsedTicks{}
+	p.Y.Min = 0
+
+	for i, period := range []ComparePeriod{a, b} {
+		data := downsampleRecords(period.Data, maxPoints)
+		start := period.Data[0].Timestamp
+		points := make(plotter.XYs, len(data))
+		for j, record := range data {
+			points[j].X = record.Timestamp.Sub(start).Hours()
+			points[j].Y = metric.value(record)
+		}
+		line, err := plotter.NewLine(points)
+		if err != nil {
+			return err
+		}
+		line.Color = compareColors[i]
+		line.Width = vg.Points(1.5)
+		if i == 1 {
+			// 虚线使两条曲线在黑白打印时也能区分
+			line.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+		}
+		p.Add(line)
+		p.Legend.Add(compareLegend(string(rune('A'+i)), period), line)
+	}
+	p.Legend.Top = true
+	p.Legend.Left = true
+
+	return p.Save(10*vg.Inch, 5*vg.Inch, outputFile)
+}
+
+// formatComparison 将两个时段的样本数、时长、对比指标的平均值/最小值/最大值，
+// 以及已用内存和已用交换空间的平均值、峰值格式化为对齐的文本表格，最后一列为B相对A的变化
+func formatComparison(a, b ComparePeriod, metric compareMetric, precision int) string {
+	statsA, statsB := computeStats(a.Data), computeStats(b.Data)
+	metricA, metricB := summarizeMetric(a.Data, metric), summarizeMetric(b.Data, metric)
+
+	var s strings.Builder
+	fmt.Fprintf(&s, Tr("时段对比（A: %s，B: %s，对比指标 %s，单位 %s；已用内存/交换空间单位 GB）:\n"), a.Label, b.Label, metric.column, metric.unit)
+	fmt.Fprintf(&s, "  %-20s %12s %12s %12s\n", "", "A", "B", "B-A")
+	fmt.Fprintf(&s, "  %-20s %12d %12d %12d\n", "samples", statsA.Samples, statsB.Samples, statsB.Samples-statsA.Samples)
+	durationA, durationB := periodDuration(a.Data), periodDuration(b.Data)
+	fmt.Fprintf(&s, "  %-20s %12s %12s %12s\n", "duration", formatElapsed(durationA), formatElapsed(durationB), formatElapsed(durationB-durationA))
+	type row struct {
+		name string
+		a, b float64
+	}
+	rows := []row{
+		{metric.column + " mean", metricA.Mean, metricB.Mean},
+		{metric.column + " min", metricA.Min, metricB.Min},
+		{metric.column + " max", metricA.Max, metricB.Max},
+	}
+	// 已用内存和已用交换空间总是列出，与对比指标相同时不重复
+	for _, series := range []struct {
+		column string
+		a, b   seriesStats
+	}{
+		{"mem_used", statsA.MemUsed, statsB.MemUsed},
+		{"swp_used", statsA.SwapUsed, statsB.SwapUsed},
+	} {
+		if series.column != metric.column {
+			rows = append(rows, row{series.column + " mean", series.a.Mean, series.b.Mean}, row{series.column + " peak", series.a.Max, series.b.Max})
+		}
+	}
+	for _, row := range rows {
+		fmt.Fprintf(&s, "  %-20s %12s %12s %12s\n", row.name,
+			FormatValue(row.a, precision), FormatValue(row.b, precision), FormatValue(row.b-row.a, precision))
+	}
+	return s.String()
+}
+
+// summarizeMetric 计算一段数据中对比指标的统计值
+func summarizeMetric(data []MemoryRecord, metric compareMetric) seriesStats {
+	values := make([]float64, len(data))
+	for i, record := range data {
+		values[i] = metric.value(record)
+	}
+	return summarize(values)
+}
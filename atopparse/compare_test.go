@@ -0,0 +1,68 @@
+package atopparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCompareMetric(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"", false},
+		{"used", false},
+		{"free", false},
+		{"swap_used", false},
+		{"used_pct", false},
+		{"mem_free", true},
+		{"USED", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCompareMetric(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCompareMetric(%q) = %v，期望出错: %t", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatComparisonMetric(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	period := func(label string, free, swapFree float64) ComparePeriod {
+		return ComparePeriod{Label: label, Data: []MemoryRecord{
+			{Timestamp: start, MemTotal: 16, MemFree: free, SwapTotal: 2, SwapFree: swapFree},
+			{Timestamp: start.Add(10 * time.Second), MemTotal: 16, MemFree: free - 2, SwapTotal: 2, SwapFree: swapFree},
+		}}
+	}
+	a, b := period("before", 8, 2), period("after", 4, 1)
+
+	tests := []struct {
+		metric   string
+		want     []string
+		wantNone []string
+	}{
+		{"", []string{"mem_used mean", "mem_used max", "swp_used peak"}, []string{"mem_used peak"}},
+		{"free", []string{"mem_free mean 7.00 3.00 -4.00", "mem_free min 6.00", "mem_used peak", "swp_used mean"}, nil},
+		{"swap_used", []string{"swp_used max 0.00 1.00 1.00", "mem_used peak"}, []string{"swp_used peak"}},
+		{"used_pct", []string{"对比指标 mem_used_pct，单位 %", "mem_used_pct mean 56.25 81.25 25.00"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			table := formatComparison(a, b, lookupCompareMetric(tt.metric), 2)
+			// 按空白折叠后比较，不依赖列宽
+			table = strings.Join(strings.Fields(table), " ")
+			for _, want := range tt.want {
+				if !strings.Contains(table, want) {
+					t.Errorf("对比表中没有 %q:\n%s", want, table)
+				}
+			}
+			for _, unwanted := range tt.wantNone {
+				if strings.Contains(table, unwanted) {
+					t.Errorf("对比表中不应有 %q:\n%s", unwanted, table)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// CPU汇总行形如 "CPU | sys 2% | user 5% | irq 0% | idle 393% | wait 0% |"，
+// 百分比按所有核心累加，多核主机上idle可以超过100%；小写的cpu行是单个核心，由parseCPUCore解析。
+// wait（iowait）在部分atop版本或配置下没有，单独记录是否存在
+var (
+	cpuLineRegex = regexp.MustCompile(`^CPU \|`)
+	cpuSysRegex  = regexp.MustCompile(`\|\s*sys\s+([\d.]+)%`)
+	cpuUserRegex = regexp.MustCompile(`\|\s*user\s+([\d.]+)%`)
+	cpuIdleRegex = regexp.MustCompile(`\|\s*idle\s+([\d.]+)%`)
+	cpuWaitRegex = regexp.MustCompile(`\|\s*wait\s+([\d.]+)%`)
+)
+
+// cpuColumns 日志中有CPU数据时追加到CSV的列
+var cpuColumns = []string{"cpu_sys", "cpu_user", "cpu_idle"}
+
+// cpuWaitColumn 是紧跟在cpuColumns之后的iowait列，CPU行没有wait字段的记录留空
+const cpuWaitColumn = "cpu_wait"
+
+// cpuSample 一行CPU汇总行中的百分比
+type cpuSample struct {
+	Sys, User, Idle float64
+	HasWait         bool
+	Wait            float64
+}
+
+// parseCPU 从CPU汇总行中取出sys/user/idle百分比以及可选的wait百分比，sys/user/idle都存在时ok为true，数值格式错误时返回错误
+func parseCPU(line string) (cpuSample, bool, error) {
+	if !cpuLineRegex.MatchString(line) {
+		return cpuSample{}, false, nil
+	}
+	sysMatch := cpuSysRegex.FindStringSubmatch(line)
+	userMatch := cpuUserRegex.FindStringSubmatch(line)
+	idleMatch := cpuIdleRegex.FindStringSubmatch(line)
+	if sysMatch == nil || userMatch == nil || idleMatch == nil {
+		return cpuSample{}, false, nil
+	}
+	values, err := parseNumbers(sysMatch[1], userMatch[1], idleMatch[1])
+	if err != nil {
+		return cpuSample{}, false, err
+	}
+	sample := cpuSample{Sys: values[0], User: values[1], Idle: values[2]}
+	if waitMatch := cpuWaitRegex.FindStringSubmatch(line); waitMatch != nil {
+		wait, err := parseNumbers(waitMatch[1])
+		if err != nil {
+			return cpuSample{}, false, err
+		}
+		sample.HasWait, sample.Wait = true, wait[0]
+	}
+	return sample, true, nil
+}
+
+// hasCPU 判断是否有记录带有CPU数据，决定CSV是否输出CPU列
+func hasCPU(data []MemoryRecord) bool {
+	for _, record := range data {
+		if record.HasCPU {
+			return true
+		}
+	}
+	return false
+}
+
+// cpuValues 返回记录的CPU列和cpu_wait列，没有CPU数据的记录留空，CPU行没有wait字段时cpu_wait留空
+func cpuValues(record MemoryRecord, precision int) []string {
+	if !record.HasCPU {
+		return []string{"", "", "", ""}
+	}
+	wait := ""
+	if record.HasCPUWait {
+		wait = FormatValue(record.CPUWait, precision)
+	}
+	return []string{
+		FormatValue(record.CPUSys, precision),
+		FormatValue(record.CPUUser, precision),
+		FormatValue(record.CPUIdle, precision),
+		wait,
+	}
+}
+
+// cpuRecords 返回带有CPU数据的记录
+func cpuRecords(data []MemoryRecord) []MemoryRecord {
+	return FilterRecords(data, func(record MemoryRecord) bool { return record.HasCPU })
+}
+
+// generateCPUChart 绘制CPU sys/user/idle百分比随时间的变化，有wait字段的记录另外绘制iowait曲线
+func generateCPUChart(data []MemoryRecord, outputFile string) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的CPU数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "CPU Utilization"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "CPU (%)"
+	p.X.Tick.Marker = timeAxis()
+
+	sysData := make(plotter.XYs, len(data))
+	userData := make(plotter.XYs, len(data))
+	idleData := make(plotter.XYs, len(data))
+	for i, record := range data {
+		x := timeAxisX(record.Timestamp)
+		sysData[i].X, sysData[i].Y = x, record.CPUSys
+		userData[i].X, userData[i].Y = x, record.CPUUser
+		idleData[i].X, idleData[i].Y = x, record.CPUIdle
+	}
+	// iowait高同时伴随换页时通常是内存不足引起的抖动，只画带有wait字段的样本
+	var waitData plotter.XYs
+	for _, record := range data {
+		if record.HasCPUWait {
+			waitData = append(waitData, plotter.XY{X: timeAxisX(record.Timestamp), Y: record.CPUWait})
+		}
+	}
+
+	series := []struct {
+		data  plotter.XYs
+		color color.RGBA
+		label string
+	}{
+		{sysData, color.RGBA{R: 255, A: 255}, "sys (%)"},
+		{userData, color.RGBA{B: 255, A: 255}, "user (%)"},
+		{idleData, color.RGBA{G: 160, A: 255}, "idle (%)"},
+	}
+	if len(waitData) > 0 {
+		series = append(series, struct {
+			data  plotter.XYs
+			color color.RGBA
+			label string
+		}{waitData, color.RGBA{R: 255, G: 140, A: 255}, "wait (%)"})
+	}
+	for _, s := range series {
+		line, err := plotter.NewLine(s.data)
+		if err != nil {
+			return err
+		}
+		line.Color = s.color
+		p.Add(line)
+		p.Legend.Add(s.label, line)
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
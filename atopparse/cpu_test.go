@@ -0,0 +1,116 @@
+package atopparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCPUWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantOK   bool
+		wantWait bool
+		want     cpuSample
+	}{
+		{
+			name:     "with wait",
+			line:     "CPU | sys 2% | user 5% | irq 0% | idle 373% | wait 20% | steal 0% |",
+			wantOK:   true,
+			wantWait: true,
+			want:     cpuSample{Sys: 2, User: 5, Idle: 373, HasWait: true, Wait: 20},
+		},
+		{
+			name:   "without wait",
+			line:   "CPU | sys 2% | user 5% | irq 0% | idle 393% |",
+			wantOK: true,
+			want:   cpuSample{Sys: 2, User: 5, Idle: 393},
+		},
+		{
+			name: "per core line",
+			line: "cpu | sys 1% | user 2% | irq 0% | idle 97% | cpu000 w 0% |",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, ok, err := parseCPU(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("ok为 %t，期望 %t", ok, tt.wantOK)
+			}
+			if sample != tt.want {
+				t.Errorf("解析结果为 %+v，期望 %+v", sample, tt.want)
+			}
+		})
+	}
+}
+
+func TestCPUWaitCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      string
+		wantWait string
+	}{
+		{"with wait", "CPU | sys 2% | user 5% | irq 0% | idle 373% | wait 20% |\n", "20.00"},
+		{"without wait", "CPU | sys 2% | user 5% | irq 0% | idle 393% |\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := parseTestLog(t, `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+`+tt.log, ParseOptions{})
+			path := filepath.Join(t.TempDir(), "report.csv")
+			if err := writeCSV(data, path, ReportOptions{Precision: 2}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(content), "\n")
+			header, row := strings.Split(lines[0], ","), strings.Split(lines[1], ",")
+			column := -1
+			for i, name := range header {
+				if name == cpuWaitColumn {
+					column = i
+				}
+			}
+			if column < 0 || header[column-1] != "cpu_idle" {
+				t.Fatalf("cpu_wait列应紧跟在cpu_idle之后: %s", lines[0])
+			}
+			if row[column] != tt.wantWait {
+				t.Errorf("cpu_wait列为 %q，期望 %q", row[column], tt.wantWait)
+			}
+
+			// 读回CSV时保留是否有wait字段
+			records, err := ReadRecordsCSV(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if records[0].HasCPUWait != (tt.wantWait != "") || records[0].CPUWait != data[0].CPUWait {
+				t.Errorf("读回的记录为 %+v，期望wait %v", records[0], data[0].CPUWait)
+			}
+		})
+	}
+}
+
+func TestGenerateCPUChartWait(t *testing.T) {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := []MemoryRecord{
+		{Timestamp: ts, HasCPU: true, CPUSys: 2, CPUUser: 5, CPUIdle: 373, HasCPUWait: true, CPUWait: 20},
+		{Timestamp: ts.Add(10 * time.Second), HasCPU: true, CPUSys: 2, CPUUser: 5, CPUIdle: 393},
+	}
+	path := filepath.Join(t.TempDir(), "cpu.png")
+	if err := generateCPUChart(data, path); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("没有生成CPU图表: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette/moreland"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// 单核cpu行形如 "cpu | sys 1% | user 2% | irq 0% | idle 97% | cpu000 w 0% |"，
+// 每个采样块中每个核心一行，百分比只针对该核心（0-100%）；核心编号和wait在同一个字段中
+var (
+	cpuCoreLineRegex = regexp.MustCompile(`^cpu \|`)
+	cpuCoreWaitRegex = regexp.MustCompile(`\|\s*cpu(\d+)\s+w\s+([\d.]+)%`)
+)
+
+// maxHeatmapColumns CPU热力图的最大列数，样本更多时相邻的列合并取平均
+const maxHeatmapColumns = 600
+
+// CoreRecord 一个采样块中单个核心的利用率，不写入CSV
+type CoreRecord struct {
+	Core int
+	Busy float64 // 100 - idle - wait，限制在0-100之间
+}
+
+// parseCPUCore 从单核cpu行中取出核心编号和利用率，不是单核cpu行或缺少字段时ok为false，数值格式错误时返回错误
+func parseCPUCore(line string) (CoreRecord, bool, error) {
+	if !cpuCoreLineRegex.MatchString(line) {
+		return CoreRecord{}, false, nil
+	}
+	idleMatch := cpuIdleRegex.FindStringSubmatch(line)
+	coreMatch := cpuCoreWaitRegex.FindStringSubmatch(line)
+	if idleMatch == nil || coreMatch == nil {
+		return CoreRecord{}, false, nil
+	}
+	core, err := strconv.Atoi(coreMatch[1])
+	if err != nil {
+		return CoreRecord{}, false, err
+	}
+	values, err := parseNumbers(idleMatch[1], coreMatch[2])
+	if err != nil {
+		return CoreRecord{}, false, err
+	}
+	busy := math.Max(0, math.Min(100, 100-values[0]-values[1]))
+	return CoreRecord{Core: core, Busy: busy}, true, nil
+}
+
+// HasCores 判断是否有记录带有单核cpu行的数据，--cpu-heatmap只在有时生成
+func HasCores(data []MemoryRecord) bool {
+	for _, record := range data {
+		if len(record.Cores) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// heatmapRow 热力图的一行：一个主机上的一个核心
+type heatmapRow struct {
+	host string
+	core int
+}
+
+// cpuHeatmapGrid 实现plotter.GridXYZ：列为采样时间，行为核心，缺少的样本为NaN
+type cpuHeatmapGrid struct {
+	xs     []float64
+	values [][]float64 // values[列][行]
+	rows   int
+}
+
+func (g cpuHeatmapGrid) Dims() (c, r int)   { return len(g.xs), g.rows }
+func (g cpuHeatmapGrid) X(c int) float64    { return g.xs[c] }
+func (g cpuHeatmapGrid) Y(r int) float64    { return float64(r) }
+func (g cpuHeatmapGrid) Z(c, r int) float64 { return g.values[c][r] }
+
+// buildCPUHeatmap 将带有单核数据的记录排成网格。行按主机和核心编号排序，各样本的核心数可以不同，
+// 某个采样时间没有某个核心时该格为NaN；相邻样本的间隔超过预期采样间隔的missingGapFactor倍时
+// 在两侧各插入一列NaN，使缺少数据的时间段显示为空白而不是被两侧的格子拉宽
+func buildCPUHeatmap(data []MemoryRecord) (cpuHeatmapGrid, []heatmapRow) {
+	rowIndex := make(map[heatmapRow]int)
+	var rows []heatmapRow
+	columns := make(map[time.Time]map[heatmapRow]float64)
+	var times []time.Time
+	for _, record := range data {
+		if len(record.Cores) == 0 {
+			continue
+		}
+		cells, ok := columns[record.Timestamp]
+		if !ok {
+			cells = make(map[heatmapRow]float64)
+			columns[record.Timestamp] = cells
+			times = append(times, record.Timestamp)
+		}
+		for _, core := range record.Cores {
+			row := heatmapRow{RecordHost(record), core.Core}
+			if _, ok := rowIndex[row]; !ok {
+				rowIndex[row] = 0
+				rows = append(rows, row)
+			}
+			cells[row] = core.Busy
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].host != rows[j].host {
+			return rows[i].host < rows[j].host
+		}
+		return rows[i].core < rows[j].core
+	})
+	for i, row := range rows {
+		rowIndex[row] = i
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	grid := cpuHeatmapGrid{rows: len(rows)}
+	column := func(ts time.Time, cells map[heatmapRow]float64) {
+		values := make([]float64, len(rows))
+		for i := range values {
+			values[i] = math.NaN()
+		}
+		for row, busy := range cells {
+			values[rowIndex[row]] = busy
+		}
+		grid.xs = append(grid.xs, timeAxisX(ts))
+		grid.values = append(grid.values, values)
+	}
+	interval := ExpectedInterval(data)
+	for i, ts := range times {
+		if i > 0 && interval > 0 && ts.Sub(times[i-1]) > missingGapFactor*interval {
+			column(times[i-1].Add(interval), nil)
+			column(ts.Add(-interval), nil)
+		}
+		column(ts, columns[ts])
+	}
+	return mergeHeatmapColumns(grid, maxHeatmapColumns), rows
+}
+
+// mergeHeatmapColumns 列数超过maxColumns时将相邻的列合并，各格取非NaN值的平均，全部为NaN时仍为NaN
+func mergeHeatmapColumns(grid cpuHeatmapGrid, maxColumns int) cpuHeatmapGrid {
+	if len(grid.xs) <= maxColumns {
+		return grid
+	}
+	size := (len(grid.xs) + maxColumns - 1) / maxColumns
+	merged := cpuHeatmapGrid{rows: grid.rows}
+	for start := 0; start < len(grid.xs); start += size {
+		end := start + size
+		if end > len(grid.xs) {
+			end = len(grid.xs)
+		}
+		var x float64
+		sums, counts := make([]float64, grid.rows), make([]int, grid.rows)
+		for c := start; c < end; c++ {
+			x += grid.xs[c]
+			for r, v := range grid.values[c] {
+				if !math.IsNaN(v) {
+					sums[r] += v
+					counts[r]++
+				}
+			}
+		}
+		values := make([]float64, grid.rows)
+		for r := range values {
+			values[r] = math.NaN()
+			if counts[r] > 0 {
+				values[r] = sums[r] / float64(counts[r])
+			}
+		}
+		merged.xs = append(merged.xs, x/float64(end-start))
+		merged.values = append(merged.values, values)
+	}
+	return merged
+}
+
+// generateCPUHeatmap 绘制各核心利用率的热力图：X轴为时间，Y轴为核心，颜色由暗到亮表示0-100%，缺少的样本为浅蓝色
+func generateCPUHeatmap(data []MemoryRecord, outputFile string) error {
+	grid, rows := buildCPUHeatmap(data)
+	if len(rows) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的单核CPU数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "CPU Core Busy (%): dark 0 - bright 100"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Core"
+	p.X.Tick.Marker = timeAxis()
+
+	// 多个主机时标签带上主机名
+	hosts := make(map[string]bool)
+	for _, row := range rows {
+		hosts[row.host] = true
+	}
+	ticks := make([]plot.Tick, len(rows))
+	for i, row := range rows {
+		label := fmt.Sprintf("cpu%03d", row.core)
+		if len(hosts) > 1 {
+			label = row.host + " " + label
+		}
+		ticks[i] = plot.Tick{Value: float64(i), Label: label}
+	}
+	p.Y.Tick.Marker = plot.ConstantTicks(ticks)
+
+	// 黑体辐射配色由黑经红、黄到白，亮度随利用率单调增加
+	colors := moreland.BlackBody()
+	colors.SetMin(0)
+	colors.SetMax(100)
+	heatmap := plotter.NewHeatMap(grid, colors.Palette(101))
+	heatmap.Min, heatmap.Max = 0, 100
+	heatmap.NaN = color.RGBA{R: 170, G: 190, B: 220, A: 255}
+	p.Add(heatmap)
+
+	// 核心较多时加高图片，使每行至少有可辨认的高度
+	height := 4 * vg.Inch
+	if rowHeight := vg.Length(len(rows)) * 0.15 * vg.Inch; rowHeight > height {
+		height = rowHeight
+	}
+	return p.Save(8*vg.Inch, height, outputFile)
+}
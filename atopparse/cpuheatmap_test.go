@@ -0,0 +1,152 @@
+package atopparse
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCPUCore(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		wantOK bool
+		want   CoreRecord
+	}{
+		{"busy core", "cpu | sys 10% | user 60% | irq 0% | idle 25% | cpu003 w 5% |", true, CoreRecord{Core: 3, Busy: 70}},
+		{"idle core", "cpu | sys 0% | user 0% | irq 0% | idle 100% | cpu000 w 0% |", true, CoreRecord{Core: 0, Busy: 0}},
+		{"clamped", "cpu | sys 0% | user 0% | irq 0% | idle 101% | cpu001 w 0% |", true, CoreRecord{Core: 1, Busy: 0}},
+		{"summary line", "CPU | sys 2% | user 5% | irq 0% | idle 393% | wait 0% |", false, CoreRecord{}},
+		{"without core field", "cpu | sys 1% | user 2% | irq 0% | idle 97% |", false, CoreRecord{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, ok, err := parseCPUCore(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK || core != tt.want {
+				t.Errorf("解析结果为 %+v ok=%t，期望 %+v ok=%t", core, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// coreLog 生成带单核cpu行的atop日志：每个元素为距10:00:00的秒数和各核心的利用率
+func coreLog(samples []struct {
+	offset int
+	busy   []float64
+}) string {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	var log strings.Builder
+	for _, sample := range samples {
+		fmt.Fprintf(&log, "ATOP - web1  %s  --------  10s elapsed\n", start.Add(time.Duration(sample.offset)*time.Second).Format("2006/01/02  15:04:05"))
+		log.WriteString("MEM | tot 16.0G | free 4.0G |\n")
+		log.WriteString("SWP | tot 2.0G | free 1.5G |\n")
+		for core, busy := range sample.busy {
+			fmt.Fprintf(&log, "cpu | sys 0%% | user %g%% | irq 0%% | idle %g%% | cpu%03d w 0%% |\n", busy, 100-busy, core)
+		}
+	}
+	return log.String()
+}
+
+func TestBuildCPUHeatmap(t *testing.T) {
+	nan := math.NaN()
+	type sample = struct {
+		offset int
+		busy   []float64
+	}
+	tests := []struct {
+		name     string
+		samples  []sample
+		wantRows int
+		want     [][]float64 // 各列的值，NaN表示空白
+	}{
+		{
+			name:     "fixed core count",
+			samples:  []sample{{0, []float64{10, 90}}, {10, []float64{20, 80}}},
+			wantRows: 2,
+			want:     [][]float64{{10, 90}, {20, 80}},
+		},
+		{
+			name:     "core added later",
+			samples:  []sample{{0, []float64{10}}, {10, []float64{20, 80}}},
+			wantRows: 2,
+			want:     [][]float64{{10, nan}, {20, 80}},
+		},
+		{
+			name:     "missing samples",
+			samples:  []sample{{0, []float64{10}}, {10, []float64{20}}, {120, []float64{30}}},
+			wantRows: 1,
+			want:     [][]float64{{10}, {20}, {nan}, {nan}, {30}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := parseTestLog(t, coreLog(tt.samples), ParseOptions{})
+			grid, rows := buildCPUHeatmap(data)
+			if len(rows) != tt.wantRows {
+				t.Fatalf("热力图有 %d 行，期望 %d 行", len(rows), tt.wantRows)
+			}
+			if len(grid.values) != len(tt.want) {
+				t.Fatalf("热力图有 %d 列，期望 %d 列", len(grid.values), len(tt.want))
+			}
+			for c, column := range tt.want {
+				for r, want := range column {
+					got := grid.Z(c, r)
+					if math.IsNaN(want) != math.IsNaN(got) || (!math.IsNaN(want) && math.Abs(got-want) > 1e-9) {
+						t.Errorf("第 %d 列第 %d 行为 %v，期望 %v", c, r, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMergeHeatmapColumns(t *testing.T) {
+	nan := math.NaN()
+	grid := cpuHeatmapGrid{
+		xs:     []float64{0, 10, 20, 30, 40},
+		values: [][]float64{{10, nan}, {30, nan}, {50, 40}, {nan, nan}, {70, 60}},
+		rows:   2,
+	}
+	merged := mergeHeatmapColumns(grid, 3)
+	want := [][]float64{{20, nan}, {50, 40}, {70, 60}}
+	if len(merged.xs) != len(want) || merged.xs[0] != 5 || merged.xs[2] != 40 {
+		t.Fatalf("合并后的列为 %v，期望3列", merged.xs)
+	}
+	for c, column := range want {
+		for r, w := range column {
+			got := merged.values[c][r]
+			if math.IsNaN(w) != math.IsNaN(got) || (!math.IsNaN(w) && got != w) {
+				t.Errorf("第 %d 列第 %d 行为 %v，期望 %v", c, r, got, w)
+			}
+		}
+	}
+}
+
+func TestGenerateCPUHeatmap(t *testing.T) {
+	data, _ := parseTestLog(t, coreLog([]struct {
+		offset int
+		busy   []float64
+	}{{0, []float64{10, 90}}, {10, []float64{20}}, {60, []float64{30, 70, 50}}}), ParseOptions{})
+	path := filepath.Join(t.TempDir(), "heatmap.png")
+	if err := generateCPUHeatmap(data, path); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("没有生成CPU热力图: %v", err)
+	}
+
+	// 没有单核数据时报错，由GenerateReport先用HasCores判断
+	if HasCores([]MemoryRecord{{MemTotal: 16}}) {
+		t.Error("没有单核数据时HasCores应为false")
+	}
+	if err := generateCPUHeatmap([]MemoryRecord{{MemTotal: 16}}, path); err == nil {
+		t.Error("没有单核数据时应返回错误")
+	}
+}
@@ -0,0 +1,325 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+var deriveNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DerivedSeries 一个由--derive定义的派生指标
+type DerivedSeries struct {
+	Name string
+	Expr string
+	root exprNode
+}
+
+// deriveFieldNames 返回表达式中可用的变量名，与规则文件支持的指标相同（单位GB）
+func deriveFieldNames() []string {
+	return append(MetricNames[:len(MetricNames):len(MetricNames)], "mem_used", "swp_used")
+}
+
+// deriveAliases 表达式中可用的变量别名，对应atop输出中的字段名（tot、cache、buff）
+var deriveAliases = map[string]string{
+	"mem_total": "mem_tot",
+	"swp_total": "swp_tot",
+	"cache":     "mem_cache",
+	"buff":      "mem_buff",
+}
+
+// ParseDerivedSeries 解析 "name=expression" 形式的派生指标定义
+func ParseDerivedSeries(specs []string) ([]DerivedSeries, error) {
+	used := make(map[string]bool)
+	for _, column := range csvHeader {
+		used[column] = true
+	}
+	for _, column := range usagePctColumns {
+		used[column] = true
+	}
+	for _, column := range cpuColumns {
+		used[column] = true
+	}
+	used[memAvailColumn] = true
+	used[cpuWaitColumn] = true
+	used[hostColumn] = true
+	used[elapsedColumn] = true
+
+	var series []DerivedSeries
+	for _, spec := range specs {
+		name, expr, found := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !found || strings.TrimSpace(expr) == "" {
+			return nil, fmt.Errorf(Tr("%q 应为 name=expression 形式"), spec)
+		}
+		if !deriveNameRegex.MatchString(name) {
+			return nil, fmt.Errorf(Tr("派生指标名 %q 只能包含字母、数字和下划线，且不能以数字开头"), name)
+		}
+		if used[name] {
+			return nil, fmt.Errorf(Tr("派生指标名 %q 与已有的CSV列重复"), name)
+		}
+		root, err := parseExpr(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		used[name] = true
+		series = append(series, DerivedSeries{Name: name, Expr: strings.TrimSpace(expr), root: root})
+	}
+	return series, nil
+}
+
+// eval 对一条记录求值；除数为0或结果不是有限数值时ok为false
+func (d DerivedSeries) eval(record MemoryRecord) (value float64, ok bool) {
+	value, ok = d.root.eval(record)
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+// exprNode 表达式语法树的节点
+type exprNode interface {
+	eval(record MemoryRecord) (float64, bool)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(MemoryRecord) (float64, bool) { return float64(n), true }
+
+type fieldNode string
+
+func (f fieldNode) eval(record MemoryRecord) (float64, bool) {
+	return RuleMetricValue(record, string(f)), true
+}
+
+type negateNode struct{ operand exprNode }
+
+func (n negateNode) eval(record MemoryRecord) (float64, bool) {
+	value, ok := n.operand.eval(record)
+	return -value, ok
+}
+
+type binaryNode struct {
+	op          byte
+	left, right exprNode
+}
+
+func (b binaryNode) eval(record MemoryRecord) (float64, bool) {
+	left, ok := b.left.eval(record)
+	if !ok {
+		return 0, false
+	}
+	right, ok := b.right.eval(record)
+	if !ok {
+		return 0, false
+	}
+	switch b.op {
+	case '+':
+		return left + right, true
+	case '-':
+		return left - right, true
+	case '*':
+		return left * right, true
+	default:
+		if right == 0 {
+			return 0, false
+		}
+		return left / right, true
+	}
+}
+
+// exprParser 按 加减 < 乘除 < 一元负号 < 括号 的优先级递归下降解析表达式
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func parseExpr(input string) (exprNode, error) {
+	p := &exprParser{input: input}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf(Tr("表达式第 %d 个字符 %q 无法识别"), p.pos+1, p.input[p.pos])
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) || (p.input[p.pos] != '+' && p.input[p.pos] != '-') {
+			return left, nil
+		}
+		op := p.input[p.pos]
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) || (p.input[p.pos] != '*' && p.input[p.pos] != '/') {
+			return left, nil
+		}
+		op := p.input[p.pos]
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '-' {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negateNode{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf(Tr("表达式不完整"))
+	}
+
+	start := p.pos
+	c := p.input[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return nil, fmt.Errorf(Tr("表达式缺少右括号"))
+		}
+		p.pos++
+		return node, nil
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf(Tr("无效的数字 %q"), p.input[start:p.pos])
+		}
+		return numberNode(value), nil
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.input) && (p.input[p.pos] == '_' || p.input[p.pos] >= 'a' && p.input[p.pos] <= 'z' ||
+			p.input[p.pos] >= 'A' && p.input[p.pos] <= 'Z' || p.input[p.pos] >= '0' && p.input[p.pos] <= '9') {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		if alias, ok := deriveAliases[name]; ok {
+			name = alias
+		}
+		if !ValidRuleMetric(name) {
+			return nil, fmt.Errorf(Tr("未知的变量 %q，可用变量: %s"), name, strings.Join(deriveFieldNames(), ", "))
+		}
+		return fieldNode(name), nil
+	default:
+		return nil, fmt.Errorf(Tr("表达式第 %d 个字符 %q 无法识别"), p.pos+1, c)
+	}
+}
+
+// derivedValues 计算每条记录的派生指标，无法求值的位置写为空
+func derivedValues(series []DerivedSeries, record MemoryRecord, precision int) []string {
+	values := make([]string, len(series))
+	for i, s := range series {
+		if value, ok := s.eval(record); ok {
+			values[i] = FormatValue(value, precision)
+		}
+	}
+	return values
+}
+
+// derivedColors 派生指标曲线依次使用的颜色
+var derivedColors = []color.RGBA{
+	{R: 31, G: 119, B: 180, A: 255},
+	{R: 255, G: 127, B: 14, A: 255},
+	{R: 44, G: 160, B: 44, A: 255},
+	{R: 214, G: 39, B: 40, A: 255},
+	{R: 148, G: 103, B: 189, A: 255},
+}
+
+// generateDerivedChart 将派生指标绘制在单独的图表中，无法求值的样本处断开曲线
+func generateDerivedChart(data []MemoryRecord, series []DerivedSeries, outputFile string) error {
+	p := plot.New()
+	p.Title.Text = "Derived Metrics"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Value"
+	p.X.Tick.Marker = timeAxis()
+
+	for i, s := range series {
+		var segments []plotter.XYs
+		var current plotter.XYs
+		for _, record := range data {
+			value, ok := s.eval(record)
+			if !ok {
+				if len(current) > 0 {
+					segments = append(segments, current)
+					current = nil
+				}
+				continue
+			}
+			current = append(current, plotter.XY{X: timeAxisX(record.Timestamp), Y: value})
+		}
+		if len(current) > 0 {
+			segments = append(segments, current)
+		}
+
+		lineColor := derivedColors[i%len(derivedColors)]
+		for j, segment := range segments {
+			line, err := plotter.NewLine(segment)
+			if err != nil {
+				return err
+			}
+			line.Color = lineColor
+			p.Add(line)
+			if j == 0 {
+				p.Legend.Add(s.Name, line)
+			}
+		}
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
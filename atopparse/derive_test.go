@@ -0,0 +1,103 @@
+package atopparse
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDerivedSeriesEval(t *testing.T) {
+	record := MemoryRecord{Timestamp: time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC),
+		MemTotal: 16, MemFree: 4, MemCache: 2, MemBuff: 0.5, SwapTotal: 2, SwapFree: 1.5}
+	noSwap := record
+	noSwap.SwapTotal, noSwap.SwapFree = 0, 0
+
+	tests := []struct {
+		name   string
+		expr   string
+		record MemoryRecord
+		want   float64
+		wantOK bool
+	}{
+		{"request sample", "(mem_total - mem_free - cache) / mem_total", record, 0.625, true},
+		{"canonical names", "(mem_tot - mem_free - mem_cache) / mem_tot", record, 0.625, true},
+		{"multiplication before addition", "1 + 2 * 3", record, 7, true},
+		{"division before subtraction", "mem_tot - mem_free / 2", record, 14, true},
+		{"left associative", "16 / 4 / 2", record, 2, true},
+		{"parentheses", "(1 + 2) * 3", record, 9, true},
+		{"unary minus", "-mem_free + 10", record, 6, true},
+		{"unary minus binds tighter than multiplication", "-2 * -3", record, 6, true},
+		{"double negation", "--swp_used", record, 0.5, true},
+		{"derived fields", "mem_used + swp_used", record, 12.5, true},
+		{"division by constant zero", "mem_free / 0", record, 0, false},
+		{"division by zero field", "swp_used / swp_tot", noSwap, 0, false},
+		{"zero divided by zero", "(mem_free - mem_free) / (swp_tot - swp_tot)", record, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := ParseDerivedSeries([]string{"x=" + tt.expr})
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, ok := series[0].eval(tt.record)
+			if ok != tt.wantOK || math.Abs(value-tt.want) > 1e-12 {
+				t.Errorf("%s 求值为 %v（ok=%t），期望 %v（ok=%t）", tt.expr, value, ok, tt.want, tt.wantOK)
+			}
+			// 无法求值时CSV单元格留空而不是Inf或NaN
+			cells := derivedValues(series, tt.record, 2)
+			if !tt.wantOK && cells[0] != "" {
+				t.Errorf("无法求值时单元格为 %q，期望为空", cells[0])
+			}
+		})
+	}
+}
+
+func TestParseDerivedSeriesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		wantErr string
+	}{
+		{"unknown field", []string{"x=mem_total - mem_avail"}, `未知的变量 "mem_avail"`},
+		{"missing right parenthesis", []string{"x=(mem_tot - mem_free"}, "缺少右括号"},
+		{"extra right parenthesis", []string{"x=mem_tot - mem_free)"}, `第 19 个字符 ')'`},
+		{"trailing token", []string{"x=mem_tot mem_free"}, `第 9 个字符 'm'`},
+		{"dangling operator", []string{"x=mem_tot -"}, "表达式不完整"},
+		{"unsupported operator", []string{"x=mem_tot % 2"}, `'%'`},
+		{"invalid number", []string{"x=1.2.3"}, `无效的数字 "1.2.3"`},
+		{"missing expression", []string{"x="}, "name=expression"},
+		{"missing equals sign", []string{"mem_tot"}, "name=expression"},
+		{"invalid name", []string{"1x=mem_tot"}, "不能以数字开头"},
+		{"duplicate name", []string{"x=mem_tot", "x=mem_free"}, `"x" 与已有的CSV列重复`},
+		{"collides with csv column", []string{"mem_used_pct=mem_used / mem_tot * 100"}, `"mem_used_pct" 与已有的CSV列重复`},
+		{"collides with cpu column", []string{"cpu_wait=mem_tot"}, `"cpu_wait" 与已有的CSV列重复`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDerivedSeries(tt.specs)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("错误为 %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDerivedSeriesNames(t *testing.T) {
+	series, err := ParseDerivedSeries([]string{" used_ratio = (mem_total - mem_free - cache) / mem_total ", "swap_ratio=swp_used/swp_tot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names, exprs []string
+	for _, s := range series {
+		names = append(names, s.Name)
+		exprs = append(exprs, s.Expr)
+	}
+	if !reflect.DeepEqual(names, []string{"used_ratio", "swap_ratio"}) {
+		t.Errorf("派生指标名为 %v", names)
+	}
+	if !reflect.DeepEqual(exprs, []string{"(mem_total - mem_free - cache) / mem_total", "swp_used/swp_tot"}) {
+		t.Errorf("表达式为 %q", exprs)
+	}
+}
@@ -0,0 +1,232 @@
+package atopparse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// dskRegex 匹配atop的DSK行（每个磁盘设备一条），取设备名、忙碌百分比以及读/写请求数；
+// 请求数较大时atop以 12e3 的形式输出
+var dskRegex = regexp.MustCompile(`^DSK \|\s*(\S+)\s*\|\s*busy\s+([\d.]+)%\s*\|\s*read\s+([\d.]+(?:e\d+)?)\s*\|\s*write\s+([\d.]+(?:e\d+)?)`)
+
+// DSK行中的吞吐量字段。MBr/s、MBw/s已经是每秒的读/写量(MB)；较旧版本atop只给出
+// 每个读/写请求的平均大小 KB/read、KB/writ（新版本写作 KiB/r、KiB/w），需要乘以采样间隔内的请求数再除以间隔
+var (
+	dskReadKBRegex  = regexp.MustCompile(`\|\s*(?:KB/read|KiB/r)\s+([\d.]+(?:e\d+)?)`)
+	dskWriteKBRegex = regexp.MustCompile(`\|\s*(?:KB/writ|KiB/w)\s+([\d.]+(?:e\d+)?)`)
+	dskReadMBRegex  = regexp.MustCompile(`\|\s*MBr/s\s+([\d.]+(?:e\d+)?)`)
+	dskWriteMBRegex = regexp.MustCompile(`\|\s*MBw/s\s+([\d.]+(?:e\d+)?)`)
+)
+
+// DiskRecord 某个磁盘设备在一个采样时间点的统计，Read/Write为采样间隔内的请求数
+type DiskRecord struct {
+	Timestamp time.Time
+	Device    string
+	Busy      float64 // 忙碌时间百分比
+	Read      float64
+	Write     float64
+
+	// 读/写吞吐量，统一换算为MB/s；DSK行没有吞吐量字段、或只有每请求大小而日志头没有采样间隔时HasThroughput为false
+	HasThroughput bool
+	ReadMBps      float64
+	WriteMBps     float64
+}
+
+// diskCSVHeader 是磁盘CSV的表头，每行为一个设备在一个时间点的统计
+var diskCSVHeader = []string{"timestamp", "device", "busy_pct", "read", "write", "read_mbps", "write_mbps"}
+
+// parseDisk 解析DSK行，不是DSK行或字段不完整时ok为false，数值格式错误时返回错误。
+// interval为所在采样块日志头中的采样间隔，用于把每请求的KB/read、KB/writ换算为MB/s
+func parseDisk(line string, timestamp time.Time, interval time.Duration) (DiskRecord, bool, error) {
+	matches := dskRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return DiskRecord{}, false, nil
+	}
+	values, err := parseNumbers(matches[2], matches[3], matches[4])
+	if err != nil {
+		return DiskRecord{}, false, err
+	}
+	disk := DiskRecord{Timestamp: timestamp, Device: matches[1], Busy: values[0], Read: values[1], Write: values[2]}
+
+	if readMB, writeMB := dskReadMBRegex.FindStringSubmatch(line), dskWriteMBRegex.FindStringSubmatch(line); readMB != nil && writeMB != nil {
+		// 每秒的吞吐量，与采样间隔无关
+		rates, err := parseNumbers(readMB[1], writeMB[1])
+		if err != nil {
+			return DiskRecord{}, false, err
+		}
+		disk.HasThroughput = true
+		disk.ReadMBps, disk.WriteMBps = rates[0], rates[1]
+	} else if readKB, writeKB := dskReadKBRegex.FindStringSubmatch(line), dskWriteKBRegex.FindStringSubmatch(line); readKB != nil && writeKB != nil && interval > 0 {
+		// 每请求的平均大小，乘以采样间隔内的请求数得到间隔内的总量
+		sizes, err := parseNumbers(readKB[1], writeKB[1])
+		if err != nil {
+			return DiskRecord{}, false, err
+		}
+		seconds := interval.Seconds()
+		disk.HasThroughput = true
+		disk.ReadMBps = disk.Read * sizes[0] / 1024 / seconds
+		disk.WriteMBps = disk.Write * sizes[1] / 1024 / seconds
+	}
+	return disk, true, nil
+}
+
+// DiskRecords 按时间顺序展开记录中的磁盘统计；时间戳以所属记录为准，
+// 因此校正时钟偏移等修改记录时间戳的处理之后仍然一致
+func DiskRecords(data []MemoryRecord) []DiskRecord {
+	var disks []DiskRecord
+	for _, record := range data {
+		for _, disk := range record.Disks {
+			disk.Timestamp = record.Timestamp
+			disks = append(disks, disk)
+		}
+	}
+	return disks
+}
+
+// diskDevices 返回出现过的设备名，按名称排序
+func diskDevices(disks []DiskRecord) []string {
+	seen := make(map[string]bool)
+	var devices []string
+	for _, disk := range disks {
+		if !seen[disk.Device] {
+			seen[disk.Device] = true
+			devices = append(devices, disk.Device)
+		}
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// writeDiskCSV 以 timestamp,device,busy_pct,read,write,read_mbps,write_mbps 的长格式写出磁盘统计。
+// 每行对应一个设备在一个时间点的统计，只在部分采样块中出现的设备只占有它出现的行，不影响其他设备；
+// 没有吞吐量数据的行read_mbps/write_mbps留空
+func writeDiskCSV(disks []DiskRecord, outputFile string, opts ReportOptions) error {
+	out, err := CreateOutput(outputFile, opts.Gzip)
+	if err != nil {
+		return err
+	}
+
+	writer := newCSVWriter(out, opts)
+	if err := writer.Write(diskCSVHeader); err != nil {
+		out.Abort()
+		return err
+	}
+	for _, disk := range disks {
+		row := []string{
+			disk.Timestamp.Format("2006-01-02 15:04:05"),
+			disk.Device,
+			FormatValue(disk.Busy, opts.Precision),
+			FormatValue(disk.Read, opts.Precision),
+			FormatValue(disk.Write, opts.Precision),
+			"",
+			"",
+		}
+		if disk.HasThroughput {
+			row[5] = FormatValue(disk.ReadMBps, opts.Precision)
+			row[6] = FormatValue(disk.WriteMBps, opts.Precision)
+		}
+		if err := writer.Write(row); err != nil {
+			out.Abort()
+			return err
+		}
+	}
+
+	if err := writeCSVFooter(out, writer, opts.Provenance); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// generateDiskBusyChart 绘制各磁盘设备的忙碌百分比，每个设备一条曲线，
+// 曲线只经过该设备出现的时间点
+func generateDiskBusyChart(disks []DiskRecord, outputFile string) error {
+	if len(disks) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的磁盘数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Disk Busy"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Busy (%)"
+	p.Y.Min = 0
+	p.X.Tick.Marker = timeAxis()
+
+	points := make(map[string]plotter.XYs)
+	for _, disk := range disks {
+		points[disk.Device] = append(points[disk.Device], plotter.XY{X: timeAxisX(disk.Timestamp), Y: disk.Busy})
+	}
+	for i, device := range diskDevices(disks) {
+		line, err := plotter.NewLine(points[device])
+		if err != nil {
+			return err
+		}
+		line.Color = derivedColors[i%len(derivedColors)]
+		p.Add(line)
+		p.Legend.Add(device, line)
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
+
+// throughputDisks 返回带有吞吐量数据的磁盘统计
+func throughputDisks(disks []DiskRecord) []DiskRecord {
+	var result []DiskRecord
+	for _, disk := range disks {
+		if disk.HasThroughput {
+			result = append(result, disk)
+		}
+	}
+	return result
+}
+
+// generateDiskThroughputChart 绘制各磁盘设备的读/写吞吐量(MB/s)，同一设备的读为实线、写为虚线，颜色相同
+func generateDiskThroughputChart(disks []DiskRecord, outputFile string) error {
+	if len(disks) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的磁盘吞吐量数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Disk Throughput"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Throughput (MB/s)"
+	p.Y.Min = 0
+	p.X.Tick.Marker = timeAxis()
+
+	read := make(map[string]plotter.XYs)
+	write := make(map[string]plotter.XYs)
+	for _, disk := range disks {
+		x := timeAxisX(disk.Timestamp)
+		read[disk.Device] = append(read[disk.Device], plotter.XY{X: x, Y: disk.ReadMBps})
+		write[disk.Device] = append(write[disk.Device], plotter.XY{X: x, Y: disk.WriteMBps})
+	}
+	for i, device := range diskDevices(disks) {
+		for _, direction := range []struct {
+			label  string
+			points plotter.XYs
+			dashed bool
+		}{
+			{"read", read[device], false},
+			{"write", write[device], true},
+		} {
+			line, err := plotter.NewLine(direction.points)
+			if err != nil {
+				return err
+			}
+			line.Color = derivedColors[i%len(derivedColors)]
+			if direction.dashed {
+				line.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+			}
+			p.Add(line)
+			p.Legend.Add(device+" "+direction.label, line)
+		}
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
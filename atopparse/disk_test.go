@@ -0,0 +1,95 @@
+package atopparse
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseDiskThroughput(t *testing.T) {
+	timestamp := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		line           string
+		interval       time.Duration
+		wantThroughput bool
+		wantRead       float64
+		wantWrite      float64
+	}{
+		{
+			name:           "per second MBr/s",
+			line:           "DSK | sda | busy 5% | read 100 | write 200 | MBr/s 1.5 | MBw/s 0.25 | avio 1.2 ms |",
+			interval:       10 * time.Second,
+			wantThroughput: true,
+			wantRead:       1.5,
+			wantWrite:      0.25,
+		},
+		{
+			name:           "per second ignores interval",
+			line:           "DSK | sda | busy 5% | read 100 | write 200 | MBr/s 1.5 | MBw/s 0.25 |",
+			interval:       0,
+			wantThroughput: true,
+			wantRead:       1.5,
+			wantWrite:      0.25,
+		},
+		{
+			name:           "per request KB/read over interval",
+			line:           "DSK | sda | busy 5% | read 100 | write 200 | KB/read 512 | KB/writ 256 | avio 1.2 ms |",
+			interval:       10 * time.Second,
+			wantThroughput: true,
+			wantRead:       100 * 512 / 1024.0 / 10, // 5 MB/s
+			wantWrite:      200 * 256 / 1024.0 / 10, // 5 MB/s
+		},
+		{
+			name:           "per request KiB/r over interval",
+			line:           "DSK | sda | busy 5% | read 120 | write 60 | KiB/r 128 | KiB/w 64 |",
+			interval:       60 * time.Second,
+			wantThroughput: true,
+			wantRead:       120 * 128 / 1024.0 / 60,
+			wantWrite:      60 * 64 / 1024.0 / 60,
+		},
+		{
+			name:     "per request without interval",
+			line:     "DSK | sda | busy 5% | read 100 | write 200 | KB/read 512 | KB/writ 256 |",
+			interval: 0,
+		},
+		{
+			name:     "no throughput fields",
+			line:     "DSK | sda | busy 5% | read 100 | write 200 |",
+			interval: 10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, ok, err := parseDisk(tt.line, timestamp, tt.interval)
+			if err != nil || !ok {
+				t.Fatalf("解析DSK行失败: ok=%t err=%v", ok, err)
+			}
+			if disk.Device != "sda" || disk.Busy != 5 {
+				t.Errorf("设备或忙碌百分比不正确: %+v", disk)
+			}
+			if disk.HasThroughput != tt.wantThroughput {
+				t.Fatalf("HasThroughput为 %t，期望 %t", disk.HasThroughput, tt.wantThroughput)
+			}
+			if math.Abs(disk.ReadMBps-tt.wantRead) > 1e-9 || math.Abs(disk.WriteMBps-tt.wantWrite) > 1e-9 {
+				t.Errorf("吞吐量为 %v/%v MB/s，期望 %v/%v", disk.ReadMBps, disk.WriteMBps, tt.wantRead, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestParseLogDiskThroughput(t *testing.T) {
+	data, _ := parseTestLog(t, `ATOP - web1  2024/06/11  10:00:00  --------  20s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+DSK | sda | busy 5% | read 40 | write 80 | KB/read 1024 | KB/writ 512 |
+`, ParseOptions{})
+	if len(data) != 1 || len(data[0].Disks) != 1 {
+		t.Fatalf("应解析出一条带一个磁盘的记录: %+v", data)
+	}
+	disk := data[0].Disks[0]
+	if !disk.HasThroughput || disk.ReadMBps != 2 || disk.WriteMBps != 2 {
+		t.Errorf("应按日志头的20秒间隔换算为2/2 MB/s，实际 %+v", disk)
+	}
+}
@@ -0,0 +1,4 @@
+// Package atopparse 解析atop文本日志中的内存（MEM/SWP）、PSI和CPU数据，
+// 并生成CSV、PNG、HTML等报告。命令行工具atop_parser_mem只是这些函数的一层参数封装，
+// 其他Go程序可以直接调用ParseLog、ParseDirectory和GenerateReport。
+package atopparse
@@ -0,0 +1,144 @@
+package atopparse
+
+import (
+	"time"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+// downsampleSegments 按各段的样本数比例分配maxPoints，对每段分别降采样；
+// 每段至少保留首尾两个点，maxPoints不大于0或总样本数不超过maxPoints时原样返回
+func downsampleSegments(segments [][]MemoryRecord, maxPoints int) [][]MemoryRecord {
+	total := 0
+	for _, segment := range segments {
+		total += len(segment)
+	}
+	if maxPoints <= 0 || total <= maxPoints {
+		return segments
+	}
+
+	downsampled := make([][]MemoryRecord, len(segments))
+	for i, segment := range segments {
+		points := maxPoints * len(segment) / total
+		if points < 2 {
+			points = 2
+		}
+		downsampled[i] = downsampleRecords(segment, points)
+	}
+	return downsampled
+}
+
+// downsampleRecords 用分桶平均把记录减少到最多maxPoints个，只用于绘图：
+// 第一个和最后一个样本原样保留，中间的样本按顺序均分为maxPoints-2个桶，
+// 每个桶取时间和各数值字段的平均值。maxPoints不大于0或样本数不超过maxPoints时原样返回
+func downsampleRecords(data []MemoryRecord, maxPoints int) []MemoryRecord {
+	if maxPoints <= 0 || len(data) <= maxPoints {
+		return data
+	}
+	if maxPoints < 2 {
+		maxPoints = 2
+	}
+
+	inner := data[1 : len(data)-1]
+	buckets := maxPoints - 2
+	downsampled := make([]MemoryRecord, 0, maxPoints)
+	downsampled = append(downsampled, data[0])
+	for b := 0; b < buckets; b++ {
+		lo, hi := b*len(inner)/buckets, (b+1)*len(inner)/buckets
+		if lo < hi {
+			downsampled = append(downsampled, averageRecords(inner[lo:hi]))
+		}
+	}
+	return append(downsampled, data[len(data)-1])
+}
+
+// averageRecords 返回一组记录的平均值：时间取平均时刻，PSI和CPU只在带有这些数据的记录间平均，
+// 磁盘统计等不参与绘制平均的字段取自第一条记录
+func averageRecords(group []MemoryRecord) MemoryRecord {
+	avg := group[0]
+	base := group[0].Timestamp
+	var offset time.Duration
+	var sum MemoryRecord
+	var psiCount, cpuCount, waitCount int
+	for _, r := range group {
+		offset += r.Timestamp.Sub(base)
+		sum.MemTotal += r.MemTotal
+		sum.MemFree += r.MemFree
+		sum.MemCache += r.MemCache
+		sum.MemBuff += r.MemBuff
+		sum.MemSlabRec += r.MemSlabRec
+		sum.SwapTotal += r.SwapTotal
+		sum.SwapFree += r.SwapFree
+		if r.HasPSI {
+			sum.PSIMemSome += r.PSIMemSome
+			sum.PSIMemFull += r.PSIMemFull
+			psiCount++
+		}
+		if r.HasCPU {
+			sum.CPUSys += r.CPUSys
+			sum.CPUUser += r.CPUUser
+			sum.CPUIdle += r.CPUIdle
+			cpuCount++
+		}
+		if r.HasCPUWait {
+			sum.CPUWait += r.CPUWait
+			waitCount++
+		}
+	}
+
+	n := float64(len(group))
+	avg.Timestamp = base.Add(offset / time.Duration(len(group)))
+	avg.MemTotal = sum.MemTotal / n
+	avg.MemFree = sum.MemFree / n
+	avg.MemCache = sum.MemCache / n
+	avg.MemBuff = sum.MemBuff / n
+	avg.MemSlabRec = sum.MemSlabRec / n
+	avg.SwapTotal = sum.SwapTotal / n
+	avg.SwapFree = sum.SwapFree / n
+	avg.HasPSI = psiCount > 0
+	if psiCount > 0 {
+		avg.PSIMemSome = sum.PSIMemSome / float64(psiCount)
+		avg.PSIMemFull = sum.PSIMemFull / float64(psiCount)
+	}
+	avg.HasCPU = cpuCount > 0
+	if cpuCount > 0 {
+		avg.CPUSys = sum.CPUSys / float64(cpuCount)
+		avg.CPUUser = sum.CPUUser / float64(cpuCount)
+		avg.CPUIdle = sum.CPUIdle / float64(cpuCount)
+	}
+	avg.HasCPUWait = waitCount > 0
+	if waitCount > 0 {
+		avg.CPUWait = sum.CPUWait / float64(waitCount)
+	}
+	return avg
+}
+
+// downsampleXYs 对不是由MemoryRecord直接得到的曲线（例如换页速率）做同样的分桶平均降采样，
+// 首尾两个点原样保留
+func downsampleXYs(points plotter.XYs, maxPoints int) plotter.XYs {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+	if maxPoints < 2 {
+		maxPoints = 2
+	}
+
+	inner := points[1 : len(points)-1]
+	buckets := maxPoints - 2
+	downsampled := make(plotter.XYs, 0, maxPoints)
+	downsampled = append(downsampled, points[0])
+	for b := 0; b < buckets; b++ {
+		lo, hi := b*len(inner)/buckets, (b+1)*len(inner)/buckets
+		if lo == hi {
+			continue
+		}
+		var sum plotter.XY
+		for _, point := range inner[lo:hi] {
+			sum.X += point.X
+			sum.Y += point.Y
+		}
+		n := float64(hi - lo)
+		downsampled = append(downsampled, plotter.XY{X: sum.X / n, Y: sum.Y / n})
+	}
+	return append(downsampled, points[len(points)-1])
+}
@@ -0,0 +1,153 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// foldedCurve 折叠到同一周期坐标轴上的一段曲线，X为距周期起点的小时数
+type foldedCurve struct {
+	Start   time.Time // 周期起点（当天或当周周一的0点）
+	Weekend bool      // 按天折叠时该天是否为周六或周日
+	Points  plotter.XYs
+}
+
+// foldPeriodStart 返回t所在周期的起点：daily为当天0点，weekly为所在周周一0点
+func foldPeriodStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == "weekly" {
+		// time.Weekday以周日为0，换算为距周一的天数
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// foldPosition 返回t在周期内的位置（小时）。按墙上时间计算，
+// 夏令时切换当天之后的曲线不会整体偏移一小时
+func foldPosition(t time.Time, period string) float64 {
+	hours := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+	if period == "weekly" {
+		hours += float64((int(t.Weekday())+6)%7) * 24
+	}
+	return hours
+}
+
+// foldPeriodHours 返回周期的长度（小时），用作X轴范围
+func foldPeriodHours(period string) float64 {
+	if period == "weekly" {
+		return 7 * 24
+	}
+	return 24
+}
+
+// foldRecords 按周期切分已用内存曲线，每个周期一条，按周期起点排序
+func foldRecords(data []MemoryRecord, period string) []foldedCurve {
+	curves := make(map[time.Time]*foldedCurve)
+	for _, record := range data {
+		start := foldPeriodStart(record.Timestamp, period)
+		curve, ok := curves[start]
+		if !ok {
+			weekday := start.Weekday()
+			curve = &foldedCurve{
+				Start:   start,
+				Weekend: period == "daily" && (weekday == time.Saturday || weekday == time.Sunday),
+			}
+			curves[start] = curve
+		}
+		curve.Points = append(curve.Points, plotter.XY{X: foldPosition(record.Timestamp, period), Y: record.MemTotal - record.MemFree})
+	}
+
+	result := make([]foldedCurve, 0, len(curves))
+	for _, curve := range curves {
+		sort.Slice(curve.Points, func(i, j int) bool { return curve.Points[i].X < curve.Points[j].X })
+		result = append(result, *curve)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
+
+// weeklyTicks 周折叠图的X轴刻度，每天0点标注星期
+type weeklyTicks struct{}
+
+func (weeklyTicks) Ticks(min, max float64) []plot.Tick {
+	names := []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	var ticks []plot.Tick
+	for day := 0; day <= 7; day++ {
+		tick := plot.Tick{Value: float64(day * 24)}
+		if day < 7 {
+			tick.Label = names[day]
+		}
+		ticks = append(ticks, tick)
+	}
+	return ticks
+}
+
+// dailyTicks 日折叠图的X轴刻度，每3小时一个
+type dailyTicks struct{}
+
+func (dailyTicks) Ticks(min, max float64) []plot.Tick {
+	var ticks []plot.Tick
+	for hour := 0; hour <= 24; hour += 3 {
+		ticks = append(ticks, plot.Tick{Value: float64(hour), Label: fmt.Sprintf("%02d:00", hour)})
+	}
+	return ticks
+}
+
+// generateFoldChart 将每天（或每周）的已用内存曲线以半透明方式叠加在同一坐标轴上，
+// 按天折叠时工作日与周末使用不同颜色
+func generateFoldChart(data []MemoryRecord, period, outputFile string) error {
+	curves := foldRecords(data, period)
+	if len(curves) == 0 {
+		return fmt.Errorf(Tr("没有可折叠的数据"))
+	}
+
+	p := plot.New()
+	p.Y.Label.Text = "MEM Used (GB)"
+	p.X.Min = 0
+	p.X.Max = foldPeriodHours(period)
+	if period == "weekly" {
+		p.Title.Text = "Memory Used by Week"
+		p.X.Label.Text = "Day of week"
+		p.X.Tick.Marker = weeklyTicks{}
+	} else {
+		p.Title.Text = "Memory Used by Day"
+		p.X.Label.Text = "Time of day"
+		p.X.Tick.Marker = dailyTicks{}
+	}
+
+	weekdayColor := color.RGBA{B: 80, A: 80}
+	weekendColor := color.RGBA{R: 80, G: 44, A: 80}
+	var weekdayLegend, weekendLegend bool
+	for _, curve := range curves {
+		line, err := plotter.NewLine(curve.Points)
+		if err != nil {
+			return err
+		}
+		line.Color = weekdayColor
+		if curve.Weekend {
+			line.Color = weekendColor
+		}
+		p.Add(line)
+
+		switch {
+		case period == "weekly" && !weekdayLegend:
+			p.Legend.Add(fmt.Sprintf("%d weeks", len(curves)), line)
+			weekdayLegend = true
+		case period == "daily" && curve.Weekend && !weekendLegend:
+			p.Legend.Add("weekend", line)
+			weekendLegend = true
+		case period == "daily" && !curve.Weekend && !weekdayLegend:
+			p.Legend.Add("weekday", line)
+			weekdayLegend = true
+		}
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
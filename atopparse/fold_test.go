@@ -0,0 +1,92 @@
+package atopparse
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFoldPeriodStartAndPosition(t *testing.T) {
+	// 2024-06-12 为星期三，06-16 为星期日，06-10 为星期一
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 6, day, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		t         time.Time
+		period    string
+		wantStart time.Time
+		wantHours float64
+	}{
+		{"daily afternoon", at(12, 13, 30), "daily", at(12, 0, 0), 13.5},
+		{"daily midnight", at(12, 0, 0), "daily", at(12, 0, 0), 0},
+		{"weekly monday", at(10, 6, 0), "weekly", at(10, 0, 0), 6},
+		{"weekly wednesday", at(12, 6, 0), "weekly", at(10, 0, 0), 2*24 + 6},
+		{"weekly sunday belongs to previous monday", at(16, 23, 0), "weekly", at(10, 0, 0), 6*24 + 23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foldPeriodStart(tt.t, tt.period); !got.Equal(tt.wantStart) {
+				t.Errorf("周期起点为 %v，期望 %v", got, tt.wantStart)
+			}
+			if got := foldPosition(tt.t, tt.period); math.Abs(got-tt.wantHours) > 1e-9 {
+				t.Errorf("周期内位置为 %v 小时，期望 %v", got, tt.wantHours)
+			}
+		})
+	}
+}
+
+func TestFoldPositionDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("没有时区数据: %v", err)
+	}
+	// 2024-03-10 凌晨2点切换为夏令时，当天距0点只过去了11小时的12:00仍位于X=12
+	noon := time.Date(2024, 3, 10, 12, 0, 0, 0, loc)
+	if got := foldPosition(noon, "daily"); got != 12 {
+		t.Errorf("夏令时切换当天12:00的位置为 %v，期望 12", got)
+	}
+}
+
+func TestFoldRecords(t *testing.T) {
+	// 星期五到星期日每天两个样本，故意打乱顺序
+	at := func(day, hour int) time.Time { return time.Date(2024, 6, day, hour, 0, 0, 0, time.UTC) }
+	record := func(ts time.Time, used float64) MemoryRecord {
+		return MemoryRecord{Timestamp: ts, MemTotal: 16, MemFree: 16 - used}
+	}
+	data := []MemoryRecord{
+		record(at(14, 18), 6), record(at(14, 6), 4),
+		record(at(15, 6), 3), record(at(15, 18), 5),
+		record(at(16, 12), 7), record(at(16, 0), 2),
+	}
+
+	tests := []struct {
+		period      string
+		wantStarts  []time.Time
+		wantWeekend []bool
+		wantPoints  [][2]float64 // 第一条曲线的点(X, Y)
+	}{
+		{"daily", []time.Time{at(14, 0), at(15, 0), at(16, 0)}, []bool{false, true, true}, [][2]float64{{6, 4}, {18, 6}}},
+		{"weekly", []time.Time{at(10, 0)}, []bool{false}, [][2]float64{{4*24 + 6, 4}, {4*24 + 18, 6}, {5*24 + 6, 3}, {5*24 + 18, 5}, {6 * 24, 2}, {6*24 + 12, 7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			curves := foldRecords(data, tt.period)
+			if len(curves) != len(tt.wantStarts) {
+				t.Fatalf("折叠出 %d 条曲线，期望 %d 条", len(curves), len(tt.wantStarts))
+			}
+			for i, curve := range curves {
+				if !curve.Start.Equal(tt.wantStarts[i]) || curve.Weekend != tt.wantWeekend[i] {
+					t.Errorf("第 %d 条曲线起点 %v、周末 %t，期望 %v、%t", i+1, curve.Start, curve.Weekend, tt.wantStarts[i], tt.wantWeekend[i])
+				}
+			}
+			points := curves[0].Points
+			if len(points) != len(tt.wantPoints) {
+				t.Fatalf("第一条曲线有 %d 个点，期望 %d 个", len(points), len(tt.wantPoints))
+			}
+			for i, want := range tt.wantPoints {
+				if points[i].X != want[0] || points[i].Y != want[1] {
+					t.Errorf("第 %d 个点为 (%v, %v)，期望 (%v, %v)", i+1, points[i].X, points[i].Y, want[0], want[1])
+				}
+			}
+		})
+	}
+}
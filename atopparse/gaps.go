@@ -0,0 +1,94 @@
+package atopparse
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// DataGap 一段超过断开上限、在图表中断开的数据缺失
+type DataGap struct {
+	Start time.Time // 缺失前的最后一个样本
+	End   time.Time // 缺失后的第一个样本
+}
+
+// GapLimit 返回图表中断开折线的缺口长度：opts.MaxGap大于0时为采样间隔的MaxGap倍
+// （采样间隔取日志头中出现最多的间隔，没有时取中位采样间隔），否则为opts.MaxFillGap。
+// 返回0表示不断开；采样间隔中途变化时GapSegments按每个样本的预期间隔计算，这里只是最常见间隔下的长度
+func GapLimit(data []MemoryRecord, opts ChartOptions) time.Duration {
+	if opts.MaxGap <= 0 {
+		return opts.MaxFillGap
+	}
+	interval := ExpectedInterval(data)
+	if interval <= 0 {
+		interval = MedianInterval(data)
+	}
+	return time.Duration(opts.MaxGap * float64(interval))
+}
+
+// GapSegments 按采样间隔把数据切分为若干连续段，只用于绘图：
+// 超过断开长度的缺口作为断点并返回，其余不超过opts.MaxFillGap的缺口按采样间隔线性插值填补。
+// 采样间隔按expectedIntervals逐个样本取（日志头没有时为中位采样间隔），opts.MaxGap的断开长度也随之变化。
+// 两者都未设置时不做任何处理，返回仅含原始数据的一段
+func GapSegments(data []MemoryRecord, opts ChartOptions) ([][]MemoryRecord, []DataGap) {
+	median := MedianInterval(data)
+	if GapLimit(data, opts) <= 0 || median <= 0 {
+		return [][]MemoryRecord{data}, nil
+	}
+	intervals := expectedIntervals(data, median)
+
+	var segments [][]MemoryRecord
+	var gaps []DataGap
+	current := []MemoryRecord{data[0]}
+	for i := 1; i < len(data); i++ {
+		prev, next := data[i-1], data[i]
+		gap, interval := next.Timestamp.Sub(prev.Timestamp), intervals[i]
+		limit := opts.MaxFillGap
+		if opts.MaxGap > 0 {
+			limit = time.Duration(opts.MaxGap * float64(interval))
+		}
+		switch {
+		case gap <= interval*3/2:
+			// 正常采样间隔
+		case gap > limit:
+			segments = append(segments, current)
+			current = nil
+			gaps = append(gaps, DataGap{Start: prev.Timestamp, End: next.Timestamp})
+		case gap <= opts.MaxFillGap:
+			current = append(current, interpolateRecords(prev, next, interval)...)
+		}
+		current = append(current, next)
+	}
+	return append(segments, current), gaps
+}
+
+// interpolateRecords 在prev和next之间按step生成线性插值的记录（不含两端）
+func interpolateRecords(prev, next MemoryRecord, step time.Duration) []MemoryRecord {
+	var filled []MemoryRecord
+	span := next.Timestamp.Sub(prev.Timestamp)
+	for t := prev.Timestamp.Add(step); next.Timestamp.Sub(t) > step/2; t = t.Add(step) {
+		ratio := float64(t.Sub(prev.Timestamp)) / float64(span)
+		lerp := func(a, b float64) float64 { return a + (b-a)*ratio }
+		filled = append(filled, MemoryRecord{
+			Timestamp:  t,
+			MemTotal:   lerp(prev.MemTotal, next.MemTotal),
+			MemFree:    lerp(prev.MemFree, next.MemFree),
+			MemCache:   lerp(prev.MemCache, next.MemCache),
+			MemBuff:    lerp(prev.MemBuff, next.MemBuff),
+			MemSlabRec: lerp(prev.MemSlabRec, next.MemSlabRec),
+			SwapTotal:  lerp(prev.SwapTotal, next.SwapTotal),
+			SwapFree:   lerp(prev.SwapFree, next.SwapFree),
+			Source:     prev.Source,
+		})
+	}
+	return filled
+}
+
+// PrintDataGaps 将超过断开上限limit的数据缺失输出到w
+func PrintDataGaps(w io.Writer, gaps []DataGap, limit time.Duration) {
+	fmt.Fprintf(w, Tr("检测到 %d 处超过 %v 的数据缺失（图表中断开）\n"), len(gaps), limit)
+	for _, gap := range gaps {
+		fmt.Fprintf(w, "  %s - %s (%v)\n",
+			gap.Start.Format("2006-01-02 15:04:05"), gap.End.Format("2006-01-02 15:04:05"), gap.End.Sub(gap.Start))
+	}
+}
@@ -0,0 +1,80 @@
+package atopparse
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gapRecords 按距10:00:00的秒数生成每10秒采样的记录，mem_free等于秒数/10
+func gapRecords(offsets ...int) []MemoryRecord {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := make([]MemoryRecord, len(offsets))
+	for i, offset := range offsets {
+		data[i] = MemoryRecord{Timestamp: start.Add(time.Duration(offset) * time.Second), Interval: 10 * time.Second,
+			MemTotal: 16, MemFree: float64(offset) / 10, SwapTotal: 2, SwapFree: 1}
+	}
+	return data
+}
+
+func TestGapSegmentsInterpolateUpto(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []MemoryRecord
+		wantSegments []int // 各段的点数（含插值点）
+		wantGaps     int
+	}{
+		{"no gaps", gapRecords(0, 10, 20, 30), []int{4}, 0},
+		{"short gap filled", gapRecords(0, 10, 70, 80), []int{9}, 0},
+		{"gap equal to threshold filled", gapRecords(0, 120, 130), []int{14}, 0},
+		{"long gap broken", gapRecords(0, 10, 310, 320), []int{2, 2}, 1},
+		{"short and long gaps", gapRecords(0, 60, 70, 400, 410), []int{8, 2}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, gaps := GapSegments(tt.data, ChartOptions{MaxFillGap: 2 * time.Minute})
+			var sizes []int
+			for _, segment := range segments {
+				sizes = append(sizes, len(segment))
+				// 插值点按采样间隔等距，数值在两端之间线性变化，mem_free始终等于秒数/10
+				for i, record := range segment {
+					if i > 0 && record.Timestamp.Sub(segment[i-1].Timestamp) != 10*time.Second {
+						t.Errorf("段内相邻点的间隔为 %v", record.Timestamp.Sub(segment[i-1].Timestamp))
+					}
+					want := float64(record.Timestamp.Sub(tt.data[0].Timestamp)/time.Second) / 10
+					if math.Abs(record.MemFree-want) > 1e-9 {
+						t.Errorf("%s 的mem_free为 %v，期望 %v", record.Timestamp.Format("15:04:05"), record.MemFree, want)
+					}
+				}
+			}
+			if !reflect.DeepEqual(sizes, tt.wantSegments) {
+				t.Errorf("分段为 %v，期望 %v", sizes, tt.wantSegments)
+			}
+			if len(gaps) != tt.wantGaps {
+				t.Errorf("断开 %d 处，期望 %d 处", len(gaps), tt.wantGaps)
+			}
+		})
+	}
+}
+
+func TestHTMLInterpolateUpto(t *testing.T) {
+	data := gapRecords(0, 10, 70, 400, 410)
+	var buf bytes.Buffer
+	if err := writeHTMLReport(&buf, data, ReportOptions{Chart: ChartOptions{MaxFillGap: 2 * time.Minute}}, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	// 短缺口的插值点出现在时间轴上，长缺口处插入空值使折线断开
+	if !strings.Contains(html, `"2024-06-11 10:00:40"`) {
+		t.Error("HTML中没有短缺口的插值点")
+	}
+	if strings.Contains(html, `"2024-06-11 10:02:00"`) {
+		t.Error("长缺口不应插值")
+	}
+	if !strings.Contains(html, `"2024-06-11 10:01:10",""`) || !strings.Contains(html, "null") {
+		t.Error("长缺口处应插入空值断开折线")
+	}
+}
@@ -0,0 +1,61 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// histogramValues 按指标取出用于直方图统计的数值，metric为free或used
+func histogramValues(data []MemoryRecord, metric string) (plotter.Values, string, error) {
+	values := make(plotter.Values, len(data))
+	switch metric {
+	case "free":
+		for i, record := range data {
+			values[i] = record.MemFree
+		}
+		return values, "MEM Free (GB)", nil
+	case "used":
+		for i, record := range data {
+			values[i] = record.MemTotal - record.MemFree
+		}
+		return values, "MEM Used (GB)", nil
+	}
+	return nil, "", fmt.Errorf(Tr("不支持的直方图指标: %s (可选: free, used)"), metric)
+}
+
+// generateHistogram 将内存分布分桶，保存直方图PNG并输出各桶的样本数
+func generateHistogram(w io.Writer, data []MemoryRecord, outputFile string, metric string, bins int) error {
+	values, label, err := histogramValues(data, metric)
+	if err != nil {
+		return err
+	}
+
+	hist, err := plotter.NewHist(values, bins)
+	if err != nil {
+		return err
+	}
+	hist.FillColor = color.RGBA{B: 255, A: 160}
+
+	p := plot.New()
+	p.Title.Text = "Memory Distribution"
+	p.X.Label.Text = label
+	p.Y.Label.Text = "Samples"
+	p.Add(hist)
+
+	if err := p.Save(8*vg.Inch, 4*vg.Inch, outputFile); err != nil {
+		return err
+	}
+
+	// 输出分桶统计表
+	fmt.Fprintf(w, Tr("%s 分布（共 %d 个样本）:\n"), label, len(values))
+	fmt.Fprintf(w, "  %-20s %s\n", Tr("区间 (GB)"), Tr("样本数"))
+	for _, bin := range hist.Bins {
+		fmt.Fprintf(w, "  %-20s %d\n", fmt.Sprintf("%.2f - %.2f", bin.Min, bin.Max), int(bin.Weight))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package atopparse
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hostColumn 是主CSV中记录所属主机的列，--seed-from读取时据此区分不同主机的同一时间点
+const hostColumn = "host"
+
+// RecordHost 返回记录所属的主机：日志头中的主机名，没有时退回来源文件名（不含扩展名），
+// 两者都没有（如从标准输入读取的记录）时为空
+func RecordHost(record MemoryRecord) string {
+	if record.Hostname != "" {
+		return record.Hostname
+	}
+	if record.Source == "" || record.Source == StdinPath {
+		return ""
+	}
+	base := filepath.Base(record.Source)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// GroupByHost 按RecordHost分组，组内保持原有的时间顺序
+func GroupByHost(data []MemoryRecord) map[string][]MemoryRecord {
+	groups := make(map[string][]MemoryRecord)
+	for _, record := range data {
+		host := RecordHost(record)
+		groups[host] = append(groups[host], record)
+	}
+	return groups
+}
+
+// HostPrefix 返回单个主机报告的输出前缀：<前缀>_<主机名>，主机名中的路径分隔符等字符替换为_，
+// 重名时追加序号
+func HostPrefix(outputPrefix, host string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, host)
+	prefix := outputPrefix + "_" + name
+	for i := 2; used[prefix]; i++ {
+		prefix = fmt.Sprintf("%s_%s_%d", outputPrefix, name, i)
+	}
+	used[prefix] = true
+	return prefix
+}
+
+// reportHosts 返回需要单独输出报告的主机，按主机名排序；无法确定主机的记录不单独输出
+func reportHosts(groups map[string][]MemoryRecord) []string {
+	hosts := make([]string, 0, len(groups))
+	for host := range groups {
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// HostReportPrefixes 返回GenerateHostReports为data中各主机使用的输出前缀，顺序与生成报告的顺序相同
+func HostReportPrefixes(data []MemoryRecord, outputPrefix string) []string {
+	used := make(map[string]bool)
+	var prefixes []string
+	for _, host := range reportHosts(GroupByHost(data)) {
+		prefixes = append(prefixes, HostPrefix(outputPrefix, host, used))
+	}
+	return prefixes
+}
+
+// GenerateHostReports 为每个主机单独生成一份报告（CSV、图表等，选项与合并报告相同），
+// 重启标注只保留落在该主机时间范围内的时间点。无法确定主机的记录不单独输出
+func GenerateHostReports(data []MemoryRecord, outputPrefix string, opts ReportOptions) error {
+	groups := GroupByHost(data)
+	used := make(map[string]bool)
+	for _, host := range reportHosts(groups) {
+		records := groups[host]
+		hostOpts := opts
+		// 单独报告的CSV总是写入文件，标准输出只输出合并后的CSV
+		hostOpts.Stdout = false
+		hostOpts.Chart.Reboots = nil
+		for _, reboot := range opts.Chart.Reboots {
+			if !reboot.Before(records[0].Timestamp) && !reboot.After(records[len(records)-1].Timestamp) {
+				hostOpts.Chart.Reboots = append(hostOpts.Chart.Reboots, reboot)
+			}
+		}
+
+		fmt.Fprintf(opts.console(), Tr("生成主机 %s 的单独报告（%d 条记录）\n"), host, len(records))
+		if err := GenerateReport(records, HostPrefix(outputPrefix, host, used), hostOpts); err != nil {
+			return fmt.Errorf(Tr("生成主机 %s 的报告时出错: %v"), host, err)
+		}
+	}
+	return nil
+}
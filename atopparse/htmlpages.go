@@ -0,0 +1,52 @@
+package atopparse
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+)
+
+// generateHTMLPages 生成交互式HTML报告。pageSize大于0且样本数超过pageSize时，
+// 按每页pageSize个样本拆分为多个页面（第一页为 <前缀>_memory_swap.html，其余为 _p2、_p3……），
+// 每页只内联自己的数据并带有上一页/下一页导航。异常点的阈值按全部样本计算，各页一致。返回写出的文件
+func generateHTMLPages(data []MemoryRecord, outputPrefix string, opts ReportOptions) ([]string, error) {
+	base := outputPrefix + "_memory_swap"
+	threshold := usedMemoryPercentile(data, opts.HTMLAnomalyP)
+	size := opts.HTMLPageSize
+	if size <= 0 || len(data) <= size {
+		file := base + ".html"
+		return []string{file}, generateHTMLReport(data, file, opts, "", threshold)
+	}
+
+	pages := (len(data) + size - 1) / size
+	files := make([]string, pages)
+	for i := range files {
+		files[i] = base + ".html"
+		if i > 0 {
+			files[i] = fmt.Sprintf("%s_p%d.html", base, i+1)
+		}
+	}
+
+	for i := range files {
+		page := data[i*size : min((i+1)*size, len(data))]
+		if err := generateHTMLReport(page, files[i], opts, htmlPageNav(i, files, page), threshold); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// htmlPageNav 返回第page页（从0开始）的导航栏，链接使用同目录下的相对文件名
+func htmlPageNav(page int, files []string, data []MemoryRecord) string {
+	var parts []string
+	if page > 0 {
+		parts = append(parts, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(filepath.Base(files[page-1])), Tr("« 上一页")))
+	}
+	parts = append(parts, html.EscapeString(fmt.Sprintf(Tr("第 %d/%d 页: %s - %s"), page+1, len(files),
+		data[0].Timestamp.Format("2006-01-02 15:04:05"), data[len(data)-1].Timestamp.Format("2006-01-02 15:04:05"))))
+	if page < len(files)-1 {
+		parts = append(parts, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(filepath.Base(files[page+1])), Tr("下一页 »")))
+	}
+	return `<p class="nav">` + strings.Join(parts, " | ") + `</p>`
+}
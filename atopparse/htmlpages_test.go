@@ -0,0 +1,66 @@
+package atopparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHTMLPages(t *testing.T) {
+	data := gapRecords(0, 10, 20, 30, 40, 50, 60, 70, 80, 90)
+	tests := []struct {
+		name      string
+		pageSize  int
+		wantPages []string
+		wantRows  []int // 各页的样本数
+	}{
+		{"no pagination", 0, []string{"r_memory_swap.html"}, []int{10}},
+		{"page size equals samples", 10, []string{"r_memory_swap.html"}, []int{10}},
+		{"even pages", 5, []string{"r_memory_swap.html", "r_memory_swap_p2.html"}, []int{5, 5}},
+		{"last page partial", 4, []string{"r_memory_swap.html", "r_memory_swap_p2.html", "r_memory_swap_p3.html"}, []int{4, 4, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files, err := generateHTMLPages(data, filepath.Join(dir, "r"), ReportOptions{HTMLPageSize: tt.pageSize})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.wantPages) {
+				t.Fatalf("生成 %d 页，期望 %d 页: %v", len(files), len(tt.wantPages), files)
+			}
+
+			offset := 0
+			for i, file := range files {
+				if filepath.Base(file) != tt.wantPages[i] {
+					t.Errorf("第 %d 页的文件名为 %s，期望 %s", i+1, filepath.Base(file), tt.wantPages[i])
+				}
+				content, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				html := string(content)
+
+				// 每页只包含自己的样本
+				for j, record := range data {
+					inPage := j >= offset && j < offset+tt.wantRows[i]
+					if got := strings.Contains(html, `"`+record.Timestamp.Format("2006-01-02 15:04:05")+`"`); got != inPage {
+						t.Errorf("第 %d 页包含样本 %d 为 %t，期望 %t", i+1, j, got, inPage)
+					}
+				}
+				offset += tt.wantRows[i]
+
+				// 上一页/下一页链接指向相邻的页面
+				hasPrev := i > 0 && strings.Contains(html, `href="`+tt.wantPages[max(i-1, 0)]+`"`)
+				hasNext := i < len(files)-1 && strings.Contains(html, `href="`+tt.wantPages[min(i+1, len(files)-1)]+`"`)
+				if len(files) > 1 && (hasPrev != (i > 0) || hasNext != (i < len(files)-1)) {
+					t.Errorf("第 %d 页的导航不正确:\n%s", i+1, html)
+				}
+				if len(files) == 1 && strings.Contains(html, `class="nav"`) {
+					t.Errorf("不分页时不应有导航")
+				}
+			}
+		})
+	}
+}
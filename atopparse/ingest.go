@@ -0,0 +1,195 @@
+package atopparse
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvRequiredColumns 读取CSV时必须有的列，其余列缺少时记为0或没有该项数据
+var csvRequiredColumns = []string{"timestamp", "mem_tot", "mem_free", "swp_tot", "swp_free"}
+
+// ReadRecordsCSV 读取本工具之前生成的CSV，按表头中的列名取值并校验每个字段的类型，
+// 出错时返回第一个有问题的行号。只要求有timestamp和基本内存列，列的顺序不限，
+// 使用率、派生指标、elapsed等读取时用不到的列被忽略
+func ReadRecordsCSV(path string) ([]MemoryRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// 兼容--gzip-output生成的.csv.gz
+	var input io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf(Tr("解压 %s 失败: %v"), path, err)
+		}
+		defer gz.Close()
+		input = gz
+	}
+
+	reader := csv.NewReader(input)
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf(Tr("读取 %s 的表头失败: %v"), path, err)
+	}
+	columns, err := mapColumns(header)
+	if err != nil {
+		return nil, fmt.Errorf(Tr("%s 第 1 行: %v"), path, err)
+	}
+	// 较早版本生成的CSV没有mem_cache/mem_buff列，读取时记为0；CPU列三列都有时才读取，cpu_wait列可以没有
+	host, withHost := columns[hostColumn]
+	_, withCPU := columns[cpuColumns[0]]
+	for _, name := range cpuColumns[1:] {
+		if _, ok := columns[name]; !ok {
+			withCPU = false
+		}
+	}
+
+	var data []MemoryRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// csv.ParseError中已包含行号，例如列数不一致
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		timestamp, err := time.Parse("2006-01-02 15:04:05", row[columns["timestamp"]])
+		if err != nil {
+			return nil, fmt.Errorf(Tr("%s 第 %d 行: timestamp 列的值 %q 不是有效的时间"), path, line, row[columns["timestamp"]])
+		}
+		value := func(name string) (float64, error) {
+			column, ok := columns[name]
+			if !ok {
+				return 0, nil
+			}
+			v, err := strconv.ParseFloat(row[column], 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return 0, fmt.Errorf(Tr("%s 第 %d 行: %s 列的值 %q 不是有效的数值"), path, line, name, row[column])
+			}
+			return v, nil
+		}
+		values := make([]float64, len(csvHeader)-1)
+		for i, name := range csvHeader[1:] {
+			if values[i], err = value(name); err != nil {
+				return nil, err
+			}
+		}
+
+		record := MemoryRecord{
+			Timestamp:  timestamp,
+			MemTotal:   values[0],
+			MemFree:    values[1],
+			SwapTotal:  values[2],
+			SwapFree:   values[3],
+			MemCache:   values[4],
+			MemBuff:    values[5],
+			MemSlabRec: values[6],
+		}
+		if withHost {
+			record.Hostname = row[host]
+		}
+		// CPU列为空表示该采样块没有CPU行
+		if withCPU && row[columns[cpuColumns[0]]] != "" {
+			var cpu [3]float64
+			for i, name := range cpuColumns {
+				if cpu[i], err = value(name); err != nil {
+					return nil, err
+				}
+			}
+			record.HasCPU = true
+			record.CPUSys, record.CPUUser, record.CPUIdle = cpu[0], cpu[1], cpu[2]
+			if column, ok := columns[cpuWaitColumn]; ok && row[column] != "" {
+				if record.CPUWait, err = value(cpuWaitColumn); err != nil {
+					return nil, err
+				}
+				record.HasCPUWait = true
+			}
+		}
+		data = append(data, record)
+	}
+	return data, nil
+}
+
+// mapColumns 返回表头中各列名所在的位置，缺少必需的列或列名重复时返回错误
+func mapColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf(Tr("列 %s 重复"), name)
+		}
+		columns[name] = i
+	}
+	var missing []string
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf(Tr("缺少列 [%s]，至少需要 %s"), strings.Join(missing, ","), strings.Join(csvRequiredColumns, ","))
+	}
+	return columns, nil
+}
+
+// mergeKey 合并时判断重复记录的键，不同主机在同一时间点的记录不算重复
+type mergeKey struct {
+	host      string
+	timestamp time.Time
+}
+
+// MergeRecords 合并历史记录和新解析的记录，主机（RecordHost）和时间戳都相同时以新记录为准，
+// 结果按时间排序，同一时间点按主机名排序
+func MergeRecords(seed, fresh []MemoryRecord) ([]MemoryRecord, int) {
+	byKey := make(map[mergeKey]MemoryRecord, len(seed)+len(fresh))
+	for _, record := range seed {
+		byKey[mergeKey{RecordHost(record), record.Timestamp}] = record
+	}
+	for _, record := range fresh {
+		byKey[mergeKey{RecordHost(record), record.Timestamp}] = record
+	}
+
+	merged := make([]MemoryRecord, 0, len(byKey))
+	for _, record := range byKey {
+		merged = append(merged, record)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if !merged[i].Timestamp.Equal(merged[j].Timestamp) {
+			return merged[i].Timestamp.Before(merged[j].Timestamp)
+		}
+		return RecordHost(merged[i]) < RecordHost(merged[j])
+	})
+	return merged, len(seed) + len(fresh) - len(merged)
+}
+
+// RetainRecords 只保留不早于最新记录之前window的记录，返回保留的记录和丢弃的条数。
+// 保留窗口以数据中最新的时间戳为准而不是当前时间，与日志时间的时区无关；data的顺序不变
+func RetainRecords(data []MemoryRecord, window time.Duration) ([]MemoryRecord, int) {
+	if len(data) == 0 {
+		return data, 0
+	}
+	newest := data[0].Timestamp
+	for _, record := range data {
+		if record.Timestamp.After(newest) {
+			newest = record.Timestamp
+		}
+	}
+	cutoff := newest.Add(-window)
+	kept := FilterRecords(data, func(record MemoryRecord) bool { return !record.Timestamp.Before(cutoff) })
+	return kept, len(data) - len(kept)
+}
@@ -0,0 +1,216 @@
+package atopparse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadRecordsCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    MemoryRecord
+		wantErr string
+	}{
+		{
+			name: "current format with cpu",
+			csv: "timestamp,mem_tot,mem_free,swp_tot,swp_free,mem_cache,mem_buff,mem_used_pct,swp_used_pct,cpu_sys,cpu_user,cpu_idle\n" +
+				"2024-06-11 10:00:00,16.00,4.00,2.00,1.50,2.00,0.50,75.00,25.00,5.00,10.00,85.00\n",
+			want: MemoryRecord{MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1.5, MemCache: 2, MemBuff: 0.5,
+				HasCPU: true, CPUSys: 5, CPUUser: 10, CPUIdle: 85},
+		},
+		{
+			name: "old format without cache and buff",
+			csv:  "timestamp,mem_tot,mem_free,swp_tot,swp_free\n2024-06-11 10:00:00,16.00,4.00,2.00,1.50\n",
+			want: MemoryRecord{MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1.5},
+		},
+		{
+			name: "reordered columns with derived and elapsed",
+			csv: "mem_free,timestamp,used_ratio,swp_free,mem_tot,swp_tot,elapsed\n" +
+				"4.00,2024-06-11 10:00:00,0.75,1.50,16.00,2.00,00:00:00\n",
+			want: MemoryRecord{MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1.5},
+		},
+		{
+			name: "empty cpu columns",
+			csv: "timestamp,mem_tot,mem_free,swp_tot,swp_free,cpu_sys,cpu_user,cpu_idle\n" +
+				"2024-06-11 10:00:00,16.00,4.00,2.00,1.50,,,\n",
+			want: MemoryRecord{MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1.5},
+		},
+		{
+			name:    "missing required column",
+			csv:     "timestamp,mem_tot,swp_tot,swp_free\n2024-06-11 10:00:00,16.00,2.00,1.50\n",
+			wantErr: "mem_free",
+		},
+		{
+			name:    "duplicate column",
+			csv:     "timestamp,mem_tot,mem_free,swp_tot,swp_free,mem_free\n2024-06-11 10:00:00,16.00,4.00,2.00,1.50,4.00\n",
+			wantErr: "mem_free",
+		},
+		{
+			name:    "invalid value",
+			csv:     "timestamp,mem_tot,mem_free,swp_tot,swp_free\n2024-06-11 10:00:00,16.00,NaN,2.00,1.50\n",
+			wantErr: "第 2 行",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prior.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0644); err != nil {
+				t.Fatal(err)
+			}
+			records, err := ReadRecordsCSV(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误应包含 %q，实际 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("应读到1条记录，实际 %d 条", len(records))
+			}
+			got := records[0]
+			tt.want.Timestamp = got.Timestamp
+			if got.Timestamp.Format("2006-01-02 15:04:05") != "2024-06-11 10:00:00" || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("读到 %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRecords(t *testing.T) {
+	at := func(clock string) time.Time {
+		ts, err := time.Parse("15:04:05", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	record := func(host, clock string, free float64) MemoryRecord {
+		return MemoryRecord{Hostname: host, Timestamp: at(clock), MemTotal: 16, MemFree: free}
+	}
+
+	tests := []struct {
+		name       string
+		seed       []MemoryRecord
+		fresh      []MemoryRecord
+		want       []MemoryRecord
+		duplicates int
+	}{
+		{
+			name:  "fresh record wins",
+			seed:  []MemoryRecord{record("web1", "10:00:00", 4), record("web1", "10:00:10", 3)},
+			fresh: []MemoryRecord{record("web1", "10:00:10", 2), record("web1", "10:00:20", 1)},
+			want: []MemoryRecord{record("web1", "10:00:00", 4), record("web1", "10:00:10", 2),
+				record("web1", "10:00:20", 1)},
+			duplicates: 1,
+		},
+		{
+			name:  "same timestamp on different hosts",
+			seed:  []MemoryRecord{record("web2", "10:00:00", 4), record("web1", "10:00:00", 5)},
+			fresh: []MemoryRecord{record("web2", "10:00:10", 3)},
+			want: []MemoryRecord{record("web1", "10:00:00", 5), record("web2", "10:00:00", 4),
+				record("web2", "10:00:10", 3)},
+		},
+		{
+			name:       "host from source file name",
+			seed:       []MemoryRecord{record("db1", "10:00:00", 4)},
+			fresh:      []MemoryRecord{{Source: "/var/log/atop/db1.txt", Timestamp: at("10:00:00"), MemTotal: 16, MemFree: 2}},
+			want:       []MemoryRecord{{Source: "/var/log/atop/db1.txt", Timestamp: at("10:00:00"), MemTotal: 16, MemFree: 2}},
+			duplicates: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, duplicates := MergeRecords(tt.seed, tt.fresh)
+			if duplicates != tt.duplicates {
+				t.Errorf("重复 %d 条，期望 %d 条", duplicates, tt.duplicates)
+			}
+			if !reflect.DeepEqual(merged, tt.want) {
+				t.Errorf("合并结果 %+v，期望 %+v", merged, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVHostRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := []MemoryRecord{
+		{Hostname: "web1", Timestamp: ts, MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1},
+		{Hostname: "web2", Timestamp: ts, MemTotal: 8, MemFree: 2, SwapTotal: 2, SwapFree: 2},
+	}
+	path := filepath.Join(t.TempDir(), "prior.csv")
+	if err := writeCSV(data, path, ReportOptions{Precision: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	seed, err := ReadRecordsCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, duplicates := MergeRecords(seed, data[:1])
+	if len(merged) != 2 || duplicates != 1 {
+		t.Fatalf("合并后 %d 条、重复 %d 条，期望 2 条、重复 1 条", len(merged), duplicates)
+	}
+	for i, host := range []string{"web1", "web2"} {
+		if RecordHost(merged[i]) != host {
+			t.Errorf("第 %d 条记录的主机为 %q，期望 %q", i, RecordHost(merged[i]), host)
+		}
+	}
+}
+
+func TestRetainRecords(t *testing.T) {
+	day := func(n int) MemoryRecord {
+		return MemoryRecord{Hostname: "web1", Timestamp: time.Date(2024, 6, n, 10, 0, 0, 0, time.UTC), MemTotal: 16, MemFree: float64(n)}
+	}
+
+	tests := []struct {
+		name    string
+		data    []MemoryRecord
+		window  time.Duration
+		want    []MemoryRecord
+		dropped int
+	}{
+		{
+			name:    "drop older than window",
+			data:    []MemoryRecord{day(1), day(2), day(5), day(9)},
+			window:  7 * 24 * time.Hour,
+			want:    []MemoryRecord{day(2), day(5), day(9)},
+			dropped: 1,
+		},
+		{
+			name:    "window relative to newest not sorted",
+			data:    []MemoryRecord{day(9), day(1), day(8)},
+			window:  24 * time.Hour,
+			want:    []MemoryRecord{day(9), day(8)},
+			dropped: 1,
+		},
+		{
+			name:   "all within window",
+			data:   []MemoryRecord{day(1), day(2)},
+			window: 30 * 24 * time.Hour,
+			want:   []MemoryRecord{day(1), day(2)},
+		},
+		{
+			name:   "empty",
+			window: time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := RetainRecords(tt.data, tt.window)
+			if dropped != tt.dropped {
+				t.Errorf("丢弃 %d 条，期望 %d 条", dropped, tt.dropped)
+			}
+			if len(kept) != len(tt.want) || (len(kept) > 0 && !reflect.DeepEqual(kept, tt.want)) {
+				t.Errorf("保留 %+v，期望 %+v", kept, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package atopparse
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// intervalRegex 匹配日志头末尾的采样间隔，例如 "----- 10s elapsed"；
+// 较长的间隔可能带有天、小时、分钟，例如 "1h5m elapsed"
+var intervalRegex = regexp.MustCompile(`\s((?:\d+[dhms])+)\s+elapsed`)
+
+// missingGapFactor 相邻记录的间隔超过预期采样间隔的这个倍数时视为缺少数据
+const missingGapFactor = 3
+
+// maxMissingGapWarnings 缺少数据的时间段逐条列出的最大条数，其余只计数
+const maxMissingGapWarnings = 5
+
+// parseInterval 从日志头中取出采样间隔，没有或无法解析时返回0
+func parseInterval(line string) time.Duration {
+	matches := intervalRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return 0
+	}
+	interval, err := parseDays(matches[1])
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// parseDays 解析可以带天数的时长，例如 "7d"、"1d2h3m"、"12h"；time.ParseDuration不支持天
+func parseDays(value string) (time.Duration, error) {
+	var duration time.Duration
+	rest := value
+	if i := strings.Index(rest, "d"); i >= 0 {
+		days, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf(Tr("无效的时长 %q"), value)
+		}
+		duration = time.Duration(days) * 24 * time.Hour
+		rest = rest[i+1:]
+	}
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf(Tr("无效的时长 %q"), value)
+		}
+		duration += d
+	}
+	return duration, nil
+}
+
+// ParseRetention 解析 --retain 的保留时长，例如 "7d"、"36h"，必须大于0
+func ParseRetention(value string) (time.Duration, error) {
+	retain, err := parseDays(value)
+	if err != nil {
+		return 0, err
+	}
+	if retain <= 0 {
+		return 0, fmt.Errorf(Tr("保留时长必须大于0"))
+	}
+	return retain, nil
+}
+
+// ExpectedInterval 返回日志头中出现次数最多的采样间隔（次数相同时取较短的），没有时返回0。
+// atop启动后的第一个样本的间隔从开机算起，取众数可以排除这样的样本
+func ExpectedInterval(data []MemoryRecord) time.Duration {
+	counts := make(map[time.Duration]int)
+	for _, record := range data {
+		if record.Interval > 0 {
+			counts[record.Interval]++
+		}
+	}
+	var expected time.Duration
+	for interval, n := range counts {
+		if n > counts[expected] || (n == counts[expected] && interval < expected) {
+			expected = interval
+		}
+	}
+	return expected
+}
+
+// expectedIntervals 返回每条记录的预期采样间隔，用于采集中途改变采样间隔（例如由10s改为60s）的日志。
+// 日志头中的间隔在连续两个样本中相同时才采用，只出现一次的间隔（如atop启动后第一个样本从开机算起的间隔）
+// 沿用之前的间隔；第一段稳定的间隔之前的记录使用第一段稳定的间隔，完全没有时为fallback
+func expectedIntervals(records []MemoryRecord, fallback time.Duration) []time.Duration {
+	intervals := make([]time.Duration, len(records))
+	var stable time.Duration
+	for i, record := range records {
+		if record.Interval > 0 && (record.Interval == stable || (i+1 < len(records) && records[i+1].Interval == record.Interval)) {
+			stable = record.Interval
+		}
+		intervals[i] = stable
+	}
+	first := fallback
+	for _, interval := range intervals {
+		if interval > 0 {
+			first = interval
+			break
+		}
+	}
+	for i := 0; i < len(intervals) && intervals[i] == 0; i++ {
+		intervals[i] = first
+	}
+	return intervals
+}
+
+// IntervalChange 采集中途采样间隔的一次变化
+type IntervalChange struct {
+	Host string
+	At   time.Time // 第一个使用新间隔的样本
+	From time.Duration
+	To   time.Duration
+}
+
+// IntervalChanges 按主机返回已排序的记录中采样间隔的变化，只出现一次的间隔不算变化
+func IntervalChanges(data []MemoryRecord) []IntervalChange {
+	groups := GroupByHost(data)
+	hosts := make([]string, 0, len(groups))
+	for host := range groups {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var changes []IntervalChange
+	for _, host := range hosts {
+		records := groups[host]
+		intervals := expectedIntervals(records, 0)
+		for i := 1; i < len(records); i++ {
+			if intervals[i-1] > 0 && intervals[i] != intervals[i-1] {
+				changes = append(changes, IntervalChange{Host: host, At: records[i].Timestamp, From: intervals[i-1], To: intervals[i]})
+			}
+		}
+	}
+	return changes
+}
+
+// PrintIntervalChanges 向w列出采样间隔的变化，换页速率和磁盘吞吐量按每个样本自己的间隔换算，不受影响
+func PrintIntervalChanges(w io.Writer, data []MemoryRecord) {
+	for _, change := range IntervalChanges(data) {
+		fmt.Fprintf(w, Tr("注意: %s 的采样间隔在 %s 由 %v 变为 %v\n"), change.Host,
+			change.At.Format("2006-01-02 15:04:05"), change.From, change.To)
+	}
+}
+
+// warnMissingSamples 按主机检查已排序的记录，相邻记录的间隔超过预期采样间隔的missingGapFactor倍时给出警告，
+// 向w列出前maxMissingGapWarnings段缺少数据的时间。预期间隔按expectedIntervals逐个样本取，
+// 采样间隔中途变化时不会把变化后的正常间隔当作缺少数据。日志头没有采样间隔的主机不检查
+func warnMissingSamples(w io.Writer, data []MemoryRecord) {
+	groups := GroupByHost(data)
+	hosts := make([]string, 0, len(groups))
+	for host := range groups {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		records := groups[host]
+		if ExpectedInterval(records) <= 0 {
+			continue
+		}
+		intervals := expectedIntervals(records, 0)
+		var missing int
+		for i := 1; i < len(records); i++ {
+			gap, expected := records[i].Timestamp.Sub(records[i-1].Timestamp), intervals[i]
+			if gap <= expected*missingGapFactor {
+				continue
+			}
+			missing++
+			if missing <= maxMissingGapWarnings {
+				fmt.Fprintf(w, Tr("警告: %[1]s 在 %[2]s 到 %[3]s 之间缺少数据（间隔 %[4]v，预期采样间隔 %[5]v）\n"), host,
+					records[i-1].Timestamp.Format("2006-01-02 15:04:05"), records[i].Timestamp.Format("2006-01-02 15:04:05"), gap, expected)
+			}
+		}
+		if missing > maxMissingGapWarnings {
+			fmt.Fprintf(w, Tr("警告: %s 另有 %d 段缺少数据未列出\n"), host, missing-maxMissingGapWarnings)
+		}
+	}
+}
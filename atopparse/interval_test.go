@@ -0,0 +1,134 @@
+package atopparse
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// changingIntervalLog 生成采样间隔中途变化的atop日志：每个元素为距10:00:00的秒数和日志头中的间隔，
+// 每个采样块换入100页，读100个1024KB的请求
+func changingIntervalLog(samples [][2]int) string {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	var log strings.Builder
+	for _, sample := range samples {
+		fmt.Fprintf(&log, "ATOP - web1  %s  --------  %ds elapsed\n", start.Add(time.Duration(sample[0])*time.Second).Format("2006/01/02  15:04:05"), sample[1])
+		log.WriteString("MEM | tot 16.0G | free 4.0G |\n")
+		log.WriteString("SWP | tot 2.0G | free 1.5G |\n")
+		log.WriteString("PAG | scan 0 | stall 0 | swin 100 | swout 0 |\n")
+		log.WriteString("DSK | sda | busy 5% | read 100 | write 0 | KB/read 1024 | KB/writ 0 |\n")
+	}
+	return log.String()
+}
+
+func TestChangingInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     [][2]int
+		wantChanges []string
+		wantMissing int
+		wantRates   []float64 // 每秒换入页数，同时也是每秒读取的MB数
+	}{
+		{
+			name:        "10s to 60s",
+			samples:     [][2]int{{0, 10}, {10, 10}, {20, 10}, {80, 60}, {140, 60}, {200, 60}},
+			wantChanges: []string{"2024-06-11 10:01:20 10s->1m0s"},
+			wantRates:   []float64{10, 10, 10, 100.0 / 60, 100.0 / 60, 100.0 / 60},
+		},
+		{
+			name:        "missing data after change",
+			samples:     [][2]int{{0, 10}, {10, 10}, {70, 60}, {130, 60}, {370, 60}, {430, 60}},
+			wantChanges: []string{"2024-06-11 10:01:10 10s->1m0s"},
+			wantMissing: 1,
+			wantRates:   []float64{10, 10, 100.0 / 60, 100.0 / 60, 100.0 / 60, 100.0 / 60},
+		},
+		{
+			name:      "first sample since boot",
+			samples:   [][2]int{{0, 3600}, {10, 10}, {20, 10}, {30, 10}},
+			wantRates: []float64{100.0 / 3600, 10, 10, 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "atop.txt"), []byte(changingIntervalLog(tt.samples)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var log bytes.Buffer
+			data, err := ParseDirectory(dir, ParseOptions{Log: &log}, NewDetectionInfo())
+			if err != nil {
+				t.Fatal(err)
+			}
+			PrintIntervalChanges(&log, data)
+
+			var changes []string
+			for _, change := range IntervalChanges(data) {
+				changes = append(changes, fmt.Sprintf("%s %v->%v", change.At.Format("2006-01-02 15:04:05"), change.From, change.To))
+			}
+			if strings.Join(changes, ";") != strings.Join(tt.wantChanges, ";") {
+				t.Errorf("采样间隔变化为 %v，期望 %v", changes, tt.wantChanges)
+			}
+			if got := strings.Count(log.String(), "缺少数据"); got != tt.wantMissing {
+				t.Errorf("缺少数据的警告有 %d 条，期望 %d 条:\n%s", got, tt.wantMissing, log.String())
+			}
+			if len(tt.wantChanges) > 0 && !strings.Contains(log.String(), "由 10s 变为 1m0s") {
+				t.Errorf("没有报告采样间隔的变化:\n%s", log.String())
+			}
+
+			for i, rate := range swapRates(data) {
+				if math.Abs(rate.In-tt.wantRates[i]) > 1e-9 {
+					t.Errorf("第 %d 个样本换入速率为 %v，期望 %v", i, rate.In, tt.wantRates[i])
+				}
+			}
+			for i, record := range data {
+				if disk := record.Disks[0]; math.Abs(disk.ReadMBps-tt.wantRates[i]) > 1e-9 {
+					t.Errorf("第 %d 个样本读吞吐量为 %v MB/s，期望 %v", i, disk.ReadMBps, tt.wantRates[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGapSegmentsChangingInterval(t *testing.T) {
+	data, _ := parseTestLog(t, changingIntervalLog([][2]int{{0, 10}, {10, 10}, {20, 10}, {80, 60}, {140, 60}, {440, 60}, {500, 60}}), ParseOptions{})
+	segments, gaps := GapSegments(data, ChartOptions{MaxGap: 2})
+	if len(gaps) != 1 || !gaps[0].End.Equal(data[5].Timestamp) {
+		t.Fatalf("只应在10:02:20到10:07:20之间断开，实际 %+v", gaps)
+	}
+	if len(segments) != 2 || len(segments[0]) != 5 || len(segments[1]) != 2 {
+		t.Errorf("应切分为5个和2个样本的两段，实际 %d 段", len(segments))
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "36h", want: 36 * time.Hour},
+		{value: "1d12h30m", want: 36*time.Hour + 30*time.Minute},
+		{value: "d", wantErr: true},
+		{value: "7days", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "-1d", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRetention(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望出错 %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("保留时长为 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
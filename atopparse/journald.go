@@ -0,0 +1,29 @@
+package atopparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// journalPrefixRegex 匹配journalctl默认的short格式（"Jun 11 10:00:00 host atop[123]: "）
+// 和short-iso等格式（"2024-06-11T10:00:00+0800 host atop[123]: "）的行前缀
+var journalPrefixRegex = regexp.MustCompile(`^(?:\w{3} [ \d]\d \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) \S+ [^\s:\[]+(?:\[\d+\])?: `)
+
+// exportFieldRegex 匹配导出格式中MESSAGE以外的字段行，例如 "_PID=123"、"__REALTIME_TIMESTAMP=..."
+var exportFieldRegex = regexp.MustCompile(`^_{0,2}[A-Z][A-Z0-9_]*=`)
+
+// stripJournalPrefix 去掉journald加在每行atop输出前的前缀；
+// 导出格式（journalctl -o export）中只保留MESSAGE字段的内容，其余字段返回空行。
+// -o cat 输出的行没有前缀，原样返回
+func stripJournalPrefix(line string) string {
+	if message, ok := strings.CutPrefix(line, "MESSAGE="); ok {
+		return message
+	}
+	if loc := journalPrefixRegex.FindStringIndex(line); loc != nil {
+		return line[loc[1]:]
+	}
+	if exportFieldRegex.MatchString(line) {
+		return ""
+	}
+	return line
+}
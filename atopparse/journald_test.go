@@ -0,0 +1,80 @@
+package atopparse
+
+import "testing"
+
+func TestStripJournalPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short format", "Jun 11 10:00:00 web1 atop[123]: MEM | tot 16.0G | free 4.0G |", "MEM | tot 16.0G | free 4.0G |"},
+		{"short format single digit day", "Jun  1 10:00:00 web1 atop[123]: SWP | tot 2.0G | free 1.5G |", "SWP | tot 2.0G | free 1.5G |"},
+		{"short-iso format", "2024-06-11T10:00:00+0800 web1 atop[123]: ATOP - web1  2024/06/11  10:00:00", "ATOP - web1  2024/06/11  10:00:00"},
+		{"identifier without pid", "Jun 11 10:00:00 web1 atop: MEM | tot 16.0G |", "MEM | tot 16.0G |"},
+		{"export message field", "MESSAGE=MEM | tot 16.0G | free 4.0G |", "MEM | tot 16.0G | free 4.0G |"},
+		{"export metadata field", "_PID=123", ""},
+		{"export realtime field", "__REALTIME_TIMESTAMP=1718071200000000", ""},
+		{"cat output unchanged", "MEM | tot 16.0G | free 4.0G |", "MEM | tot 16.0G | free 4.0G |"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripJournalPrefix(tt.line); got != tt.want {
+				t.Errorf("去掉前缀后为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJournald(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"short format", `Jun 11 10:00:00 web1 atop[123]: ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+Jun 11 10:00:00 web1 atop[123]: MEM | tot 16.0G | free 4.0G |
+Jun 11 10:00:00 web1 atop[123]: SWP | tot 2.0G | free 1.5G |
+Jun 11 10:00:10 web1 atop[123]: ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+Jun 11 10:00:10 web1 atop[123]: MEM | tot 16.0G | free 3.0G |
+Jun 11 10:00:10 web1 atop[123]: SWP | tot 2.0G | free 1.0G |
+`},
+		{"short-iso format", `2024-06-11T10:00:00+0800 web1 atop[123]: ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+2024-06-11T10:00:00+0800 web1 atop[123]: MEM | tot 16.0G | free 4.0G |
+2024-06-11T10:00:00+0800 web1 atop[123]: SWP | tot 2.0G | free 1.5G |
+2024-06-11T10:00:10+0800 web1 atop[123]: ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+2024-06-11T10:00:10+0800 web1 atop[123]: MEM | tot 16.0G | free 3.0G |
+2024-06-11T10:00:10+0800 web1 atop[123]: SWP | tot 2.0G | free 1.0G |
+`},
+		{"export format", `__REALTIME_TIMESTAMP=1718071200000000
+_PID=123
+MESSAGE=ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+
+_PID=123
+MESSAGE=MEM | tot 16.0G | free 4.0G |
+
+_PID=123
+MESSAGE=SWP | tot 2.0G | free 1.5G |
+
+_PID=123
+MESSAGE=ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+
+_PID=123
+MESSAGE=MEM | tot 16.0G | free 3.0G |
+
+_PID=123
+MESSAGE=SWP | tot 2.0G | free 1.0G |
+`},
+		{"cat output", remoteTestLog},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := parseTestLog(t, tt.content, ParseOptions{Journald: true})
+			if len(data) != 2 {
+				t.Fatalf("解析出 %d 条记录，期望 2 条", len(data))
+			}
+			if data[0].Hostname != "web1" || data[1].MemFree != 3 || data[1].SwapFree != 1 {
+				t.Errorf("记录为 %+v", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package atopparse
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+)
+
+// jsonRecord 是--format json输出中的一条记录，字段名与CSV列名一致；
+// 没有CPU数据的记录省略CPU字段，没有--derive时省略derived
+type jsonRecord struct {
+	Timestamp  time.Time          `json:"timestamp"`
+	MemTotal   float64            `json:"mem_tot"`
+	MemFree    float64            `json:"mem_free"`
+	SwapTotal  float64            `json:"swp_tot"`
+	SwapFree   float64            `json:"swp_free"`
+	MemCache   float64            `json:"mem_cache"`
+	MemBuff    float64            `json:"mem_buff"`
+	MemSlabRec float64            `json:"mem_slrec"`
+	MemPct     float64            `json:"mem_used_pct"`
+	SwapPct    float64            `json:"swp_used_pct"`
+	MemAvail   float64            `json:"mem_avail_est"`
+	CPUSys     *float64           `json:"cpu_sys,omitempty"`
+	CPUUser    *float64           `json:"cpu_user,omitempty"`
+	CPUIdle    *float64           `json:"cpu_idle,omitempty"`
+	CPUWait    *float64           `json:"cpu_wait,omitempty"`
+	Derived    map[string]float64 `json:"derived,omitempty"` // 求值失败（例如除以0）的派生指标不出现
+}
+
+// RoundValue 将数值舍入到指定的小数位数，用于JSON输出
+func RoundValue(value float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(value*scale) / scale
+}
+
+// WriteJSON 将记录以JSON数组写到w，时间戳为ISO-8601格式，数值按opts.Precision舍入
+func WriteJSON(w io.Writer, data []MemoryRecord, opts ReportOptions) error {
+	round := func(value float64) float64 { return RoundValue(value, opts.Precision) }
+	optional := func(value float64) *float64 {
+		value = round(value)
+		return &value
+	}
+
+	records := make([]jsonRecord, len(data))
+	for i, record := range data {
+		records[i] = jsonRecord{
+			Timestamp:  record.Timestamp,
+			MemTotal:   round(record.MemTotal),
+			MemFree:    round(record.MemFree),
+			SwapTotal:  round(record.SwapTotal),
+			SwapFree:   round(record.SwapFree),
+			MemCache:   round(record.MemCache),
+			MemBuff:    round(record.MemBuff),
+			MemSlabRec: round(record.MemSlabRec),
+			MemPct:     round(record.MemUsedPct()),
+			SwapPct:    round(record.SwapUsedPct()),
+			MemAvail:   round(record.MemAvailableEst()),
+		}
+		if record.HasCPU {
+			records[i].CPUSys = optional(record.CPUSys)
+			records[i].CPUUser = optional(record.CPUUser)
+			records[i].CPUIdle = optional(record.CPUIdle)
+		}
+		if record.HasCPUWait {
+			records[i].CPUWait = optional(record.CPUWait)
+		}
+		for _, series := range opts.Derived {
+			if value, ok := series.eval(record); ok {
+				if records[i].Derived == nil {
+					records[i].Derived = make(map[string]float64, len(opts.Derived))
+				}
+				records[i].Derived[series.Name] = round(value)
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(content, '\n'))
+	return err
+}
+
+// writeJSONFile 将记录写到JSON文件，outputFile为StdoutPath时写到标准输出
+func writeJSONFile(data []MemoryRecord, outputFile string, opts ReportOptions) error {
+	out, err := CreateOutput(outputFile, opts.Gzip)
+	if err != nil {
+		return err
+	}
+	if err := WriteJSON(out, data, opts); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
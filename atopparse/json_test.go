@@ -0,0 +1,71 @@
+package atopparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJSON(t *testing.T) {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	memory := MemoryRecord{Timestamp: ts, MemTotal: 16, MemFree: 4.126, SwapTotal: 2, SwapFree: 1.5, MemCache: 2, MemBuff: 0.5}
+	withCPU := memory
+	withCPU.HasCPU, withCPU.CPUSys, withCPU.CPUUser, withCPU.CPUIdle = true, 5, 20.004, 75
+	withCPU.HasCPUWait, withCPU.CPUWait = true, 1.5
+
+	memoryFields := []string{"mem_avail_est", "mem_buff", "mem_cache", "mem_free", "mem_slrec", "mem_tot", "mem_used_pct", "swp_free", "swp_tot", "swp_used_pct", "timestamp"}
+	tests := []struct {
+		name       string
+		record     MemoryRecord
+		wantFields []string
+		want       map[string]any
+	}{
+		{"memory only", memory, memoryFields,
+			map[string]any{"timestamp": "2024-06-11T10:00:00+08:00", "mem_tot": 16.0, "mem_free": 4.13, "mem_used_pct": 74.21, "swp_used_pct": 25.0}},
+		{"with cpu", withCPU, append([]string{"cpu_idle", "cpu_sys", "cpu_user", "cpu_wait"}, memoryFields...),
+			map[string]any{"cpu_sys": 5.0, "cpu_user": 20.0, "cpu_idle": 75.0, "cpu_wait": 1.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteJSON(&buf, []MemoryRecord{tt.record}, ReportOptions{Precision: 2}); err != nil {
+				t.Fatal(err)
+			}
+			var records []map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+				t.Fatalf("不是有效的JSON数组: %v\n%s", err, buf.String())
+			}
+			if len(records) != 1 {
+				t.Fatalf("JSON中有 %d 条记录，期望 1 条", len(records))
+			}
+
+			var fields []string
+			for field := range records[0] {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			sort.Strings(tt.wantFields)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("字段为 %v，期望 %v", fields, tt.wantFields)
+			}
+			for field, want := range tt.want {
+				if records[0][field] != want {
+					t.Errorf("%s 为 %v，期望 %v", field, records[0][field], want)
+				}
+			}
+		})
+	}
+
+	// 没有记录时输出空数组而不是null
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, nil, ReportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("没有记录时输出 %q，期望 []", buf.String())
+	}
+}
@@ -0,0 +1,12 @@
+package atopparse
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// 测试按中文消息断言，不受运行测试的终端的语言设置影响
+	Locale = "zh"
+	os.Exit(m.Run())
+}
@@ -0,0 +1,89 @@
+package atopparse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// markdownLowestFree 是Markdown报告中列出的空闲内存最低的时刻数
+const markdownLowestFree = 10
+
+// lowestFreeRecords 返回空闲内存最低的n条记录，空闲内存相同时较早的在前，不修改data
+func lowestFreeRecords(data []MemoryRecord, n int) []MemoryRecord {
+	sorted := make([]MemoryRecord, len(data))
+	copy(sorted, data)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].MemFree != sorted[j].MemFree {
+			return sorted[i].MemFree < sorted[j].MemFree
+		}
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// formatMarkdown 生成便于粘贴到工单中的Markdown报告：采集时间范围、样本数、
+// 与统计摘要相同的统计表、空闲内存最低的时刻，chartFile不为空时以相对路径嵌入图表
+func formatMarkdown(data []MemoryRecord, stats memoryStats, chartFile string, precision int) string {
+	first, last := data[0].Timestamp, data[0].Timestamp
+	for _, record := range data {
+		if record.Timestamp.Before(first) {
+			first = record.Timestamp
+		}
+		if record.Timestamp.After(last) {
+			last = record.Timestamp
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", Tr("内存使用报告"))
+	fmt.Fprintf(&b, "- %s: %s – %s\n", Tr("采集时间范围"), first.Format("2006-01-02 15:04:05"), last.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- %s: %d\n\n", Tr("记录数"), stats.Samples)
+
+	fmt.Fprintf(&b, "## %s\n\n", Tr("统计（单位 GB）"))
+	b.WriteString("| | min | max | mean | p50 | p95 | p99 |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	rows := []struct {
+		name  string
+		stats seriesStats
+	}{
+		{"mem_used", stats.MemUsed},
+		{"swp_used", stats.SwapUsed},
+	}
+	for _, row := range rows {
+		s := row.stats
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", row.name,
+			FormatValue(s.Min, precision), FormatValue(s.Max, precision), FormatValue(s.Mean, precision),
+			FormatValue(s.P50, precision), FormatValue(s.P95, precision), FormatValue(s.P99, precision))
+	}
+	b.WriteString("\n")
+
+	lowest := lowestFreeRecords(data, markdownLowestFree)
+	fmt.Fprintf(&b, "## %s\n\n", fmt.Sprintf(Tr("空闲内存最低的 %d 个时刻"), len(lowest)))
+	b.WriteString("| timestamp | mem_free | mem_used | mem_used_pct | swp_used |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+	for _, record := range lowest {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			record.Timestamp.Format("2006-01-02 15:04:05"),
+			FormatValue(record.MemFree, precision),
+			FormatValue(record.MemTotal-record.MemFree, precision),
+			FormatValue(record.MemUsedPct(), precision),
+			FormatValue(record.SwapTotal-record.SwapFree, precision))
+	}
+
+	if chartFile != "" {
+		// 报告与图表在同一目录，使用相对路径以便整个目录一起附到工单中
+		fmt.Fprintf(&b, "\n## %s\n\n![memory/swap](%s)\n", Tr("内存使用图表"), filepath.Base(chartFile))
+	}
+	return b.String()
+}
+
+// writeMarkdownReport 将Markdown报告写入文件
+func writeMarkdownReport(data []MemoryRecord, stats memoryStats, outputFile, chartFile string, precision int) error {
+	return os.WriteFile(outputFile, []byte(formatMarkdown(data, stats, chartFile, precision)), 0644)
+}
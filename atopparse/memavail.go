@@ -0,0 +1,28 @@
+package atopparse
+
+import (
+	"image/color"
+	"regexp"
+)
+
+// memSlrecRegex 匹配MEM行中可回收slab的slrec字段，例如 "| slab 435.1M | slrec 340.2M |"，较旧版本atop没有该字段
+var memSlrecRegex = regexp.MustCompile(`\| slrec\s+([\d.]+)([A-Za-z])`)
+
+// memAvailColumn 是紧跟在使用率列之后的估算可用内存列（GB）
+const memAvailColumn = "mem_avail_est"
+
+// memAvailColor 内存图表中估算可用内存曲线的颜色，不随 --palette 变化
+var memAvailColor = color.RGBA{G: 139, B: 139, A: 255}
+
+// MemAvailableEst 按 free + cache + buff + slrec 估算可用内存（GB），结果不超过MemTotal。
+// 与内核 /proc/meminfo 的 MemAvailable 相比，内核还会减去各内存区域的低水位预留，
+// 并认为页缓存和可回收slab各有一部分（不超过低水位）不能回收；这里把全部页缓存都算作可回收，
+// 其中的脏页、共享内存和tmpfs实际上不能直接丢弃，因此估算值通常偏高，在内存紧张时偏差更明显。
+// 较旧版本atop的MEM行没有cache/buff/slrec字段时，对应部分为0，估算值接近free
+func (r MemoryRecord) MemAvailableEst() float64 {
+	avail := r.MemFree + r.MemCache + r.MemBuff + r.MemSlabRec
+	if r.MemTotal > 0 && avail > r.MemTotal {
+		return r.MemTotal
+	}
+	return avail
+}
@@ -0,0 +1,77 @@
+package atopparse
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMemAvailableEst(t *testing.T) {
+	tests := []struct {
+		name   string
+		record MemoryRecord
+		want   float64
+	}{
+		{"free only", MemoryRecord{MemTotal: 16, MemFree: 4}, 4},
+		{"free cache buff slrec", MemoryRecord{MemTotal: 16, MemFree: 1, MemCache: 5, MemBuff: 0.5, MemSlabRec: 0.5}, 7},
+		{"capped at total", MemoryRecord{MemTotal: 8, MemFree: 4, MemCache: 4, MemBuff: 1, MemSlabRec: 1}, 8},
+		{"no total", MemoryRecord{MemFree: 1, MemCache: 2}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.record.MemAvailableEst(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("估算可用内存为 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMemSlabRec(t *testing.T) {
+	tests := []struct {
+		name      string
+		mem       string
+		wantSlrec float64
+		wantAvail string
+	}{
+		{"with slrec", "MEM | tot 16.0G | free 1.0G | cache 5.0G | dirty 0.1M | buff 512.0M | slab 1.0G | slrec 512.0M |", 0.5, "7.00"},
+		{"without slrec", "MEM | tot 16.0G | free 1.0G | cache 5.0G | buff 512.0M |", 0, "6.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := parseTestLog(t, "ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed\n"+tt.mem+"\nSWP | tot 2.0G | free 1.5G |\n", ParseOptions{})
+			if len(data) != 1 {
+				t.Fatalf("应解析出一条记录，实际 %d 条", len(data))
+			}
+			if math.Abs(data[0].MemSlabRec-tt.wantSlrec) > 1e-9 {
+				t.Errorf("mem_slrec为 %v，期望 %v", data[0].MemSlabRec, tt.wantSlrec)
+			}
+
+			path := filepath.Join(t.TempDir(), "report.csv")
+			if err := writeCSV(data, path, ReportOptions{Precision: 2}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(content), "\n")
+			header, row := strings.Split(lines[0], ","), strings.Split(lines[1], ",")
+			for i, name := range header {
+				if name == memAvailColumn && row[i] != tt.wantAvail {
+					t.Errorf("%s列为 %q，期望 %q", memAvailColumn, row[i], tt.wantAvail)
+				}
+			}
+
+			// 读回CSV后slrec保留，估算值不变
+			records, err := ReadRecordsCSV(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(records[0].MemAvailableEst()-data[0].MemAvailableEst()) > 1e-9 {
+				t.Errorf("读回后估算可用内存为 %v，期望 %v", records[0].MemAvailableEst(), data[0].MemAvailableEst())
+			}
+		})
+	}
+}
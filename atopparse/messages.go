@@ -0,0 +1,478 @@
+package atopparse
+
+import (
+	"os"
+	"strings"
+)
+
+// Locale 控制台消息的语言：zh（中文，默认）或en
+var Locale = DefaultLocale()
+
+// DefaultLocale 按LC_ALL、LC_MESSAGES、LANG的优先级推断默认语言，
+// 以en开头（如 en_US.UTF-8）时为en，其余情况为zh
+func DefaultLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if strings.HasPrefix(value, "en") {
+				return "en"
+			}
+			return "zh"
+		}
+	}
+	return "zh"
+}
+
+// Tr 返回消息在当前语言下的文本。消息以中文原文为键，
+// 英文格式字符串中的占位符与原文一一对应（顺序不同时使用 %[n]d 形式的显式下标）；
+// 表中没有的消息原样返回
+func Tr(message string) string {
+	if Locale == "en" {
+		if translated, ok := MessagesEN[message]; ok {
+			return translated
+		}
+	}
+	return message
+}
+
+// MessagesEN 中文消息到英文的对照表，新增控制台消息时需要同时在这里添加英文
+var MessagesEN = map[string]string{
+	"用法: %s [参数]\n":                       "Usage: %s [options]\n",
+	"错误: 不支持的语言 %s，可选 zh 或 en\n":          "error: unsupported locale %s, choose zh or en\n",
+	"不支持的语言 %s":                           "unsupported locale %s",
+	"控制台消息的语言: zh 或 en，默认按 LANG 环境变量推断":   "language of console messages: zh or en, inferred from the LANG environment variable by default",
+	"自动识别摘要:":                             "Detection summary:",
+	"  主机数量: %d (%s)\n":                   "  Hosts: %d (%s)\n",
+	"  容量单位分布: %s\n":                      "  Capacity units: %s\n",
+	"  时间戳格式: %s\n":                       "  Timestamp layouts: %s\n",
+	"  含多条MEM行的采样块: %d\n":                 "  Sample blocks with multiple MEM lines: %d\n",
+	"  含多条SWP行的采样块: %d\n":                 "  Sample blocks with multiple SWP lines: %d\n",
+	"  含PSI内存压力数据的采样块: %d\n":              "  Sample blocks with PSI memory pressure: %d\n",
+	"  时区假设: 按日志中的本地时间解析，不做时区转换":          "  Time zone: log timestamps are read as local time, no conversion applied",
+	"无法解析时间戳 %q":                          "cannot parse timestamp %q",
+	"目录 %s 不存在: %v":                       "directory %s does not exist: %v",
+	"%s 不是一个目录":                           "%s is not a directory",
+	"警告: 目录 %s 中没有找到文件\n":                 "warning: no files found in directory %s\n",
+	"解析文件 %s 时出错: %v":                     "error parsing file %s: %v",
+	"解析文件 %s 时出错: %v\n":                   "error parsing file %s: %v\n",
+	"成功解析文件: %s, 找到 %d 条记录\n":             "parsed file: %s, found %d records\n",
+	"文件 %s 中没有找到有效数据":                     "no valid data found in file %s",
+	"文件 %s 中没有找到有效数据\n":                   "no valid data found in file %s\n",
+	"跳过了 %d 个没有有效数据的文件\n":                 "skipped %d files without valid data\n",
+	"警告: 指定了 --no-sort 但记录并非按时间排列，仍然进行排序": "warning: --no-sort was given but the records are not in time order; sorting anyway",
+	"总共从 %d 个文件中解析出 %d 条记录\n":             "parsed %[2]d records from %[1]d files in total\n",
+	"没有找到有效数据":                            "no valid data found",
+	"已保存CSV文件: %s\n":                      "saved CSV file: %s\n",
+	"已保存长格式CSV文件: %s\n":                   "saved long-format CSV file: %s\n",
+	"警告: OpenMetrics 输出中跳过了 %d 个同一主机内时间戳重复的样本\n": "warning: skipped %d samples with duplicate timestamps within a host in the OpenMetrics output\n",
+	"已保存OpenMetrics文件: %s\n": "saved OpenMetrics file: %s\n",
+	"已保存内存使用图表: %s\n":        "saved memory usage chart: %s\n",
+	"已保存交互式SVG图表: %s\n":      "saved interactive SVG chart: %s\n",
+	"已保存内存压力(PSI)图表: %s\n":   "saved memory pressure (PSI) chart: %s\n",
+	"已保存交互式HTML报告: %s\n":     "saved interactive HTML report: %s\n",
+	"已保存内存分布直方图: %s\n":       "saved memory distribution histogram: %s\n",
+	"没有可绘制的数据":               "no data to plot",
+	"swap disabled：整个时间段内交换空间总量为 0，已省略交换空间曲线":                 "swap disabled: swap total is 0 for the whole period, swap series omitted",
+	"单个atop日志文件的路径，也可以是http://或https://地址，- 表示标准输入":           "path of a single atop log file, an http:// or https:// URL, or - for standard input",
+	"单个atop日志文件的路径，也可以是http://或https://地址，- 表示标准输入 (简写)":      "path of a single atop log file, an http:// or https:// URL, or - for standard input (shorthand)",
+	"包含多个atop日志文件的目录路径":                                       "directory containing multiple atop log files",
+	"包含多个atop日志文件的目录路径 (简写)":                                  "directory containing multiple atop log files (shorthand)",
+	"输出文件前缀 (默认: memory_report)":                              "output file prefix (default: memory_report)",
+	"输出文件前缀 (简写)":                                             "output file prefix (shorthand)",
+	"生成交互式HTML报告，可查看每个时间点的详细数据":                               "generate an interactive HTML report showing the details of each sample",
+	"目录模式下遇到第一个解析出错或没有有效数据的文件时立即退出":                           "in directory mode, exit immediately on the first file that fails to parse or has no valid data",
+	"不输出格式自动识别摘要":                                             "do not print the format detection summary",
+	"先载入之前生成的CSV，再与本次解析的记录合并（按主机和时间戳去重，以本次解析结果为准）":            "load a previously generated CSV first and merge it with the newly parsed records (deduplicated by host and timestamp, newly parsed records win)",
+	"输出内存状态越过阈值的进入/恢复事件时间线":                                   "print a timeline of enter/recover events when memory state crosses a threshold",
+	"--transitions 中空闲内存低于该值(GB)视为内存紧张，0表示不跟踪":                "--transitions treats free memory below this value (GB) as memory pressure, 0 disables it",
+	"--transitions 中交换空间使用超过该值(GB)视为开始使用swap，0表示不跟踪":          "--transitions treats swap usage above this value (GB) as swapping, 0 disables it",
+	"CSV行顺序: asc (按时间正序) 或 desc (最新的在前)":                      "CSV row order: asc (oldest first) or desc (newest first)",
+	"在终端输出空闲内存和交换空间的迷你趋势图":                                    "print sparklines of free memory and free swap in the terminal",
+	"额外生成长格式CSV (timestamp,metric,device,value)，便于pandas/R分析": "also write a long-format CSV (timestamp,metric,device,value) for pandas/R analysis",
+	"不生成PNG内存使用图表":                                            "do not generate the PNG memory usage chart",
+	"生成内存分布直方图PNG并输出各区间的样本数":                                  "generate a memory distribution histogram PNG and print the sample count per bin",
+	"直方图统计的指标: free (空闲内存) 或 used (已用内存)":                     "histogram metric: free (free memory) or used (used memory)",
+	"直方图分桶数": "number of histogram bins",
+	"只保留每天指定时段内的样本，例如 09:00-18:00（开始包含、结束不包含）":                                         "keep only samples within this daily time window, e.g. 09:00-18:00 (start inclusive, end exclusive)",
+	"只保留指定星期的样本，例如 mon-fri 或 sat,sun":                                                  "keep only samples on these weekdays, e.g. mon-fri or sat,sun",
+	"从HTTP(S)地址读取日志的超时时间":                                                              "timeout for reading logs from HTTP(S) URLs",
+	"同一采样块出现多条MEM行时的处理方式: first (只保留第一条，即系统总量) 或 sum (累加各行)":                           "how to handle multiple MEM lines in one sample block: first (keep the first, i.e. the system total) or sum (add them up)",
+	"同一采样块出现多条SWP行(多个交换设备)时的处理方式: sum (累加为总量) 或 first (只保留第一条)":                        "how to handle multiple SWP lines (several swap devices) in one sample block: sum (add up to the total) or first (keep the first)",
+	"日志头时间戳的解析格式（Go时间格式，例如 02-01-2006 15:04:05），默认自动识别":                                "layout of the log header timestamps (Go time layout, e.g. 02-01-2006 15:04:05); detected automatically by default",
+	"每个日志文件开头丢弃的样本数，用于去掉atop刚启动时不可靠的数据":                                                "number of samples to drop at the start of each log file, to skip unreliable data right after atop starts",
+	"检测疑似重启（长时间无数据后空闲内存大幅回升），在摘要中列出并在图表中标注":                                            "detect likely reboots (free memory jumps back up after a long gap), list them in the summary and mark them on the chart",
+	"判定重启所需的最小采样间隔":                                                                    "minimum sampling gap for a reboot",
+	"判定重启所需的空闲内存最小回升量(GB)":                                                             "minimum free memory increase (GB) for a reboot",
+	"不在CSV和HTML报告末尾记录工具版本和命令行":                                                         "do not record the tool version and command line at the end of CSV and HTML reports",
+	"只校验指定的CSV文件能否被 --seed-from 读取（列名和字段类型），报告第一个出错的行后退出":                              "only check whether the given CSV can be read by --seed-from (column names and field types), report the first bad line and exit",
+	"列出空闲内存最低的N个样本分别来自哪些日志文件，0表示不输出":                                                   "list which log files the N samples with the lowest free memory come from, 0 disables it",
+	"目录模式下假定按文件名顺序合并的记录已按时间排列而跳过排序；检查发现乱序时仍会排序":                                        "in directory mode, assume records merged in file name order are already in time order and skip sorting; still sorts if they are not",
+	"比较多个日志文件重叠时段的内存曲线，估计各文件之间的时钟偏移":                                                   "estimate the clock offset between log files by comparing memory curves over overlapping periods",
+	"按估计的时钟偏移校正各日志文件的时间戳（隐含 --clock-skew）":                                             "shift each log file's timestamps by the estimated clock offset (implies --clock-skew)",
+	"估计时钟偏移时搜索的最大偏移量":                                                                  "maximum offset searched when estimating clock skew",
+	"YAML规则文件，定义多条带级别的阈值规则；有越界时按命中的最高级别退出（warning为2，critical为3）":                       "YAML rules file defining several threshold rules with severities; on breach, exit with the highest severity hit (2 for warning, 3 for critical)",
+	"只输出阈值越界的时间窗口（阈值同 --transition-mem-free/--transition-swap-used），不生成完整报告；有越界时退出码为2": "only write the threshold breach windows (thresholds as --transition-mem-free/--transition-swap-used), no full report; exit code 2 on breach",
+	"--breaches-only 的输出格式: csv 或 json":                                                "output format of --breaches-only: csv or json",
+	"额外生成 <前缀>_memory_swap.svg，悬停在数据点上时由浏览器显示提示，不依赖JavaScript":                         "also write <prefix>_memory_swap.svg, whose data points show browser tooltips on hover without JavaScript",
+	"PNG图表的X轴标注为距第一个样本的经过时间 HH:MM:SS，并在CSV末尾追加 elapsed 列，适用于基准测试":                      "label the PNG chart X axis with elapsed time HH:MM:SS since the first sample and append an elapsed column to the CSV, for benchmarks",
+	"CSV（含长格式CSV）和--breaches-only的CSV/JSON输出经gzip压缩，文件名追加.gz；PNG和HTML不压缩":              "gzip the CSV (including the long-format CSV) and --breaches-only CSV/JSON output and append .gz; PNG and HTML are not compressed",
+	"目录模式下除合并报告外，再为每个日志文件单独生成一份报告，前缀为 <前缀>_<文件名>":                                      "in directory mode, also write one report per log file besides the merged report, prefixed <prefix>_<file name>",
+	"CSV和JSON输出中数值（GB）保留的小数位数，图表和统计始终使用完整精度":                                           "decimal places of values (GB) in CSV and JSON output; charts and statistics always use full precision",
+	"额外生成带时间戳的OpenMetrics文本 <前缀>_openmetrics.txt，可用于向时序数据库回填历史数据":                      "also write timestamped OpenMetrics text <prefix>_openmetrics.txt for backfilling a time series database",
+	"目录模式下不逐个提示没有有效数据的文件（仍会计数并在最后汇总）":                                                  "in directory mode, do not report each file without valid data (they are still counted and summarized at the end)",
+	"输出更详细的过程信息，包括 --skip-empty 隐藏的逐文件提示":                                              "print more detailed progress, including the per-file messages hidden by --skip-empty",
+	"输入为 journalctl -u atop 的输出（short、short-iso、cat 或 export 格式），解析前去掉每行的journald前缀":   "input is journalctl -u atop output (short, short-iso, cat or export format); strip the journald prefix of each line before parsing",
+	"解析结果缓存文件（如 atop.gob），按源文件修改时间和大小判断，只重新解析有变化的文件":                                   "cache file for parsed records (e.g. atop.gob); only files whose modification time or size changed are parsed again",
+	"图表中不超过该长度的数据缺口线性插值填补，更长的缺口断开折线并列出，例如 2m；0表示不处理":                                   "linearly interpolate chart gaps up to this length and break the line at longer gaps, which are listed, e.g. 2m; 0 disables it",
+	"校验失败: %v\n":                                                 "validation failed: %v\n",
+	"校验通过: %s 共 %d 条记录\n":                                        "validation passed: %s has %d records\n",
+	"错误: 必须指定 --log_file (-f) 或 --dir (-d) 参数":                   "error: --log_file (-f) or --dir (-d) is required",
+	"必须指定 --log_file (-f) 或 --dir (-d) 参数":                       "--log_file (-f) or --dir (-d) is required",
+	"错误: --log_file 和 --dir 参数不能同时使用":                            "error: --log_file and --dir cannot be used together",
+	"--log_file 和 --dir 参数不能同时使用":                                "--log_file and --dir cannot be used together",
+	"错误: --per-file-reports 只能用于目录模式 (-d)":                       "error: --per-file-reports can only be used in directory mode (-d)",
+	"--per-file-reports 只能用于目录模式 (-d)":                           "--per-file-reports can only be used in directory mode (-d)",
+	"错误: --per-file-reports 不能与 --aggregate 同时使用，聚合后的记录不再区分来源文件": "error: --per-file-reports cannot be used with --aggregate, aggregated records no longer carry their source file",
+	"--per-file-reports 不能与 --aggregate 同时使用":                    "--per-file-reports cannot be used with --aggregate",
+	"错误: 不支持的排序方式 %s，可选 asc 或 desc\n":                            "error: unsupported order %s, choose asc or desc\n",
+	"不支持的排序方式 %s":                                                "unsupported order %s",
+	"错误: 不支持的MEM行处理方式 %s，可选 first 或 sum\n":                       "error: unsupported MEM line handling %s, choose first or sum\n",
+	"不支持的MEM行处理方式 %s":                                            "unsupported MEM line handling %s",
+	"错误: 不支持的聚合方式 %s，可选 mean\n":                                  "error: unsupported aggregation %s, choose mean\n",
+	"不支持的聚合方式 %s":                                                "unsupported aggregation %s",
+	"错误: 不支持的SWP行处理方式 %s，可选 sum 或 first\n":                       "error: unsupported SWP line handling %s, choose sum or first\n",
+	"不支持的SWP行处理方式 %s":                                            "unsupported SWP line handling %s",
+	"错误: 不支持的越界摘要格式 %s，可选 csv 或 json\n":                          "error: unsupported breach summary format %s, choose csv or json\n",
+	"不支持的越界摘要格式 %s":                                              "unsupported breach summary format %s",
+	"错误: --precision 不能为负数":                                      "error: --precision cannot be negative",
+	"--precision 不能为负数":                                          "--precision cannot be negative",
+	"错误: --trim-warmup 不能为负数":                                    "error: --trim-warmup cannot be negative",
+	"--trim-warmup 不能为负数":                                        "--trim-warmup cannot be negative",
+	"错误: 不支持的直方图指标 %s，可选 free 或 used\n":                          "error: unsupported histogram metric %s, choose free or used\n",
+	"不支持的直方图指标 %s":                                               "unsupported histogram metric %s",
+	"错误: --histogram-bins 必须大于0":                                 "error: --histogram-bins must be greater than 0",
+	"--histogram-bins 必须大于0":                                     "--histogram-bins must be greater than 0",
+	"错误: %v\n":                                                   "error: %v\n",
+	"错误: 输出文件 %s 与输入文件 %s 相同，请修改 --output 前缀\n":                  "error: output file %s is the same as input file %s, please change the --output prefix\n",
+	"输出文件 %s 会覆盖输入文件 %s":                                         "output file %s would overwrite input file %s",
+	"解析单个日志文件: %s\n":                                             "parsing single log file: %s\n",
+	"解析目录中的所有日志文件: %s\n":                                         "parsing all log files in directory: %s\n",
+	"警告: 无法写入缓存 %s: %v\n":                                        "warning: cannot write cache %s: %v\n",
+	"已按估计偏移校正时间戳":                                                "timestamps shifted by the estimated offsets",
+	"警告: 忽略历史数据: %v\n":                                           "warning: ignoring historical data: %v\n",
+	"从 %s 载入 %d 条历史记录，合并后共 %d 条（重复 %d 条）\n":                      "loaded %[2]d historical records from %[1]s, %[3]d records after merging (%[4]d duplicates)\n",
+	"没有找到有效的内存数据":                                                "no valid memory data found",
+	"按时段过滤后剩余 %d 条记录\n":                                          "%d records left after time window filtering\n",
+	"没有落在指定时段内的内存数据":                                             "no memory data within the given time window",
+	"HTTP服务出错: %v\n":                                             "HTTP server error: %v\n",
+	"生成报告时出错: %v\n":                                              "error generating report: %v\n",
+	"已保存越界摘要: %s（共 %d 个越界窗口）\n":                                  "saved breach summary: %s (%d breach windows)\n",
+	"%d 个越界窗口":                                                   "%d breach windows",
+	"报告生成完成！":                                                    "report generation complete!",
+	"%d 个规则越界窗口":                                                 "%d rule breach windows",
+	"警告: 无法读取缓存 %s: %v，将重新解析所有文件\n":                              "warning: cannot read cache %s: %v, all files will be parsed again\n",
+	"警告: 缓存 %s 已损坏: %v，将重新解析所有文件\n":                              "warning: cache %s is corrupt: %v, all files will be parsed again\n",
+	"解析选项与缓存 %s 不一致，缓存失效\n":                                      "parse options differ from cache %s, cache invalidated\n",
+	"缓存: %d 个文件命中，%d 个文件重新解析\n":                                  "cache: %d files hit, %d files parsed again\n",
+	"时钟偏移估计: 只有一个来源，无需比较":                                        "clock skew estimate: only one source, nothing to compare",
+	"时钟偏移估计（参考来源 %s）:\n":                                         "clock skew estimate (reference source %s):\n",
+	"  没有与参考来源有足够重叠且有波动的来源":                                      "  no source overlaps the reference with enough varying samples",
+	"  %s: %s%v (相关系数 %.2f，%d 个样本)\n":                            "  %s: %s%v (correlation %.2f, %d samples)\n",
+	"无效的时段 %q，格式应为 HH:MM-HH:MM":                                  "invalid time window %q, expected HH:MM-HH:MM",
+	"无效的时间 %q，格式应为 HH:MM":                                        "invalid time %q, expected HH:MM",
+	"无效的时段 %q，开始和结束时间不能相同":                                       "invalid time window %q, start and end cannot be equal",
+	"无效的星期范围 %q":                                                 "invalid weekday range %q",
+	"无效的星期 %q，可选 mon, tue, wed, thu, fri, sat, sun":              "invalid weekday %q, choose from mon, tue, wed, thu, fri, sat, sun",
+	"检测到 %d 处超过 %v 的数据缺失（图表中断开）\n":                               "found %d data gaps longer than %v (line broken in charts)\n",
+	"无效的查询请求: %v":                                                "invalid query request: %v",
+	"未知指标: %s":                                                   "unknown metric: %s",
+	"不支持的直方图指标: %s (可选: free, used)":                             "unsupported histogram metric: %s (choose: free, used)",
+	"%s 分布（共 %d 个样本）:\n":                                         "%s distribution (%d samples):\n",
+	"区间 (GB)":                                                    "range (GB)",
+	"样本数":                                                        "samples",
+	"解压 %s 失败: %v":                                               "failed to decompress %s: %v",
+	"读取 %s 的表头失败: %v":                                            "failed to read the header of %s: %v",
+	"%s 第 1 行: %v":                                               "%s line 1: %v",
+	"%s 第 %d 行: timestamp 列的值 %q 不是有效的时间":                        "%s line %d: timestamp value %q is not a valid time",
+	"%s 第 %d 行: %s 列的值 %q 不是有效的数值":                               "%s line %d: %s value %q is not a valid number",
+	"生成文件 %s 的单独报告（%d 条记录）\n":                                    "generating separate report for file %s (%d records)\n",
+	"生成文件 %s 的报告时出错: %v":                                         "error generating report for file %s: %v",
+	"没有可绘制的PSI数据":                                                "no PSI data to plot",
+	"检测到 %d 次疑似重启\n":                                             "detected %d likely reboots\n",
+	"获取 %s 失败: HTTP %s":                                          "failed to fetch %s: HTTP %s",
+	"解压 %s 的响应失败: %v":                                            "failed to decompress the response of %s: %v",
+	"解析规则文件 %s 失败: %v":                                           "failed to parse rules file %s: %v",
+	"规则文件 %s 中没有规则":                                              "rules file %s contains no rules",
+	"规则文件 %s 第 %d 条规则缺少 name":                                    "rules file %s: rule %d has no name",
+	"规则文件 %s 中规则名 %s 重复":                                         "rules file %s: duplicate rule name %s",
+	"规则 %s 的指标 %q 不受支持，可选 mem_tot、mem_free、mem_used、mem_cache、mem_buff、swp_tot、swp_free、swp_used": "rule %s: unsupported metric %q, choose mem_tot, mem_free, mem_used, mem_cache, mem_buff, swp_tot, swp_free or swp_used",
+	"规则 %s 的比较符 %q 不受支持，可选 <、<=、>、>=":                                                             "rule %s: unsupported comparator %q, choose <, <=, > or >=",
+	"规则 %s 的级别 %q 不受支持，可选 warning 或 critical":                                                     "rule %s: unsupported severity %q, choose warning or critical",
+	"规则检查: %d 条规则，%d 个越界窗口\n":                                                                     "rule check: %d rules, %d breach windows\n",
+	"直到数据结束仍未恢复":                                                                                  "not recovered by the end of the data",
+	"恢复于 ":                                                                                        "recovered at ",
+	"  [%s] %s: %s - %s，最严重 %.2fG，%s\n":                                                           "  [%s] %s: %s - %s, worst %.2fG, %s\n",
+	"空闲内存最低的 %d 个样本所在的文件:\n":                                                                      "files holding the %d samples with the lowest free memory:\n",
+	"(来源未知)": "(unknown source)",
+	"  %s: %d 个样本，最低 %.2fG (%s)\n":      "  %s: %d samples, lowest %.2fG (%s)\n",
+	"空闲内存低于 %.2fG":                      "free memory below %.2fG",
+	"交换空间使用超过 %.2fG":                    "swap usage above %.2fG",
+	"进入: %s":                            "enter: %s",
+	"恢复: %s (持续 %s，最严重 %.2fG，当前 %.2fG)": "recover: %s (lasted %s, worst %.2fG, now %.2fG)",
+	"内存状态变化（共 %d 个事件）:\n":               "memory state changes (%d events):\n",
+	"已保存Vega-Lite规范: %s\n":              "saved Vega-Lite spec: %s\n",
+	"额外生成内联数据的Vega-Lite规范 <前缀>_memory_swap.vl.json，可在Vega编辑器或其他工具中重新设置样式": "also write a Vega-Lite spec with inlined data, <prefix>_memory_swap.vl.json, for restyling in the Vega editor or other tools",
+	"已保存交互式HTML报告: %s 等 %d 页\n": "saved interactive HTML report: %s and more, %d pages\n",
+	"« 上一页":              "« previous",
+	"下一页 »":              "next »",
+	"第 %d/%d 页: %s - %s": "page %d/%d: %s - %s",
+	"HTML报告每页的样本数，超过时拆分为多个带上一页/下一页导航的页面；0表示不分页": "samples per HTML report page; longer reports are split into pages with previous/next navigation, 0 disables paging",
+	"错误: --html-paginate 不能为负数":                     "error: --html-paginate cannot be negative",
+	"--html-paginate 不能为负数":                         "--html-paginate cannot be negative",
+	"错误: 无法计算输入文件校验和: %v\n":                         "Error: cannot compute input checksums: %v\n",
+	"输入 %s: sha256=%s，%d 条记录\n":                     "Input %s: sha256=%s, %d records\n",
+	"输入 %s: 远程地址不计算校验和，%d 条记录\n":                    "Input %s: no checksum for remote source, %d records\n",
+	"错误: 无法写入 %s: %v\n":                             "Error: cannot write %s: %v\n",
+	"已保存输入校验和: %s\n":                                "Saved input checksums: %s\n",
+	"计算各输入文件的SHA-256并写入<前缀>_inputs.json，同时记录在来源说明中": "Compute the SHA-256 of each input file, write it to <prefix>_inputs.json and record it in the provenance",
+	"已保存派生指标图表: %s\n":                               "Saved derived metrics chart: %s\n",
+	"错误: --derive %v\n":                             "Error: --derive %v\n",
+	"%q 应为 name=expression 形式":                      "%q must have the form name=expression",
+	"派生指标名 %q 只能包含字母、数字和下划线，且不能以数字开头":               "derived metric name %q may only contain letters, digits and underscores and must not start with a digit",
+	"派生指标名 %q 与已有的CSV列重复":                           "derived metric name %q clashes with an existing CSV column",
+	"表达式第 %d 个字符 %q 无法识别":                           "unrecognised character %[2]q at position %[1]d of the expression",
+	"表达式不完整":                                        "incomplete expression",
+	"表达式缺少右括号":                                      "missing closing parenthesis in expression",
+	"无效的数字 %q":                                      "invalid number %q",
+	"未知的变量 %q，可用变量: %s":                             "unknown variable %q, available variables: %s",
+	"添加派生指标 name=expression，可重复指定；表达式可使用 mem_tot、mem_free、mem_used、mem_cache、mem_buff、swp_tot、swp_free、swp_used 和 + - * / 括号": "Add a derived metric name=expression, may be repeated; expressions may use mem_tot, mem_free, mem_used, mem_cache, mem_buff, swp_tot, swp_free, swp_used and + - * / parentheses",
+	"没有可折叠的数据":                            "no data to fold",
+	"已保存周期叠加图表: %s\n":                     "Saved period overlay chart: %s\n",
+	"错误: 不支持的折叠周期 %s，可选 daily 或 weekly\n": "Error: unsupported fold period %s, choose daily or weekly\n",
+	"不支持的折叠周期 %s":                         "unsupported fold period %s",
+	"将每天(daily)或每周(weekly)的已用内存曲线叠加在同一坐标轴上，生成<前缀>_fold_<周期>.png": "Overlay the memory-used curve of each day (daily) or week (weekly) on a common axis in <prefix>_fold_<period>.png",
+	"已将CSV写入标准输出": "Wrote CSV to standard output",
+	"将CSV写到标准输出，默认不生成PNG，诊断信息写到标准错误；为 --output - 的等价写法":           "Write the CSV to standard output, skip the PNG and send diagnostics to standard error; equivalent to --output -",
+	"错误: --output - 只能输出CSV，需要其他文件时请使用 --stdout 并用 --output 指定前缀": "Error: --output - can only produce the CSV; use --stdout with an --output prefix for other files",
+	"--output - 不能与生成其他文件的参数同时使用":                                 "--output - cannot be combined with options that write other files",
+	"没有可绘制的CPU数据":                                         "no CPU data to plot",
+	"已保存CPU使用率图表: %s\n":                                   "Saved CPU utilization chart: %s\n",
+	"无效的 --%s %q，格式应为 %s":                                 "invalid --%s %q, expected format %s",
+	"只保留不早于该时间的样本，格式 2006-01-02 15:04:05（包含）":             "Keep only samples at or after this time, format 2006-01-02 15:04:05 (inclusive)",
+	"只保留不晚于该时间的样本，格式 2006-01-02 15:04:05（包含）":             "Keep only samples at or before this time, format 2006-01-02 15:04:05 (inclusive)",
+	"错误: --end %s 早于 --start %s\n":                        "Error: --end %s is before --start %s\n",
+	"--end 早于 --start":                                    "--end is before --start",
+	"按时间范围过滤后剩余 %d 条记录\n":                                 "%d records left after time range filtering\n",
+	"没有落在指定时间范围内的内存数据":                                    "no memory data within the given time range",
+	"内存使用统计（共 %d 个样本，单位 GB）:\n":                           "Memory usage statistics (%d samples, GB):\n",
+	"已保存统计摘要: %s\n":                                       "Saved statistics summary: %s\n",
+	"错误: 不支持的输出格式 %s，可选 csv 或 json\n":                     "Error: unsupported output format %s, choose csv or json\n",
+	"不支持的输出格式 %s":                                         "unsupported output format %s",
+	"主输出格式: csv (默认) 或 json (写到<前缀>.json，时间戳为ISO-8601格式)": "Main output format: csv (default) or json (written to <prefix>.json with ISO-8601 timestamps)",
+	"已将JSON写入标准输出":                                        "Wrote JSON to standard output",
+	"已保存JSON文件: %s\n":                                     "Saved JSON file: %s\n",
+	"目录模式下递归读取子目录中的日志文件（会进入指向目录的符号链接，同一目录只读取一次）": "In directory mode, also read log files in subdirectories (symlinks to directories are followed, each directory is read only once)",
+	"错误: --recursive 只能用于目录模式 (-d)":           "Error: --recursive can only be used in directory mode (-d)",
+	"--recursive 只能用于目录模式 (-d)":               "--recursive can only be used in directory mode (-d)",
+	"警告: 无法读取 %s: %v\n":                       "Warning: cannot read %s: %v\n",
+	"警告: %s 中出现未知的容量单位 %q，已忽略使用该单位的值\n":       "Warning: unknown size unit %[2]q in %[1]s, values using it are ignored\n",
+	"目录模式下同时解析的文件数，默认为CPU核心数":                 "Number of files parsed concurrently in directory mode, defaults to the number of CPU cores",
+	"错误: --workers 必须大于0":                     "Error: --workers must be greater than 0",
+	"--workers 必须大于0":                         "--workers must be greater than 0",
+	"错误: --checksum 不能用于标准输入 (-f -)":          "Error: --checksum cannot be used with standard input (-f -)",
+	"--checksum 不能用于标准输入 (-f -)":              "--checksum cannot be used with standard input (-f -)",
+	"在HTML报告中附加内存/交换空间使用率(%)图表":               "Add a memory/swap usage (%) chart to the HTML report",
+	"已保存使用率图表: %s\n":                          "Saved usage percentage chart: %s\n",
+	"空闲内存低于该值(GB)的样本视为越界，报告生成后以退出码2退出；0表示不检查": "Samples with free memory below this value (GB) count as breaches; exit with code 2 after the report is generated; 0 disables the check",
+	"空闲交换空间低于该值(GB)的样本视为越界（跳过未启用交换空间的样本），报告生成后以退出码2退出；0表示不检查": "Samples with free swap below this value (GB) count as breaches (samples without swap are skipped); exit with code 2 after the report is generated; 0 disables the check",
+	"错误: --mem-free-threshold 和 --swap-free-threshold 不能为负数":  "Error: --mem-free-threshold and --swap-free-threshold must not be negative",
+	"--mem-free-threshold 和 --swap-free-threshold 不能为负数":      "--mem-free-threshold and --swap-free-threshold must not be negative",
+	"空闲交换空间低于 %.2fG":                     "free swap below %.2fG",
+	"阈值检查: %s: 没有越界样本\n":                 "Threshold check: %s: no breaching samples\n",
+	"阈值检查: %s: %d 个越界样本，最低 %.2fG (%s)\n": "Threshold check: %s: %d breaching samples, lowest %.2fG (%s)\n",
+	"%d 个样本低于阈值":                         "%d samples below threshold",
+	"对已用内存做线性回归，输出斜率(GB/小时)和R²，用于发现缓慢的内存泄漏":                                        "Fit a linear regression to used memory and print the slope (GB/hour) and R², to spot slow memory leaks",
+	"--trend 中斜率超过该值(GB/小时)且R²不低于--trend-r2时提示可能存在内存泄漏":                            "With --trend, warn about a possible memory leak when the slope exceeds this value (GB/hour) and R² is at least --trend-r2",
+	"--trend 中判定内存泄漏所需的最小R² (0-1)":                                                 "Minimum R² (0-1) for --trend to report a possible memory leak",
+	"错误: --trend-r2 必须在 0 到 1 之间":                                                  "Error: --trend-r2 must be between 0 and 1",
+	"--trend-r2 必须在 0 到 1 之间":                                                      "--trend-r2 must be between 0 and 1",
+	"趋势分析: 需要至少2个不同时间的样本，当前 %d 个，跳过\n":                                             "Trend: at least 2 samples at different times are required, got %d, skipping\n",
+	"趋势分析: 已用内存斜率 %+.4f GB/小时，R² = %.3f（%d 个样本）\n":                                 "Trend: used memory slope %+.4f GB/hour, R² = %.3f (%d samples)\n",
+	"警告: 可能存在内存泄漏（斜率超过 %.4f GB/小时且 R² 不低于 %.2f）\n":                                 "Warning: possible memory leak detected (slope above %.4f GB/hour and R² at least %.2f)\n",
+	"HTML报告内联内嵌的Chart.js，不依赖CDN，适合无法访问互联网的环境（文件更大）":                                "Inline the embedded Chart.js into the HTML report instead of loading it from the CDN, for offline environments (larger files)",
+	"错误: 此版本构建时未内嵌 Chart.js，无法使用 --html-offline（构建前运行 go generate ./atopparse 下载）": "Error: this build does not embed Chart.js, so --html-offline is unavailable (run go generate ./atopparse before building to download it)",
+	"未内嵌 Chart.js": "Chart.js is not embedded",
+	"目录模式下合并同一主机时间戳相同的记录（日志轮转重叠），只保留文件顺序中的第一条，并报告去除的条数": "In directory mode, collapse records of the same host sharing a timestamp (overlapping log rotation), keeping the first in file order, and report how many were removed",
+	"去除了 %d 条时间戳重复的记录\n":           "removed %d records with duplicate timestamps\n",
+	"--dedup 不能与 --aggregate 同时使用": "--dedup cannot be combined with --aggregate",
+	"没有可绘制的磁盘数据":                   "no disk data to plot",
+	"已保存磁盘统计CSV文件: %s\n":           "saved disk statistics CSV: %s\n",
+	"已保存磁盘忙碌图表: %s\n":              "saved disk busy chart: %s\n",
+	"对PNG和HTML内存图表的曲线做N点居中移动平均，CSV仍为原始数据；0或1表示不平滑": "Apply an N-point centered moving average to the PNG and HTML memory chart lines, the CSV keeps raw data; 0 or 1 disables smoothing",
+	"错误: --smooth 不能为负数": "error: --smooth must not be negative",
+	"--smooth 不能为负数":     "--smooth must not be negative",
+	"警告: %s 中有 %d 行无法解析的时间戳，对应的采样块已丢弃\n":                        "warning: %s: unparsed timestamp lines: %d, their sample blocks were dropped\n",
+	"  无法解析的时间戳行: %d（对应的采样块已丢弃，可用 --date-layout 指定格式）\n":        "  unparsed timestamp lines: %d (their sample blocks were dropped, use --date-layout to specify the format)\n",
+	"除合并报告外，再为日志头中的每个主机单独生成一份报告，前缀为 <前缀>_<主机名>；日志头没有主机名时按文件名区分": "In addition to the merged report, generate a separate report for each host in the log headers, prefixed <prefix>_<host>; records without a hostname are grouped by file name",
+	"生成主机 %s 的单独报告（%d 条记录）\n":                                   "generating a separate report for host %s (%d records)\n",
+	"生成主机 %s 的报告时出错: %v":                                        "error generating report for host %s: %v",
+	"流式模式（仅目录模式）：假定按文件名顺序各文件的记录已按时间排列，逐个文件解析并立即写入CSV，内存中只保留一个文件的记录，适合一个月以上的日志。代价是不做全局排序（乱序时只警告，CSV中的顺序即文件顺序），且只生成CSV，不能与图表、统计、过滤等需要全部记录的参数同时使用": "Streaming mode (directory mode only): assume each file's records are already in time order when taken in file name order, parse one file at a time and write it to the CSV immediately, keeping only one file's records in memory, suitable for month-long captures. The trade-off: no global sort (out-of-order records only produce a warning, the CSV keeps file order), and only the CSV is produced; cannot be combined with options such as charts, statistics or filters that need all records",
+	"错误: --assume-sorted 只能用于目录模式 (-d)":         "error: --assume-sorted can only be used in directory mode (-d)",
+	"--assume-sorted 只能用于目录模式 (-d)":             "--assume-sorted can only be used in directory mode (-d)",
+	"错误: --assume-sorted 流式模式只生成CSV，不支持 --%s\n": "error: --assume-sorted streaming mode only writes the CSV and does not support --%s\n",
+	"--assume-sorted 不支持 --%s":                  "--assume-sorted does not support --%s",
+	"流式解析目录中的所有日志文件: %s\n":                      "streaming all log files in directory: %s\n",
+	"警告: 指定了 --assume-sorted 但有 %d 条记录早于之前写出的记录，CSV 并非按时间排列；需要排序时请去掉 --assume-sorted\n": "warning: --assume-sorted was given but %d records are earlier than records already written, the CSV is not in time order; drop --assume-sorted if sorting is needed\n",
+	"总共从 %d 个文件中流式写出 %d 条记录\n": "streamed %[2]d records from %[1]d files in total\n",
+	"PNG和HTML图表每个图最多绘制的点数，超过时分桶平均降采样（保留首尾样本），CSV仍为完整数据；0表示不降采样": "Maximum number of points drawn per PNG and HTML chart; larger datasets are downsampled by bucket averaging (first and last samples kept), the CSV keeps full resolution; 0 disables downsampling",
+	"错误: --max-points 必须为0或不小于2":            "error: --max-points must be 0 or at least 2",
+	"--max-points 必须为0或不小于2":                "--max-points must be 0 or at least 2",
+	"没有可绘制的换页数据":                            "no paging data to plot",
+	"已保存换入/换出速率图表: %s\n":                    "saved swap in/out rate chart: %s\n",
+	"所有输出文件写到该目录（不存在时创建），--output 只作为文件名前缀": "Write all output files into this directory (created if missing); --output is then only the file name prefix",
+	"错误: --outdir 不能与 --output - 同时使用":      "error: --outdir cannot be combined with --output -",
+	"--outdir 不能与 --output - 同时使用":          "--outdir cannot be combined with --output -",
+	"错误: 无法创建输出目录 %s: %v\n":                 "error: cannot create output directory %s: %v\n",
+	"无法创建输出目录 %s: %v":                       "cannot create output directory %s: %v",
+	"在生成报告之外，将记录写入该SQLite数据库的 mem_records 表，按 (timestamp, hostname) 更新已有的行，便于跨多次运行用SQL查询": "In addition to the report, write the records into the mem_records table of this SQLite database, upserting on (timestamp, hostname), for SQL queries across runs",
+	"无法创建 SQLite 表: %v":                "cannot create SQLite table: %v",
+	"已将 %d 条记录写入 SQLite 数据库: %s\n":     "wrote %d records to SQLite database: %s\n",
+	"数值格式错误: %q":                       "malformed numeric field: %q",
+	"%s 第 %d 行: %v":                    "%s line %d: %v",
+	"  数值格式错误的行: %d（对应的采样块已丢弃）\n":      "  Lines with malformed numbers: %d (their sample blocks were dropped)\n",
+	"警告: %s 中有 %d 行数值格式错误，对应的采样块已丢弃\n": "Warning: %s has %d lines with malformed numbers; their sample blocks were dropped\n",
+	"遇到数值格式错误的字段时报告文件名和行号并退出，而不是丢弃对应的采样块": "Report the file and line of a malformed numeric field and exit, instead of dropping its sample block",
+	"生成便于粘贴到工单中的Markdown报告 <前缀>.md":       "Write a Markdown report <prefix>.md for pasting into tickets",
+	"已保存Markdown报告: %s\n": "Saved Markdown report: %s\n",
+	"内存使用报告":              "Memory usage report",
+	"采集时间范围":              "Capture time range",
+	"统计（单位 GB）":           "Statistics (GB)",
+	"空闲内存最低的 %d 个时刻":      "%d lowest free-memory moments",
+	"内存使用图表":              "Memory usage chart",
+	"记录数":                 "Records",
+	"YAML或JSON配置文件，键为参数的长名称，作为命令行没有指定的参数的默认值": "YAML or JSON config file keyed by long flag names; its values are defaults for flags not given on the command line",
+	"无法读取配置文件: %v":            "cannot read config file: %v",
+	"解析配置文件 %s 失败: %v":        "failed to parse config file %s: %v",
+	"配置文件 %s 中的参数 %s 不存在":     "config file %s: unknown flag %s",
+	"配置文件 %s 中参数 %s 的值无效: %v": "config file %s: invalid value for %s: %v",
+	"配置文件 %s 中的参数 %s 不能指定多个值": "config file %s: flag %s does not accept a list",
+	"值为空":       "value is empty",
+	"列表中不能嵌套列表": "nested lists are not allowed",
+	"不支持的值 %s":  "unsupported value %s",
+	"HTML报告中以醒目的颜色标出已用内存高于该百分位数(0-100)的数据点；0表示不标出":                   "Highlight HTML report points whose used memory is above this percentile (0-100); 0 disables",
+	"错误: --html-anomaly-percentile 必须在 0 到 100 之间":                   "Error: --html-anomaly-percentile must be between 0 and 100",
+	"--html-anomaly-percentile 必须在 0 到 100 之间":                       "--html-anomaly-percentile must be between 0 and 100",
+	"已用内存高于第 %[1]g 百分位数（%[2]s GB）的 %[3]d 个数据点在 MEM Free 曲线上以洋红色大点标出": "%[3]d points with used memory above the %[1]gth percentile (%[2]s GB) are shown as large magenta dots on the MEM Free line",
+	"  采样间隔: %s\n": "  Sampling intervals: %s\n",
+	"警告: %[1]s 在 %[2]s 到 %[3]s 之间缺少数据（间隔 %[4]v，预期采样间隔 %[5]v）\n": "Warning: %[1]s has no data between %[2]s and %[3]s (gap %[4]v, expected interval %[5]v)\n",
+	"警告: %s 另有 %d 段缺少数据未列出\n":                                   "Warning: %s has %d more gaps not listed\n",
+	"不生成报告文件，而是在指定地址启动HTTP服务，例如 :8080：/report?dir=... 按请求解析目录并返回HTML报告，/data.json 返回JSON记录，同时提供Grafana SimpleJSON数据源接口": "Start an HTTP server at the given address (e.g. :8080) instead of writing reports: /report?dir=... parses a directory on demand and returns the HTML report, /data.json returns the records as JSON, and the Grafana SimpleJSON endpoints are served too",
+	"HTTP服务已启动: http://%s（/report、/data.json 以及 Grafana SimpleJSON 数据源接口）\n":                                            "HTTP server started: http://%s (/report, /data.json and Grafana SimpleJSON endpoints)\n",
+	"缺少 dir 参数": "missing dir parameter",
+	"目录 %s 中没有找到有效的内存数据":                                      "no valid memory data found in directory %s",
+	"CSV输出的列及其顺序，逗号分隔，例如 timestamp,mem_free,swp_free；默认输出全部列": "CSV columns to write, in order, comma-separated, e.g. timestamp,mem_free,swp_free; all columns by default",
+	"CSV的分隔符，例如 ; ，\\t 表示制表符":                                 "CSV delimiter, e.g. ;  (\\t for tab)",
+	"错误: --columns 只能用于CSV输出，不能与 --format json 同时使用":          "Error: --columns only applies to CSV output and cannot be used with --format json",
+	"--columns 不能与 --format json 同时使用":                        "--columns cannot be used with --format json",
+	"错误: --columns %v\n":   "Error: --columns %v\n",
+	"错误: --delimiter %v\n": "Error: --delimiter %v\n",
+	"未知的CSV列 %q，可选: %s":    "unknown CSV column %q, valid columns: %s",
+	"CSV列 %q 重复":           "CSV column %q is listed more than once",
+	"分隔符必须是单个字符（不能是双引号或换行），制表符写作 \\t":                  "the delimiter must be a single character (not a double quote or newline); write \\t for tab",
+	"错误: --compare 不能与 --serve 或 --breaches-only 同时使用": "Error: --compare cannot be used with --serve or --breaches-only",
+	"--compare 不能与 --serve 或 --breaches-only 同时使用":     "--compare cannot be used with --serve or --breaches-only",
+	"解析对比数据: %s\n":           "Parsing comparison data: %s\n",
+	"对比数据 %s 中没有找到有效的内存数据\n": "No valid memory data found in comparison data %s\n",
+	"对比数据 %s 中没有找到有效的内存数据":   "No valid memory data found in comparison data %s",
+	"与另一段采集数据（日志文件或目录）对比：生成两段已用内存叠加的图表 <前缀>_compare.png（各自从0开始计时）和平均值/峰值对比表 <前缀>_compare.txt": "Compare with another capture period (log file or directory): write an overlaid used-memory chart <prefix>_compare.png (each period starts at 0) and a mean/peak comparison table <prefix>_compare.txt",
+	"对比的两个时段都需要有数据":      "both periods to compare need data",
+	"已保存时段对比图表: %s\n":    "Saved period comparison chart: %s\n",
+	"已保存时段对比表: %s\n":     "Saved period comparison table: %s\n",
+	"错误: --palette %v\n": "Error: --palette %v\n",
+	"错误: --%s %v\n":      "Error: --%s %v\n",
+	"内存/交换空间图表的预设配色: default 或 colorblind (色盲友好的Okabe-Ito配色)":                      "Preset colors for the memory/swap charts: default or colorblind (colorblind-friendly Okabe-Ito palette)",
+	"MEM Total 曲线的颜色，格式为 #RRGGBB，优先于 --palette":                                    "Color of the MEM Total line as #RRGGBB; overrides --palette",
+	"MEM Free 曲线的颜色，格式为 #RRGGBB，优先于 --palette":                                     "Color of the MEM Free line as #RRGGBB; overrides --palette",
+	"MEM Cache 曲线的颜色，格式为 #RRGGBB，优先于 --palette":                                    "Color of the MEM Cache line as #RRGGBB; overrides --palette",
+	"MEM Buffers 曲线的颜色，格式为 #RRGGBB，优先于 --palette":                                  "Color of the MEM Buffers line as #RRGGBB; overrides --palette",
+	"SWAP Total 曲线的颜色，格式为 #RRGGBB，优先于 --palette":                                   "Color of the SWAP Total line as #RRGGBB; overrides --palette",
+	"SWAP Free 曲线的颜色，格式为 #RRGGBB，优先于 --palette":                                    "Color of the SWAP Free line as #RRGGBB; overrides --palette",
+	"未知的配色 %s，可选: %s":                                                              "unknown palette %s, choose one of: %s",
+	"无效的颜色 %q，应为 #RRGGBB 或 #RGB 格式":                                                "invalid color %q, expected #RRGGBB or #RGB",
+	"错误: --chart-format %v\n":                                                      "Error: --chart-format %v\n",
+	"错误: --chart-format svg 不能与 --svg-interactive 同时使用，两者都写入 <前缀>_memory_swap.svg": "Error: --chart-format svg cannot be used with --svg-interactive; both write <prefix>_memory_swap.svg",
+	"--chart-format svg 不能与 --svg-interactive 同时使用":                                "--chart-format svg cannot be used with --svg-interactive",
+	"内存使用图表 <前缀>_memory_swap.<格式> 的格式: png、svg 或 pdf，多种格式用逗号分隔，例如 png,pdf":         "Format of the memory chart <prefix>_memory_swap.<format>: png, svg or pdf; separate multiple formats with commas, e.g. png,pdf",
+	"不支持的图表格式 %q，可选: %s":                                                           "unsupported chart format %q, choose from: %s",
+	"错误: --prometheus-all 需要同时指定 --prometheus":                                     "Error: --prometheus-all requires --prometheus",
+	"--prometheus-all 需要同时指定 --prometheus":                                         "--prometheus-all requires --prometheus",
+	"已保存Prometheus指标: %s\n":                                                        "Saved Prometheus metrics: %s\n",
+	"在生成报告之外，将每个主机最新的记录以Prometheus文本格式写入该文件（如node_exporter textfile collector目录中的 atop.prom），带host标签": "In addition to the report, write each host's latest record to this file in Prometheus text format (e.g. atop.prom in the node_exporter textfile collector directory), with a host label",
+	"--prometheus 写出全部记录并带时间戳，而不是每个主机最新的一条":                                                           "Make --prometheus write all records with timestamps instead of each host's latest one",
+	"错误: --max-gap 必须为0或大于1": "Error: --max-gap must be 0 or greater than 1",
+	"--max-gap 必须为0或大于1":     "--max-gap must be 0 or greater than 1",
+	"图表中相邻样本相隔超过采样间隔的该倍数时断开折线并列出缺口，例如 3；0表示不处理，优先于 --interpolate-gaps-upto 的断开长度": "Break chart lines and list the gap where consecutive samples are more than this multiple of the sampling interval apart, e.g. 3; 0 disables; takes precedence over the break length of --interpolate-gaps-upto",
+	"错误: --timeout 和 --partial 只能用于目录模式 (-d)": "Error: --timeout and --partial can only be used in directory mode (-d)",
+	"--timeout 和 --partial 只能用于目录模式 (-d)":     "--timeout and --partial can only be used in directory mode (-d)",
+	"错误: --timeout 不能为负数":                     "Error: --timeout cannot be negative",
+	"--timeout 不能为负数":                         "--timeout cannot be negative",
+	"错误: 解析未完成（%v），已解析 %d 条记录；指定 --partial 可用这些记录生成报告\n": "Error: parsing did not finish (%v); %d records were parsed; use --partial to generate the report from them\n",
+	"警告: 解析未完成（%v），使用已解析的 %d 条记录生成报告\n":                  "Warning: parsing did not finish (%v); generating the report from the %d records parsed\n",
+	"目录模式下解析的时间上限，例如 5m，超过后中止解析；0表示不限制。Ctrl-C 同样会中止解析":   "Time limit for parsing in directory mode, e.g. 5m; parsing is aborted when it passes; 0 means no limit. Ctrl-C also aborts parsing",
+	"解析超过 --timeout 或被 Ctrl-C 中断时，用已解析的记录继续生成报告，而不是退出":   "When parsing exceeds --timeout or is interrupted by Ctrl-C, generate the report from the records parsed so far instead of exiting",
+	"处理完 %d/%d 个文件时中止: %w": "aborted after %d/%d files: %w",
+	"没有可绘制的网络数据":           "no network data to plot",
+	"已保存网络统计CSV文件: %s\n":   "Saved network statistics CSV: %s\n",
+	"已保存网络速率图表: %s\n":      "Saved network throughput chart: %s\n",
+	"列 %s 重复":              "duplicate column %s",
+	"缺少列 [%s]，至少需要 %s":     "missing columns [%s], at least %s are required",
+	"--serve 的 dir 参数所在的根目录，dir 为相对于该目录的路径；不指定时不接受 dir 参数": "root directory for the dir parameter of --serve; dir is a path relative to it. Without it the dir parameter is rejected",
+	"错误: --serve-root 需要同时指定 --serve":                      "Error: --serve-root requires --serve",
+	"--serve-root 需要同时指定 --serve":                          "--serve-root requires --serve",
+	"%s 不是目录":                                 "%s is not a directory",
+	"错误: 无效的 --serve-root: %v\n":              "Error: invalid --serve-root: %v\n",
+	"无效的 --serve-root: %v":                    "invalid --serve-root: %v",
+	"没有指定 --serve-root，不接受 dir 参数":            "the dir parameter is not accepted without --serve-root",
+	"dir 必须是 --serve-root 下的相对路径且不能包含 ..: %s": "dir must be a relative path under --serve-root without ..: %s",
+	"目录 %s 不存在":                               "directory %s does not exist",
+	"按主机和时间戳聚合来自多个日志的记录，目前支持 mean (取平均值)":                    "aggregate records from several logs by host and timestamp; currently supports mean",
+	"按主机和时间戳取平均: %d 条记录合并为 %d 条\n":                           "Averaged by host and timestamp: %d records merged into %d\n",
+	"错误: --dedup 不能与 --aggregate 同时使用，聚合依赖同一主机不同日志中时间戳相同的记录": "error: --dedup cannot be combined with --aggregate, which relies on records of the same host from different logs sharing timestamps",
+	"未知（日志中没有版本行）":                                           "unknown (no version line in the log)",
+	"  atop版本: %s\n":                                         "  atop version: %s\n",
+	"没有可绘制的磁盘吞吐量数据":                                          "no disk throughput data to plot",
+	"已保存磁盘吞吐量图表: %s\n":                                       "Saved disk throughput chart: %s\n",
+	"PNG和HTML内存图表中不绘制估算可用内存（free + cache + buff + slrec）曲线":  "do not draw the estimated available memory (free + cache + buff + slrec) line in the PNG and HTML memory charts",
+	"注意: %s 的采样间隔在 %s 由 %v 变为 %v\n":                          "Note: sampling interval of %s changed at %s from %v to %v\n",
+	"未知的对比指标 %q，可选: %s":                                      "unknown compare metric %q, choose from: %s",
+	"时段对比（A: %s，B: %s，对比指标 %s，单位 %s；已用内存/交换空间单位 GB）:\n":      "Period comparison (A: %s, B: %s, metric %s in %s; used memory/swap in GB):\n",
+	"--compare 叠加和对比的指标: used（已用内存，默认）、free（空闲内存）、swap_used（已用交换空间）或 used_pct（内存使用率）": "metric overlaid and compared by --compare: used (used memory, default), free (free memory), swap_used (used swap) or used_pct (memory usage percent)",
+	"错误: --compare-metric 需要同时指定 --compare": "Error: --compare-metric requires --compare",
+	"--compare-metric 需要同时指定 --compare":     "--compare-metric requires --compare",
+	"错误: --compare-metric %v\n":             "Error: --compare-metric %v\n",
+	"换页抖动最严重的采样间隔（分数 = min(swin, swout) + %s × |swin − swout|，单位 页/秒）:\n": "Worst swap thrash intervals (score = min(swin, swout) + %s × |swin − swout|, pages/s):\n",
+	"已保存换页抖动分数图表: %s\n": "Saved swap thrash score chart: %s\n",
+	"换页抖动分数 min(swin, swout) + W × |swin − swout| 中单向换页的权重W（0到1），0只计同时换入和换出的部分，1为两者中较大的一个": "weight W of one-directional paging in the swap thrash score min(swin, swout) + W × |swin − swout| (0 to 1); 0 counts only simultaneous swap-in and swap-out, 1 is the larger of the two",
+	"错误: --thrash-weight 必须在 0 到 1 之间": "Error: --thrash-weight must be between 0 and 1",
+	"--thrash-weight 必须在 0 到 1 之间":     "--thrash-weight must be between 0 and 1",
+	"目录模式下同时解析的文件数的上限，优先于 --workers，默认为CPU核心数；1表示逐个文件串行解析": "upper bound on files parsed at the same time in directory mode, takes precedence over --workers, defaults to the number of CPU cores; 1 parses files one at a time",
+	"错误: --max-concurrency 必须大于0":  "Error: --max-concurrency must be greater than 0",
+	"--max-concurrency 必须大于0":      "--max-concurrency must be greater than 0",
+	"错误: --retain 需要同时指定 --append": "Error: --retain requires --append",
+	"--retain 需要同时指定 --append":     "--retain requires --append",
+	"错误: --retain %v\n":            "Error: --retain %v\n",
+	"错误: --append 不能与 --stdout、--output -、--format json、--columns、--delimiter、--serve 或 --breaches-only 同时使用": "Error: --append cannot be combined with --stdout, --output -, --format json, --columns, --delimiter, --serve or --breaches-only",
+	"--append 不能与 --stdout、--output -、--format json、--columns、--delimiter、--serve 或 --breaches-only 同时使用":     "--append cannot be combined with --stdout, --output -, --format json, --columns, --delimiter, --serve or --breaches-only",
+	"错误: 无法读取要追加的CSV: %v\n":                 "Error: cannot read the CSV to append to: %v\n",
+	"追加到 %s：已有 %d 条记录，合并后共 %d 条（重复 %d 条）\n": "Appending to %s: %d existing records, %d after merge (%d duplicates)\n",
+	"按 --retain %s 丢弃了 %d 条较早的记录，保留 %d 条\n": "--retain %s dropped %d older records, kept %d\n",
+	"追加到已有的 <前缀>.csv：先读入该文件，与本次解析的记录合并（按主机和时间戳去重，以本次为准）后整体重写；重写先写临时文件再改名": "Append to the existing <prefix>.csv: read it, merge with the records parsed now (deduplicated by host and timestamp, new records win) and rewrite it via a temporary file and rename",
+	"与 --append 一起使用：只保留最新记录之前这段时间内的记录，例如 7d、36h，更早的行在重写时丢弃":              "With --append: keep only records within this duration of the newest record, e.g. 7d or 36h; older rows are dropped on rewrite",
+	"无效的时长 %q":  "invalid duration %q",
+	"保留时长必须大于0": "retention must be greater than 0",
+	"日志中有单核cpu行时生成各核心利用率的热力图 <前缀>_cpu_heatmap.png（X轴为时间，Y轴为核心）": "Render a per-core utilization heatmap <prefix>_cpu_heatmap.png when the log has per-core cpu lines (time on X, cores on Y)",
+	"已保存CPU核心热力图: %s\n":           "Saved CPU core heatmap: %s\n",
+	"警告: 日志中没有单核cpu行，不生成CPU核心热力图": "Warning: no per-core cpu lines in the log, skipping the CPU core heatmap",
+	"没有可绘制的单核CPU数据":               "no per-core CPU data to plot",
+	"错误: --group-by-host 的输出文件 %s 与本次运行的其他输出文件相同，请修改 --output 前缀\n": "error: --group-by-host output file %s is the same as another output file of this run, please change the --output prefix\n",
+	"输出文件 %s 会被写出多次": "output file %s would be written more than once",
+}
@@ -0,0 +1,166 @@
+package atopparse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// netRegex 匹配atop的NET网络接口行（每个接口一条），取接口名、收/发包数以及收/发速率，例如
+// "NET | eth0 ---- | pcki 2045 | pcko 2107 | sp 1000 Mbps | si 123 Kbps | so 456 Kbps | ..."。
+// 接口名后可能带有忙碌百分比或 ----，pcko与si之间可能有链路速率sp等字段。
+// transport（TCP/UDP）和network（IP）汇总行没有pcki字段，不会匹配
+var netRegex = regexp.MustCompile(`^NET \|\s*(\S+)(?:\s+(?:-+|[\d.]+%))?\s*\|\s*pcki\s+([\d.]+(?:e\d+)?)\s*\|\s*pcko\s+([\d.]+(?:e\d+)?)\s*\|.*?\bsi\s+([\d.]+(?:e\d+)?)\s*([KMGT]?bps)\s*\|\s*so\s+([\d.]+(?:e\d+)?)\s*([KMGT]?bps)`)
+
+// netRateToMbps 各速率单位换算为Mbps的系数，atop按1000进位
+var netRateToMbps = map[string]float64{
+	"bps":  1e-6,
+	"Kbps": 1e-3,
+	"Mbps": 1,
+	"Gbps": 1e3,
+	"Tbps": 1e6,
+}
+
+// NetRecord 某个网络接口在一个采样时间点的统计，PacketsIn/PacketsOut为采样间隔内的收/发包数
+type NetRecord struct {
+	Timestamp  time.Time
+	Interface  string
+	PacketsIn  float64
+	PacketsOut float64
+	InMbps     float64 // 接收速率，单位统一为Mbps
+	OutMbps    float64 // 发送速率，单位统一为Mbps
+}
+
+// netCSVHeader 是网络CSV的表头，每行为一个接口在一个时间点的统计
+var netCSVHeader = []string{"timestamp", "interface", "pcki", "pcko", "si_mbps", "so_mbps"}
+
+// parseNet 解析NET接口行，不是接口行或字段不完整时ok为false，数值格式错误时返回错误
+func parseNet(line string, timestamp time.Time) (NetRecord, bool, error) {
+	matches := netRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return NetRecord{}, false, nil
+	}
+	values, err := parseNumbers(matches[2], matches[3], matches[4], matches[6])
+	if err != nil {
+		return NetRecord{}, false, err
+	}
+	return NetRecord{
+		Timestamp:  timestamp,
+		Interface:  matches[1],
+		PacketsIn:  values[0],
+		PacketsOut: values[1],
+		InMbps:     values[2] * netRateToMbps[matches[5]],
+		OutMbps:    values[3] * netRateToMbps[matches[7]],
+	}, true, nil
+}
+
+// NetRecords 按时间顺序展开记录中的网络接口统计；时间戳以所属记录为准
+func NetRecords(data []MemoryRecord) []NetRecord {
+	var nets []NetRecord
+	for _, record := range data {
+		for _, net := range record.Nets {
+			net.Timestamp = record.Timestamp
+			nets = append(nets, net)
+		}
+	}
+	return nets
+}
+
+// netInterfaces 返回出现过的接口名，按名称排序
+func netInterfaces(nets []NetRecord) []string {
+	seen := make(map[string]bool)
+	var interfaces []string
+	for _, net := range nets {
+		if !seen[net.Interface] {
+			seen[net.Interface] = true
+			interfaces = append(interfaces, net.Interface)
+		}
+	}
+	sort.Strings(interfaces)
+	return interfaces
+}
+
+// writeNetCSV 以 timestamp,interface,pcki,pcko,si_mbps,so_mbps 的长格式写出网络接口统计，
+// 只在部分采样块中出现的接口只占有它出现的行
+func writeNetCSV(nets []NetRecord, outputFile string, opts ReportOptions) error {
+	out, err := CreateOutput(outputFile, opts.Gzip)
+	if err != nil {
+		return err
+	}
+
+	writer := newCSVWriter(out, opts)
+	if err := writer.Write(netCSVHeader); err != nil {
+		out.Abort()
+		return err
+	}
+	for _, net := range nets {
+		row := []string{
+			net.Timestamp.Format("2006-01-02 15:04:05"),
+			net.Interface,
+			FormatValue(net.PacketsIn, opts.Precision),
+			FormatValue(net.PacketsOut, opts.Precision),
+			FormatValue(net.InMbps, opts.Precision),
+			FormatValue(net.OutMbps, opts.Precision),
+		}
+		if err := writer.Write(row); err != nil {
+			out.Abort()
+			return err
+		}
+	}
+
+	if err := writeCSVFooter(out, writer, opts.Provenance); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// generateNetChart 绘制各网络接口的收/发速率(Mbps)，同一接口的接收为实线、发送为虚线，颜色相同
+func generateNetChart(nets []NetRecord, outputFile string) error {
+	if len(nets) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的网络数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Network Throughput"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Throughput (Mbps)"
+	p.Y.Min = 0
+	p.X.Tick.Marker = timeAxis()
+
+	in := make(map[string]plotter.XYs)
+	out := make(map[string]plotter.XYs)
+	for _, net := range nets {
+		x := timeAxisX(net.Timestamp)
+		in[net.Interface] = append(in[net.Interface], plotter.XY{X: x, Y: net.InMbps})
+		out[net.Interface] = append(out[net.Interface], plotter.XY{X: x, Y: net.OutMbps})
+	}
+	for i, iface := range netInterfaces(nets) {
+		for _, direction := range []struct {
+			label  string
+			points plotter.XYs
+			dashed bool
+		}{
+			{"in", in[iface], false},
+			{"out", out[iface], true},
+		} {
+			line, err := plotter.NewLine(direction.points)
+			if err != nil {
+				return err
+			}
+			line.Color = derivedColors[i%len(derivedColors)]
+			if direction.dashed {
+				line.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+			}
+			p.Add(line)
+			p.Legend.Add(iface+" "+direction.label, line)
+		}
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
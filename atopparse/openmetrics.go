@@ -0,0 +1,84 @@
+package atopparse
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricHelp OpenMetrics和Prometheus输出中各指标的# HELP说明，键与csvHeader中的列名一致。
+// 导出的数据会被其他系统读取，因此使用固定的英文，不随控制台语言变化
+var metricHelp = map[string]string{
+	"mem_tot":   "Total physical memory from the atop MEM line, in gigabytes",
+	"mem_free":  "Free physical memory from the atop MEM line, in gigabytes",
+	"swp_tot":   "Total swap space from the atop SWP line, in gigabytes",
+	"swp_free":  "Free swap space from the atop SWP line, in gigabytes",
+	"mem_cache": "Page cache from the atop MEM line, in gigabytes",
+	"mem_buff":  "Buffer memory from the atop MEM line, in gigabytes",
+}
+
+// escapeLabelValue 按OpenMetrics规则转义标签值中的反斜杠、双引号和换行
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// openMetricsTimestamp 按OpenMetrics规范将时间戳格式化为秒，保留到毫秒；1970年以前的时间为负数
+func openMetricsTimestamp(millis int64) string {
+	return strconv.FormatFloat(float64(millis)/1000, 'f', 3, 64)
+}
+
+// writeOpenMetrics 以OpenMetrics文本格式输出完整的历史序列，每个样本带有时间戳，数值单位为GB，
+// 指标名与Prometheus输出相同。每个主机（RecordHost）一条序列，带有host标签，轮转产生的多个日志文件
+// 属于同一序列；按规范要求时间戳严格递增，重复的时间戳只保留第一个样本。返回因时间戳重复而跳过的样本数
+func writeOpenMetrics(data []MemoryRecord, outputFile string, precision int) (int, error) {
+	groups := GroupByHost(data)
+	hosts := make([]string, 0, len(groups))
+	skipped := 0
+	for host, records := range groups {
+		hosts = append(hosts, host)
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+		kept := records[:1]
+		for _, record := range records[1:] {
+			if record.Timestamp.UnixMilli() <= kept[len(kept)-1].Timestamp.UnixMilli() {
+				skipped++
+				continue
+			}
+			kept = append(kept, record)
+		}
+		groups[host] = kept
+	}
+	sort.Strings(hosts)
+
+	out, err := CreateOutput(outputFile, false)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(out)
+	// 同一指标族的所有样本必须连续输出，因此外层按指标循环
+	for _, metric := range MetricNames {
+		name := prometheusName(metric)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(w, "# UNIT %s gigabytes\n", name)
+		fmt.Fprintf(w, "# HELP %s %s\n", name, metricHelp[metric])
+
+		for _, host := range hosts {
+			labels := ""
+			if host != "" {
+				labels = fmt.Sprintf(`{host="%s"}`, escapeLabelValue(host))
+			}
+			for _, record := range groups[host] {
+				value, _ := MetricValue(record, metric)
+				fmt.Fprintf(w, "%s%s %s %s\n", name, labels, FormatValue(value, precision), openMetricsTimestamp(record.Timestamp.UnixMilli()))
+			}
+		}
+	}
+	fmt.Fprintln(w, "# EOF")
+
+	if err := w.Flush(); err != nil {
+		out.Abort()
+		return 0, err
+	}
+	return skipped, out.Commit()
+}
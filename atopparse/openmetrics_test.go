@@ -0,0 +1,89 @@
+package atopparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenMetricsTimestamp(t *testing.T) {
+	tests := []struct {
+		millis int64
+		want   string
+	}{
+		{1718100000123, "1718100000.123"},
+		{1718100000000, "1718100000.000"},
+		{0, "0.000"},
+		{-1500, "-1.500"},
+		{-250, "-0.250"},
+	}
+	for _, tt := range tests {
+		if got := openMetricsTimestamp(tt.millis); got != tt.want {
+			t.Errorf("%d 毫秒格式化为 %q，期望 %q", tt.millis, got, tt.want)
+		}
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	record := func(host, source string, offset time.Duration, free float64) MemoryRecord {
+		return MemoryRecord{Timestamp: start.Add(offset), Hostname: host, Source: source, MemTotal: 16, MemFree: free, SwapTotal: 2, SwapFree: 1}
+	}
+	// web1 的日志轮转为两个文件，第二个文件开头重复了上一个文件最后的样本
+	data := []MemoryRecord{
+		record("web1", "atop_20240611", 0, 4),
+		record("web1", "atop_20240611", 10*time.Second, 3.5),
+		record("db1", "db.txt", 0, 8),
+		record("web1", "atop_20240612", 10*time.Second, 3.4),
+		record("web1", "atop_20240612", 20*time.Second, 3),
+	}
+
+	for _, locale := range []string{"zh", "en"} {
+		t.Run(locale, func(t *testing.T) {
+			defer func(saved string) { Locale = saved }(Locale)
+			Locale = locale
+
+			path := filepath.Join(t.TempDir(), "om.txt")
+			skipped, err := writeOpenMetrics(data, path, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped != 1 {
+				t.Errorf("跳过 %d 个样本，期望 1 个", skipped)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := string(content)
+
+			wantFree := "# TYPE atop_mem_free_gigabytes gauge\n" +
+				"# UNIT atop_mem_free_gigabytes gigabytes\n" +
+				"# HELP atop_mem_free_gigabytes Free physical memory from the atop MEM line, in gigabytes\n" +
+				`atop_mem_free_gigabytes{host="db1"} 8.00 1718100000.000` + "\n" +
+				`atop_mem_free_gigabytes{host="web1"} 4.00 1718100000.000` + "\n" +
+				`atop_mem_free_gigabytes{host="web1"} 3.50 1718100010.000` + "\n" +
+				`atop_mem_free_gigabytes{host="web1"} 3.00 1718100020.000` + "\n"
+			if !strings.Contains(text, wantFree) {
+				t.Errorf("mem_free指标族不正确:\n%s", text)
+			}
+			if strings.Contains(text, "source=") || !strings.HasSuffix(text, "# EOF\n") {
+				t.Errorf("应按主机分组并以 # EOF 结束:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestWriteOpenMetricsError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing", "om.txt")
+	data := []MemoryRecord{{Timestamp: time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), MemTotal: 16, MemFree: 4}}
+	if _, err := writeOpenMetrics(data, path, 2); err == nil {
+		t.Fatal("目录不存在时应返回错误")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("失败时不应留下输出文件: %v", err)
+	}
+}
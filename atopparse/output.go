@@ -0,0 +1,89 @@
+package atopparse
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// StdoutPath 作为输出路径时表示写到标准输出
+const StdoutPath = "-"
+
+// logOutput 返回进度、警告等诊断信息的输出位置，w为nil时为标准输出
+func logOutput(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
+}
+
+// OutputName 返回输出文件名，compress为true时追加.gz后缀
+func OutputName(name string, compress bool) string {
+	if compress {
+		return name + ".gz"
+	}
+	return name
+}
+
+// outputFile 先写入同目录下的临时文件，Commit时再改名为目标文件，
+// 写入中途失败不会留下不完整的输出；compress为true时内容经gzip压缩
+type outputFile struct {
+	io.Writer
+	file *os.File
+	gz   *gzip.Writer
+	path string
+}
+
+// CreateOutput 创建输出文件，调用方必须调用Commit或Abort；path为StdoutPath时写到标准输出
+func CreateOutput(path string, compress bool) (*outputFile, error) {
+	if path == StdoutPath {
+		out := &outputFile{Writer: os.Stdout, path: path}
+		if compress {
+			out.gz = gzip.NewWriter(os.Stdout)
+			out.Writer = out.gz
+		}
+		return out, nil
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	out := &outputFile{Writer: file, file: file, path: path}
+	if compress {
+		out.gz = gzip.NewWriter(file)
+		out.Writer = out.gz
+	}
+	return out, nil
+}
+
+// Commit 结束写入并将临时文件改名为目标文件
+func (o *outputFile) Commit() error {
+	if o.gz != nil {
+		if err := o.gz.Close(); err != nil {
+			o.Abort()
+			return err
+		}
+	}
+	if o.file == nil {
+		return nil
+	}
+	if err := o.file.Chmod(0644); err != nil {
+		o.Abort()
+		return err
+	}
+	if err := o.file.Close(); err != nil {
+		os.Remove(o.file.Name())
+		return err
+	}
+	return os.Rename(o.file.Name(), o.path)
+}
+
+// Abort 放弃写入并删除临时文件；Commit成功后调用无效果
+func (o *outputFile) Abort() {
+	if o.file == nil {
+		return
+	}
+	o.file.Close()
+	os.Remove(o.file.Name())
+}
@@ -0,0 +1,110 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// atop的PAG行给出采样间隔内的分页统计，其中swin/swout为换入/换出的页数
+var (
+	pagLineRegex  = regexp.MustCompile(`^PAG \|`)
+	pagSwinRegex  = regexp.MustCompile(`\|\s*swin\s+([\d.]+(?:e\d+)?)`)
+	pagSwoutRegex = regexp.MustCompile(`\|\s*swout\s+([\d.]+(?:e\d+)?)`)
+)
+
+// parsePAG 从PAG行中取出换入/换出页数，两者都存在时ok为true，数值格式错误时返回错误
+func parsePAG(line string) (swapIn, swapOut float64, ok bool, err error) {
+	if !pagLineRegex.MatchString(line) {
+		return 0, 0, false, nil
+	}
+	inMatch := pagSwinRegex.FindStringSubmatch(line)
+	outMatch := pagSwoutRegex.FindStringSubmatch(line)
+	if inMatch == nil || outMatch == nil {
+		return 0, 0, false, nil
+	}
+	values, err := parseNumbers(inMatch[1], outMatch[1])
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return values[0], values[1], true, nil
+}
+
+// pagRecords 返回带有PAG数据的记录
+func pagRecords(data []MemoryRecord) []MemoryRecord {
+	return FilterRecords(data, func(record MemoryRecord) bool { return record.HasPAG })
+}
+
+// swapRate 某个采样时间点的换入/换出速率（页/秒）
+type swapRate struct {
+	Timestamp time.Time
+	In        float64
+	Out       float64
+}
+
+// swapRates 把换入/换出页数除以采样间隔换算为每秒速率。采样间隔取日志头中的Interval，
+// 没有时退回与同一来源文件中上一个样本的时间差；两者都没有（如每个来源的第一个样本）时速率记为0
+func swapRates(data []MemoryRecord) []swapRate {
+	last := make(map[string]time.Time)
+	rates := make([]swapRate, len(data))
+	for i, record := range data {
+		rates[i].Timestamp = record.Timestamp
+		prev, ok := last[record.Source]
+		last[record.Source] = record.Timestamp
+		seconds := record.Interval.Seconds()
+		if record.Interval == 0 && ok {
+			seconds = record.Timestamp.Sub(prev).Seconds()
+		}
+		if seconds > 0 {
+			rates[i].In = record.SwapIn / seconds
+			rates[i].Out = record.SwapOut / seconds
+		}
+	}
+	return rates
+}
+
+// generateSwapRateChart 绘制换入/换出速率随时间的变化，超过maxPoints个点时降采样
+func generateSwapRateChart(data []MemoryRecord, outputFile string, maxPoints int) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的换页数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Swap In/Out Rate"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Pages/s"
+	p.Y.Min = 0
+	p.X.Tick.Marker = timeAxis()
+
+	rates := swapRates(data)
+	inData := make(plotter.XYs, len(rates))
+	outData := make(plotter.XYs, len(rates))
+	for i, rate := range rates {
+		x := timeAxisX(rate.Timestamp)
+		inData[i].X, inData[i].Y = x, rate.In
+		outData[i].X, outData[i].Y = x, rate.Out
+	}
+
+	inLine, err := plotter.NewLine(downsampleXYs(inData, maxPoints))
+	if err != nil {
+		return err
+	}
+	inLine.Color = color.RGBA{G: 160, A: 255}
+	p.Add(inLine)
+	p.Legend.Add("swap in (pages/s)", inLine)
+
+	outLine, err := plotter.NewLine(downsampleXYs(outData, maxPoints))
+	if err != nil {
+		return err
+	}
+	outLine.Color = color.RGBA{R: 255, A: 255}
+	p.Add(outLine)
+	p.Legend.Add("swap out (pages/s)", outLine)
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
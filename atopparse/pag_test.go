@@ -0,0 +1,58 @@
+package atopparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSwapRates(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	tests := []struct {
+		name    string
+		data    []MemoryRecord
+		wantIn  []float64
+		wantOut []float64
+	}{
+		{
+			name: "interval from header",
+			data: []MemoryRecord{
+				{Source: "a", Timestamp: at(0), Interval: 10 * time.Second, SwapIn: 100, SwapOut: 50},
+				{Source: "a", Timestamp: at(10), Interval: 10 * time.Second, SwapIn: 200, SwapOut: 0},
+			},
+			wantIn:  []float64{10, 20},
+			wantOut: []float64{5, 0},
+		},
+		{
+			name: "header interval differs from timestamp gap",
+			data: []MemoryRecord{
+				{Source: "a", Timestamp: at(0), Interval: 600 * time.Second, SwapIn: 600},
+				// 中间缺了样本，时间差为60秒，但该样本的换页数只覆盖日志头中的5秒
+				{Source: "a", Timestamp: at(60), Interval: 5 * time.Second, SwapIn: 50},
+			},
+			wantIn:  []float64{1, 10},
+			wantOut: []float64{0, 0},
+		},
+		{
+			name: "timestamp gap without header interval",
+			data: []MemoryRecord{
+				{Source: "a", Timestamp: at(0), SwapIn: 100},
+				{Source: "b", Timestamp: at(5), SwapIn: 100},
+				{Source: "a", Timestamp: at(20), SwapIn: 100},
+			},
+			wantIn:  []float64{0, 0, 5},
+			wantOut: []float64{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates := swapRates(tt.data)
+			for i, rate := range rates {
+				if rate.In != tt.wantIn[i] || rate.Out != tt.wantOut[i] {
+					t.Errorf("第 %d 个样本速率为 %v/%v，期望 %v/%v", i, rate.In, rate.Out, tt.wantIn[i], tt.wantOut[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,963 @@
+package atopparse
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemoryRecord 表示单条内存记录
+type MemoryRecord struct {
+	Timestamp  time.Time
+	MemTotal   float64
+	MemFree    float64
+	MemCache   float64 // 页缓存，较旧版本atop的MEM行没有该字段时为0
+	MemBuff    float64 // 缓冲区，较旧版本atop的MEM行没有该字段时为0
+	MemSlabRec float64 // 可回收的slab（MEM行的slrec字段），没有该字段时为0
+	SwapTotal  float64
+	SwapFree   float64
+	Source     string        // 记录来自的日志文件，不写入CSV
+	Hostname   string        // 日志头 "ATOP - <主机名>" 中的主机名，不写入CSV
+	Interval   time.Duration // 日志头 "10s elapsed" 中与上一个样本的采样间隔，没有时为0，不写入CSV
+
+	// PSI内存压力（停滞时间百分比），只有较新版本atop的日志才有，不写入CSV
+	HasPSI     bool
+	PSIMemSome float64
+	PSIMemFull float64
+
+	// CPU汇总行的sys/user/idle百分比（所有核心累加），日志中有CPU行时写入CSV
+	HasCPU  bool
+	CPUSys  float64
+	CPUUser float64
+	CPUIdle float64
+
+	// CPU汇总行的wait（iowait）百分比，CPU行中有wait字段时写入CSV的cpu_wait列
+	HasCPUWait bool
+	CPUWait    float64
+
+	// PAG行中采样间隔内换入/换出的页数，不写入CSV；速率由相邻样本的间隔换算
+	HasPAG  bool
+	SwapIn  float64
+	SwapOut float64
+
+	// 各磁盘设备的DSK行统计，一个采样块中每个设备一条，不写入主CSV
+	Disks []DiskRecord
+
+	// 各核心的单核cpu行利用率，一个采样块中每个核心一条，只用于--cpu-heatmap，不写入CSV
+	Cores []CoreRecord
+
+	// 各网络接口的NET行统计，一个采样块中每个接口一条，不写入主CSV
+	Nets []NetRecord
+}
+
+// csvHeader 是CSV报告的表头
+var csvHeader = []string{"timestamp", "mem_tot", "mem_free", "swp_tot", "swp_free", "mem_cache", "mem_buff", "mem_slrec"}
+
+// MetricNames 列出可按名称取值的指标，名称与CSV列名一致
+var MetricNames = []string{"mem_tot", "mem_free", "swp_tot", "swp_free", "mem_cache", "mem_buff"}
+
+// MetricValue 按指标名称返回记录中的值
+func MetricValue(record MemoryRecord, name string) (float64, bool) {
+	switch name {
+	case "mem_tot":
+		return record.MemTotal, true
+	case "mem_free":
+		return record.MemFree, true
+	case "swp_tot":
+		return record.SwapTotal, true
+	case "swp_free":
+		return record.SwapFree, true
+	case "mem_cache":
+		return record.MemCache, true
+	case "mem_buff":
+		return record.MemBuff, true
+	}
+	return 0, false
+}
+
+// FormatValue 按指定的小数位数格式化写入文件的数值，内部计算始终使用完整精度
+func FormatValue(value float64, precision int) string {
+	return strconv.FormatFloat(value, 'f', precision, 64)
+}
+
+// ParseOptions 控制日志解析行为
+type ParseOptions struct {
+	FailFast       bool          // 目录模式下遇到第一个解析出错或没有有效数据的文件时立即返回错误
+	TrimWarmup     int           // 每个文件开头丢弃的样本数
+	DateLayout     string        // 指定时间戳的解析格式（Go时间格式），为空时自动尝试dateLayouts
+	HTTPTimeout    time.Duration // 从HTTP(S)地址读取日志的超时时间
+	MemLines       string        // 同一采样块出现多条MEM行时的处理方式: first或sum
+	SwapLines      string        // 同一采样块出现多条SWP行时的处理方式: sum或first
+	NoSort         bool          // 目录模式下假定按文件名顺序合并后的记录已按时间排列，跳过排序
+	Cache          *RecordCache  // 解析结果缓存，为nil时每次都重新解析
+	Journald       bool          // 输入来自journald，匹配前先去掉每行的journald前缀
+	SkipEmpty      bool          // 目录模式下不逐个提示没有有效数据的文件，只在最后汇总数量
+	Recursive      bool          // 目录模式下递归读取子目录中的文件
+	Workers        int           // 目录模式下同时解析的文件数，0表示runtime.NumCPU()
+	MaxConcurrency int           // 大于0时为同时解析的文件数的上限，优先于Workers；1表示串行解析
+	Strict         bool          // 遇到第一个数值格式错误的字段时返回错误，而不是丢弃该采样块继续解析
+	Dedup          bool          // 目录模式下排序后合并同一主机时间戳相同的记录（日志轮转重叠），只保留文件顺序中的第一条
+	Log            io.Writer     // 进度和警告信息的输出位置，为nil时写到标准输出
+}
+
+// console 返回解析过程中进度和警告信息的输出位置
+func (opts ParseOptions) console() io.Writer {
+	return logOutput(opts.Log)
+}
+
+// DetectionInfo 记录解析过程中自动识别出的格式信息
+type DetectionInfo struct {
+	Units    map[string]int  // 各容量单位出现的次数
+	Hosts    map[string]bool // 日志头中出现的主机名
+	Dates    map[string]int  // 各时间戳格式匹配的次数
+	Versions map[string]int  // 日志中 "Version: 2.7.1" 等版本行给出的atop版本及出现次数
+
+	Intervals map[time.Duration]int // 日志头中各采样间隔出现的次数
+
+	MultiMemBlocks  int // 含有多条MEM行的采样块数
+	MultiSwapBlocks int // 含有多条SWP行的采样块数
+	PSIBlocks       int // 含有PSI内存压力数据的采样块数
+
+	UnparsedTimestamps int // 时间戳无法解析而被丢弃的日志头行数
+	MalformedLines     int // 数值字段格式错误的行数，所在采样块已丢弃
+}
+
+// NewDetectionInfo 创建空的识别信息
+func NewDetectionInfo() *DetectionInfo {
+	return &DetectionInfo{
+		Units:    make(map[string]int),
+		Hosts:    make(map[string]bool),
+		Dates:    make(map[string]int),
+		Versions: make(map[string]int),
+
+		Intervals: make(map[time.Duration]int),
+	}
+}
+
+// addUnits 记录一次匹配中出现的容量单位
+func (d *DetectionInfo) addUnits(units ...string) {
+	if d == nil {
+		return
+	}
+	for _, unit := range units {
+		d.Units[unit]++
+	}
+}
+
+// addHost 记录日志头中的主机名
+func (d *DetectionInfo) addHost(host string) {
+	if d == nil {
+		return
+	}
+	d.Hosts[host] = true
+}
+
+// addDateLayout 记录时间戳匹配的格式
+func (d *DetectionInfo) addDateLayout(layout string) {
+	if d == nil {
+		return
+	}
+	d.Dates[layout]++
+}
+
+// addVersion 记录日志中的atop版本
+func (d *DetectionInfo) addVersion(version string) {
+	if d == nil {
+		return
+	}
+	d.Versions[version]++
+}
+
+// addInterval 记录日志头中的采样间隔
+func (d *DetectionInfo) addInterval(interval time.Duration) {
+	if d == nil || interval <= 0 {
+		return
+	}
+	d.Intervals[interval]++
+}
+
+// addMultiMemBlock 记录一个含有多条MEM行的采样块
+func (d *DetectionInfo) addMultiMemBlock() {
+	if d == nil {
+		return
+	}
+	d.MultiMemBlocks++
+}
+
+// addMultiSwapBlock 记录一个含有多条SWP行的采样块
+func (d *DetectionInfo) addMultiSwapBlock() {
+	if d == nil {
+		return
+	}
+	d.MultiSwapBlocks++
+}
+
+// addPSIBlock 记录一个含有PSI内存压力数据的采样块
+func (d *DetectionInfo) addPSIBlock() {
+	if d == nil {
+		return
+	}
+	d.PSIBlocks++
+}
+
+// addUnparsedTimestamp 记录一个时间戳无法解析的日志头行
+func (d *DetectionInfo) addUnparsedTimestamp() {
+	if d == nil {
+		return
+	}
+	d.UnparsedTimestamps++
+}
+
+// addMalformedLine 记录一个数值字段格式错误的行
+func (d *DetectionInfo) addMalformedLine() {
+	if d == nil {
+		return
+	}
+	d.MalformedLines++
+}
+
+// merge 合并另一份识别信息（例如从缓存读取的单个文件的结果）
+func (d *DetectionInfo) merge(other *DetectionInfo) {
+	if d == nil || other == nil {
+		return
+	}
+	for unit, n := range other.Units {
+		d.Units[unit] += n
+	}
+	for host := range other.Hosts {
+		d.Hosts[host] = true
+	}
+	for layout, n := range other.Dates {
+		d.Dates[layout] += n
+	}
+	for version, n := range other.Versions {
+		d.Versions[version] += n
+	}
+	for interval, n := range other.Intervals {
+		d.Intervals[interval] += n
+	}
+	d.MultiMemBlocks += other.MultiMemBlocks
+	d.MultiSwapBlocks += other.MultiSwapBlocks
+	d.PSIBlocks += other.PSIBlocks
+	d.UnparsedTimestamps += other.UnparsedTimestamps
+	d.MalformedLines += other.MalformedLines
+}
+
+// PrintDetectionSummary 将自动识别结果摘要输出到w
+func PrintDetectionSummary(w io.Writer, info *DetectionInfo) {
+	hosts := make([]string, 0, len(info.Hosts))
+	for host := range info.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	units := make([]string, 0, len(info.Units))
+	for unit := range info.Units {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	unitCounts := make([]string, len(units))
+	for i, unit := range units {
+		unitCounts[i] = fmt.Sprintf("%s=%d", unit, info.Units[unit])
+	}
+
+	layouts := make([]string, 0, len(info.Dates))
+	for layout := range info.Dates {
+		layouts = append(layouts, fmt.Sprintf("%q=%d", layout, info.Dates[layout]))
+	}
+	sort.Strings(layouts)
+
+	versions := make([]string, 0, len(info.Versions))
+	for version := range info.Versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	atopVersion := Tr("未知（日志中没有版本行）")
+	if len(versions) > 0 {
+		atopVersion = strings.Join(versions, ", ")
+	}
+
+	fmt.Fprintln(w, Tr("自动识别摘要:"))
+	fmt.Fprintf(w, Tr("  atop版本: %s\n"), atopVersion)
+	fmt.Fprintf(w, Tr("  主机数量: %d (%s)\n"), len(hosts), strings.Join(hosts, ", "))
+	fmt.Fprintf(w, Tr("  容量单位分布: %s\n"), strings.Join(unitCounts, ", "))
+	fmt.Fprintf(w, Tr("  时间戳格式: %s\n"), strings.Join(layouts, ", "))
+	if len(info.Intervals) > 0 {
+		intervals := make([]time.Duration, 0, len(info.Intervals))
+		for interval := range info.Intervals {
+			intervals = append(intervals, interval)
+		}
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+		intervalCounts := make([]string, len(intervals))
+		for i, interval := range intervals {
+			intervalCounts[i] = fmt.Sprintf("%v=%d", interval, info.Intervals[interval])
+		}
+		fmt.Fprintf(w, Tr("  采样间隔: %s\n"), strings.Join(intervalCounts, ", "))
+	}
+	if info.MultiMemBlocks > 0 {
+		fmt.Fprintf(w, Tr("  含多条MEM行的采样块: %d\n"), info.MultiMemBlocks)
+	}
+	if info.MultiSwapBlocks > 0 {
+		fmt.Fprintf(w, Tr("  含多条SWP行的采样块: %d\n"), info.MultiSwapBlocks)
+	}
+	if info.PSIBlocks > 0 {
+		fmt.Fprintf(w, Tr("  含PSI内存压力数据的采样块: %d\n"), info.PSIBlocks)
+	}
+	if info.UnparsedTimestamps > 0 {
+		fmt.Fprintf(w, Tr("  无法解析的时间戳行: %d（对应的采样块已丢弃，可用 --date-layout 指定格式）\n"), info.UnparsedTimestamps)
+	}
+	if info.MalformedLines > 0 {
+		fmt.Fprintf(w, Tr("  数值格式错误的行: %d（对应的采样块已丢弃）\n"), info.MalformedLines)
+	}
+	fmt.Fprintln(w, Tr("  时区假设: 按日志中的本地时间解析，不做时区转换"))
+}
+
+// 编译正则表达式
+var (
+	timestampRegex = regexp.MustCompile(`ATOP - (\S+)\s+(.*?\d{1,2}:\d{2}:\d{2})`)
+	memRegex       = regexp.MustCompile(`MEM \| tot\s+([\d.]+)([A-Za-z]) \| free\s+([\d.]+)([A-Za-z])(?:.*?\| cache\s+([\d.]+)([A-Za-z]))?(?:.*?\| buff\s+([\d.]+)([A-Za-z]))?`)
+	swpRegex       = regexp.MustCompile(`SWP \| tot\s+([\d.]+)([A-Za-z]) \| free\s+([\d.]+)([A-Za-z])`)
+)
+
+// versionRegex 匹配 atop -V 输出的 "Version: 2.7.1 - 2022/01/08 ..." 或 "atop version 2.7.1" 等版本行；
+// atop -r 的文本输出本身不含版本，只有采集脚本把版本写进日志时才能识别
+var versionRegex = regexp.MustCompile(`(?i)^\s*(?:atop\s+)?version:?\s+v?(\d+\.\d+(?:\.\d+)?)\b`)
+
+// unitFactors 是各容量单位换算为GB的系数
+var unitFactors = map[string]float64{
+	"T": 1024,
+	"G": 1,
+	"M": 1.0 / 1024,
+	"K": 1.0 / (1024 * 1024),
+}
+
+// toGB 将带单位的容量换算为GB，单位未知时known为false，数值格式错误时返回错误
+func toGB(value, unit string) (gb float64, known bool, err error) {
+	factor, known := unitFactors[unit]
+	if !known {
+		return 0, false, nil
+	}
+	number, err := parseNumber(value)
+	if err != nil {
+		return 0, true, err
+	}
+	return number * factor, true, nil
+}
+
+// parseNumber 解析日志中的数值字段。被截断或损坏的日志可能出现 "1.2.3" 这样的字段，
+// 此时返回错误而不是当作0
+func parseNumber(value string) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf(Tr("数值格式错误: %q"), value)
+	}
+	return number, nil
+}
+
+// firstError 返回第一个不为nil的错误
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseNumbers 依次解析多个数值字段，返回第一个格式错误
+func parseNumbers(values ...string) ([]float64, error) {
+	numbers := make([]float64, len(values))
+	for i, value := range values {
+		number, err := parseNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// dateLayouts 未指定--date-layout时依次尝试的时间戳格式，第一个为atop默认格式
+var dateLayouts = []string{
+	"2006/01/02 15:04:05",
+	"2006-01-02 15:04:05",
+	"02/01/2006 15:04:05",
+	"02-01-2006 15:04:05",
+	"02.01.2006 15:04:05",
+	"2006-01-02T15:04:05",
+	"2 Jan 2006 15:04:05",
+	"Jan 2 2006 15:04:05",
+	"2006 Jan 2 15:04:05",
+}
+
+// localMonths 将本地化的月份缩写（德语、法语、西班牙语、意大利语等，小写并去掉末尾的点）
+// 换成英文缩写，使包含Jan的格式也能解析非英文环境下atop输出的月份名
+var localMonths = map[string]string{
+	"ene": "Jan", "janv": "Jan", "gen": "Jan", "jän": "Jan",
+	"févr": "Feb", "fév": "Feb", "fevr": "Feb",
+	"mär": "Mar", "mrz": "Mar", "mars": "Mar",
+	"avr": "Apr", "abr": "Apr",
+	"mai": "May", "may": "May", "mag": "May",
+	"juin": "Jun", "giu": "Jun",
+	"juil": "Jul", "lug": "Jul",
+	"août": "Aug", "aout": "Aug", "ago": "Aug",
+	"sept": "Sep", "set": "Sep",
+	"okt": "Oct", "ott": "Oct",
+	"dez": "Dec", "déc": "Dec", "dic": "Dec",
+}
+
+// englishMonths 将时间戳中的本地化月份缩写替换为英文，其余部分不变
+func englishMonths(value string) string {
+	fields := strings.Fields(value)
+	for i, field := range fields {
+		if month, ok := localMonths[strings.TrimSuffix(strings.ToLower(field), ".")]; ok {
+			fields[i] = month
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// parseTimestamp 依次尝试layouts解析时间戳，返回解析结果和匹配的格式；
+// 连续空白会先合并为一个空格，以兼容atop在日期和时间之间输出多个空格的情况
+func parseTimestamp(value string, layouts []string) (time.Time, string, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range layouts {
+		if timestamp, err := time.Parse(layout, value); err == nil {
+			return timestamp, layout, nil
+		}
+	}
+	// 月份名不是英文时换成英文缩写后再试一次
+	if localized := englishMonths(value); localized != value {
+		for _, layout := range layouts {
+			if timestamp, err := time.Parse(layout, localized); err == nil {
+				return timestamp, layout, nil
+			}
+		}
+	}
+	return time.Time{}, "", fmt.Errorf(Tr("无法解析时间戳 %q"), value)
+}
+
+// StdinPath 作为日志路径时表示从标准输入读取
+const StdinPath = "-"
+
+// openLog 打开日志输入，filePath可以是本地文件、HTTP(S)地址或表示标准输入的StdinPath
+func openLog(filePath string, opts ParseOptions) (io.ReadCloser, error) {
+	if filePath == StdinPath {
+		return io.NopCloser(os.Stdin), nil
+	}
+	if IsRemoteLog(filePath) {
+		return openRemoteLog(filePath, opts.HTTPTimeout)
+	}
+	return os.Open(filePath)
+}
+
+// gzipLog 包装gzip压缩的日志，关闭时同时关闭底层输入
+type gzipLog struct {
+	*gzip.Reader
+	file io.Closer
+}
+
+func (g gzipLog) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// decompressLog 按内容开头的gzip魔数判断日志是否经过压缩（logrotate压缩的.gz文件
+// 或未声明Content-Encoding的远程文件），是则返回解压后的输入
+func decompressLog(file io.ReadCloser, filePath string) (io.ReadCloser, bool, error) {
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return struct {
+			io.Reader
+			io.Closer
+		}{buffered, file}, false, nil
+	}
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		file.Close()
+		return nil, true, fmt.Errorf(Tr("解压 %s 失败: %v"), filePath, err)
+	}
+	return gzipLog{gz, file}, true, nil
+}
+
+// ParseLog 解析单个atop日志文件，gzip压缩的文件会自动解压
+// info不为nil时，会记录识别出的主机名和容量单位
+func ParseLog(filePath string, opts ParseOptions, info *DetectionInfo) ([]MemoryRecord, error) {
+	raw, err := openLog(filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	file, compressed, err := decompressLog(raw, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	layouts := dateLayouts
+	if opts.DateLayout != "" {
+		layouts = []string{opts.DateLayout}
+	}
+
+	var data []MemoryRecord
+	var current MemoryRecord // 当前采样块中已解析的数据
+	var memLines int         // 当前采样块中已出现的MEM行数
+	var swpLines int         // 当前采样块中已出现的SWP行数
+	var unparsed int         // 时间戳无法解析的日志头行数
+	var malformed int        // 数值字段格式错误的行数
+	var badBlock bool        // 当前采样块中有格式错误的行，整块丢弃
+	var lineNo int
+
+	// warnUnknownUnit 对每个未知的容量单位只警告一次，包含该单位的值不会被使用
+	warnedUnits := make(map[string]bool)
+	warnUnknownUnit := func(units ...string) {
+		for _, unit := range units {
+			if _, known := unitFactors[unit]; !known && !warnedUnits[unit] {
+				warnedUnits[unit] = true
+				fmt.Fprintf(opts.console(), Tr("警告: %s 中出现未知的容量单位 %q，已忽略使用该单位的值\n"), filePath, unit)
+			}
+		}
+	}
+
+	// flushBlock 将当前采样块写入数据列表，块中必须已经出现过MEM行；
+	// 在遇到下一个时间戳行以及文件结束时调用，因此末尾不完整的采样块（例如只有MEM行、缺少结尾换行）也能保留
+	flushBlock := func() {
+		if memLines > 0 && !badBlock {
+			data = append(data, current)
+			if current.HasPSI {
+				info.addPSIBlock()
+			}
+		}
+		if memLines > 1 {
+			info.addMultiMemBlock()
+		}
+		if swpLines > 1 {
+			info.addMultiSwapBlock()
+		}
+		memLines = 0
+		swpLines = 0
+		badBlock = false
+	}
+
+	// malformedLine 处理数值字段格式错误的行：Strict时返回带行号的错误，
+	// 否则计数并丢弃当前采样块，避免错误的值被当作0画进图表
+	malformedLine := func(err error) error {
+		if opts.Strict {
+			return fmt.Errorf(Tr("%s 第 %d 行: %v"), filePath, lineNo, err)
+		}
+		malformed++
+		info.addMalformedLine()
+		badBlock = true
+		return nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNo++
+		if opts.Journald {
+			line = stripJournalPrefix(line)
+		}
+
+		if matches := versionRegex.FindStringSubmatch(line); matches != nil {
+			info.addVersion(matches[1])
+			continue
+		}
+
+		// 匹配时间戳行
+		if matches := timestampRegex.FindStringSubmatch(line); matches != nil {
+			flushBlock()
+			timestamp, layout, err := parseTimestamp(matches[2], layouts)
+			if err != nil {
+				// 时间戳无法解析时丢弃整个采样块，避免其数据被记到上一个时间点
+				info.addUnparsedTimestamp()
+				unparsed++
+				current = MemoryRecord{}
+				continue
+			}
+			info.addHost(matches[1])
+			info.addDateLayout(layout)
+			interval := parseInterval(line)
+			info.addInterval(interval)
+			current = MemoryRecord{Timestamp: timestamp, Source: filePath, Hostname: matches[1], Interval: interval}
+			continue
+		}
+		if strings.Contains(line, "ATOP - ") {
+			// 日志头中找不到 HH:MM:SS 形式的时间，同样丢弃这个采样块
+			flushBlock()
+			info.addUnparsedTimestamp()
+			unparsed++
+			current = MemoryRecord{}
+			continue
+		}
+
+		// 匹配MEM行
+		if matches := memRegex.FindStringSubmatch(line); matches != nil && !current.Timestamp.IsZero() {
+			memTot, okTot, errTot := toGB(matches[1], matches[2])
+			memFree, okFree, errFree := toGB(matches[3], matches[4])
+			if err := firstError(errTot, errFree); err != nil {
+				if err := malformedLine(err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if !okTot || !okFree {
+				warnUnknownUnit(matches[2], matches[4])
+				continue
+			}
+			info.addUnits(matches[2], matches[4])
+
+			// cache和buff字段是可选的，较旧版本atop的MEM行没有时记为0
+			var memCache, memBuff float64
+			var errCache, errBuff error
+			if matches[5] != "" {
+				var ok bool
+				if memCache, ok, errCache = toGB(matches[5], matches[6]); ok {
+					info.addUnits(matches[6])
+				} else {
+					warnUnknownUnit(matches[6])
+				}
+			}
+			if matches[7] != "" {
+				var ok bool
+				if memBuff, ok, errBuff = toGB(matches[7], matches[8]); ok {
+					info.addUnits(matches[8])
+				} else {
+					warnUnknownUnit(matches[8])
+				}
+			}
+			var memSlabRec float64
+			var errSlabRec error
+			if slrec := memSlrecRegex.FindStringSubmatch(line); slrec != nil {
+				var ok bool
+				if memSlabRec, ok, errSlabRec = toGB(slrec[1], slrec[2]); ok {
+					info.addUnits(slrec[2])
+				} else {
+					warnUnknownUnit(slrec[2])
+				}
+			}
+			if err := firstError(errCache, errBuff, errSlabRec); err != nil {
+				if err := malformedLine(err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			// 同一采样块有多条MEM行（例如按内存区域输出）时按策略处理：
+			// first只保留第一条（系统总量），sum累加所有行
+			if memLines == 0 {
+				current.MemTotal = memTot
+				current.MemFree = memFree
+				current.MemCache = memCache
+				current.MemBuff = memBuff
+				current.MemSlabRec = memSlabRec
+			} else if opts.MemLines == "sum" {
+				current.MemTotal += memTot
+				current.MemFree += memFree
+				current.MemCache += memCache
+				current.MemBuff += memBuff
+				current.MemSlabRec += memSlabRec
+			}
+			memLines++
+			continue
+		}
+
+		// 匹配PSI行
+		some, full, ok, err := parsePSIMemory(line)
+		if err != nil && !current.Timestamp.IsZero() {
+			if err := malformedLine(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok && !current.Timestamp.IsZero() {
+			current.HasPSI = true
+			current.PSIMemSome = some
+			current.PSIMemFull = full
+			continue
+		}
+
+		// 匹配PAG行
+		swapIn, swapOut, ok, err := parsePAG(line)
+		if err != nil && !current.Timestamp.IsZero() {
+			if err := malformedLine(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok && !current.Timestamp.IsZero() {
+			current.HasPAG = true
+			current.SwapIn = swapIn
+			current.SwapOut = swapOut
+			continue
+		}
+
+		// 匹配DSK行，同一采样块中每个设备一条，全部保留
+		disk, ok, err := parseDisk(line, current.Timestamp, current.Interval)
+		if err != nil && !current.Timestamp.IsZero() {
+			if err := malformedLine(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok && !current.Timestamp.IsZero() {
+			current.Disks = append(current.Disks, disk)
+			continue
+		}
+
+		// 匹配NET接口行，同一采样块中每个接口一条，全部保留；transport/network汇总行不匹配
+		net, ok, err := parseNet(line, current.Timestamp)
+		if err != nil && !current.Timestamp.IsZero() {
+			if err := malformedLine(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok && !current.Timestamp.IsZero() {
+			current.Nets = append(current.Nets, net)
+			continue
+		}
+
+		// 匹配CPU汇总行
+		cpu, ok, err := parseCPU(line)
+		if err != nil && !current.Timestamp.IsZero() {
+			if err := malformedLine(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok && !current.Timestamp.IsZero() {
+			current.HasCPU = true
+			current.CPUSys = cpu.Sys
+			current.CPUUser = cpu.User
+			current.CPUIdle = cpu.Idle
+			current.HasCPUWait = cpu.HasWait
+			current.CPUWait = cpu.Wait
+			continue
+		}
+
+		// 匹配单核cpu行，同一采样块中每个核心一条，全部保留
+		core, ok, err := parseCPUCore(line)
+		if err != nil && !current.Timestamp.IsZero() {
+			if err := malformedLine(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok && !current.Timestamp.IsZero() {
+			current.Cores = append(current.Cores, core)
+			continue
+		}
+
+		// 匹配SWP行
+		if matches := swpRegex.FindStringSubmatch(line); matches != nil && !current.Timestamp.IsZero() {
+			swpTot, okTot, errTot := toGB(matches[1], matches[2])
+			swpFree, okFree, errFree := toGB(matches[3], matches[4])
+			if err := firstError(errTot, errFree); err != nil {
+				if err := malformedLine(err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if !okTot || !okFree {
+				warnUnknownUnit(matches[2], matches[4])
+				continue
+			}
+			info.addUnits(matches[2], matches[4])
+
+			// 有多个交换设备时每个设备一条SWP行：sum累加为总量，first只保留第一条
+			if swpLines == 0 || opts.SwapLines == "sum" {
+				current.SwapTotal += swpTot
+				current.SwapFree += swpFree
+			}
+			swpLines++
+		}
+		// 其他行（例如抓取脚本插入的 "=== host start ===" 横幅或注释）直接忽略，
+		// 不会清空当前采样块，MEM和SWP行之间夹有这类行时样本仍完整
+	}
+
+	if err := scanner.Err(); err != nil {
+		// 压缩文件损坏或被截断时报错，而不是静默地只返回已解析的部分
+		if compressed {
+			return nil, fmt.Errorf(Tr("解压 %s 失败: %v"), filePath, err)
+		}
+		return nil, err
+	}
+	flushBlock()
+	if malformed > 0 {
+		fmt.Fprintf(opts.console(), Tr("警告: %s 中有 %d 行数值格式错误，对应的采样块已丢弃\n"), filePath, malformed)
+	}
+	if unparsed > 0 {
+		fmt.Fprintf(opts.console(), Tr("警告: %s 中有 %d 行无法解析的时间戳，对应的采样块已丢弃\n"), filePath, unparsed)
+	}
+
+	// 丢弃atop启动后最初几个数值不可靠的样本
+	if opts.TrimWarmup > 0 {
+		if opts.TrimWarmup >= len(data) {
+			return nil, nil
+		}
+		data = data[opts.TrimWarmup:]
+	}
+
+	return data, nil
+}
+
+// ParseDirectory 解析目录中的所有atop日志文件
+func ParseDirectory(dirPath string, opts ParseOptions, info *DetectionInfo) ([]MemoryRecord, error) {
+	return ParseDirectoryContext(context.Background(), dirPath, opts, info)
+}
+
+// ParseDirectoryContext 与ParseDirectory相同，但ctx取消或超时后立即停止等待并不再开始解析新的文件，
+// 返回按文件顺序已处理完的文件中的记录（同样经过排序和去重）以及包装了ctx.Err()的错误，
+// 调用方可以用errors.Is判断是否中止并决定是否使用这些记录
+func ParseDirectoryContext(ctx context.Context, dirPath string, opts ParseOptions, info *DetectionInfo) ([]MemoryRecord, error) {
+	// 检查目录是否存在
+	fileInfo, err := os.Stat(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf(Tr("目录 %s 不存在: %v"), dirPath, err)
+	}
+	if !fileInfo.IsDir() {
+		return nil, fmt.Errorf(Tr("%s 不是一个目录"), dirPath)
+	}
+
+	// 获取目录中的所有文件，Recursive时包括子目录中的文件
+	files, err := ListLogFiles(dirPath, opts.Recursive, opts.console())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		fmt.Fprintf(opts.console(), Tr("警告: 目录 %s 中没有找到文件\n"), dirPath)
+		return nil, nil
+	}
+
+	var allData []MemoryRecord
+	var successfulFiles int
+	var emptyFiles int
+
+	// 并发解析各文件，按文件顺序处理结果，提示信息和合并后的记录顺序与并发度无关
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := parseFiles(ctx, files, opts, poolSize(opts), opts.Cache.Parse)
+
+	var interrupted error
+	for i, filePath := range files {
+		// 提示信息中使用相对于目录的路径，递归模式下可以看出文件来自哪个子目录
+		name, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			name = filepath.Base(filePath)
+		}
+
+		var result fileResult
+		select {
+		case result = <-results[i]:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			interrupted = fmt.Errorf(Tr("处理完 %d/%d 个文件时中止: %w"), i, len(files), ctx.Err())
+			break
+		}
+		info.merge(result.info)
+		fileData, err := result.records, result.err
+		if err != nil {
+			if opts.FailFast {
+				return nil, fmt.Errorf(Tr("解析文件 %s 时出错: %v"), filePath, err)
+			}
+			fmt.Fprintf(opts.console(), Tr("解析文件 %s 时出错: %v\n"), name, err)
+			continue
+		}
+
+		if len(fileData) > 0 {
+			fmt.Fprintf(opts.console(), Tr("成功解析文件: %s, 找到 %d 条记录\n"), name, len(fileData))
+			allData = append(allData, fileData...)
+			successfulFiles++
+		} else {
+			if opts.FailFast {
+				return nil, fmt.Errorf(Tr("文件 %s 中没有找到有效数据"), filePath)
+			}
+			emptyFiles++
+			if !opts.SkipEmpty {
+				fmt.Fprintf(opts.console(), Tr("文件 %s 中没有找到有效数据\n"), name)
+			}
+		}
+	}
+	if opts.SkipEmpty && emptyFiles > 0 {
+		fmt.Fprintf(opts.console(), Tr("跳过了 %d 个没有有效数据的文件\n"), emptyFiles)
+	}
+
+	if len(allData) == 0 {
+		return nil, interrupted
+	}
+
+	// 按时间戳排序，时间戳相同的记录保持文件顺序；NoSort时只做一次线性检查，发现乱序仍然排序
+	if !opts.NoSort || !recordsSorted(allData) {
+		if opts.NoSort {
+			fmt.Fprintln(opts.console(), Tr("警告: 指定了 --no-sort 但记录并非按时间排列，仍然进行排序"))
+		}
+		sort.SliceStable(allData, func(i, j int) bool {
+			return allData[i].Timestamp.Before(allData[j].Timestamp)
+		})
+	}
+
+	// 排序是稳定的，时间戳相同的记录中保留的是文件顺序中的第一条
+	if opts.Dedup {
+		var removed int
+		allData, removed = dedupRecords(allData)
+		fmt.Fprintf(opts.console(), Tr("去除了 %d 条时间戳重复的记录\n"), removed)
+	}
+	warnMissingSamples(opts.console(), allData)
+
+	fmt.Fprintf(opts.console(), Tr("总共从 %d 个文件中解析出 %d 条记录\n"), successfulFiles, len(allData))
+	return allData, interrupted
+}
+
+// recordsSorted 判断记录是否已按时间戳非递减排列
+func recordsSorted(data []MemoryRecord) bool {
+	for i := 1; i < len(data); i++ {
+		if data[i].Timestamp.Before(data[i-1].Timestamp) {
+			return false
+		}
+	}
+	return true
+}
+
+// reversedRecords 返回按相反顺序排列的记录副本
+func reversedRecords(data []MemoryRecord) []MemoryRecord {
+	reversed := make([]MemoryRecord, len(data))
+	for i, record := range data {
+		reversed[len(data)-1-i] = record
+	}
+	return reversed
+}
+
+// RuleMetricValue 按指标名称取值，在MetricValue之外支持已用内存、已用交换空间和估算可用内存
+func RuleMetricValue(record MemoryRecord, metric string) float64 {
+	switch metric {
+	case "mem_used":
+		return record.MemTotal - record.MemFree
+	case "swp_used":
+		return record.SwapTotal - record.SwapFree
+	case memAvailColumn:
+		return record.MemAvailableEst()
+	}
+	value, _ := MetricValue(record, metric)
+	return value
+}
+
+// ValidRuleMetric 判断规则中的指标名是否受支持
+func ValidRuleMetric(metric string) bool {
+	if metric == "mem_used" || metric == "swp_used" || metric == memAvailColumn {
+		return true
+	}
+	_, ok := MetricValue(MemoryRecord{}, metric)
+	return ok
+}
@@ -0,0 +1,379 @@
+package atopparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseTestLog 把content写入临时文件并解析，返回记录和识别信息
+func parseTestLog(t *testing.T, content string, opts ParseOptions) ([]MemoryRecord, *DetectionInfo) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "atop.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	info := NewDetectionInfo()
+	data, err := ParseLog(path, opts, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, info
+}
+
+const versionTestBlock = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+`
+
+func TestDetectVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		want    map[string]int
+		summary string
+	}{
+		{"atop -V output", "Version: 2.7.1 - 2022/01/08 09:53:19     <gerlof.langeveld@atoptool.nl>\n", map[string]int{"2.7.1": 1}, "atop版本: 2.7.1"},
+		{"version line", "atop version 2.10\n", map[string]int{"2.10": 1}, "atop版本: 2.10"},
+		{"no version", "", map[string]int{}, "atop版本: 未知"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, info := parseTestLog(t, tt.prefix+versionTestBlock, ParseOptions{})
+			if len(data) != 1 {
+				t.Fatalf("应解析出1条记录，实际 %d 条", len(data))
+			}
+			if !reflect.DeepEqual(info.Versions, tt.want) {
+				t.Errorf("识别出的版本 %v，期望 %v", info.Versions, tt.want)
+			}
+			var summary bytes.Buffer
+			PrintDetectionSummary(&summary, info)
+			if !strings.Contains(summary.String(), tt.summary) {
+				t.Errorf("摘要中没有 %q:\n%s", tt.summary, summary.String())
+			}
+		})
+	}
+}
+
+// blocksLog 生成n个采样块的atop日志，从start开始每隔step一块，每块的空闲内存比上一块少0.1G
+func blocksLog(host string, start time.Time, n int, step time.Duration) string {
+	var log strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&log, "ATOP - %s  %s  --------  %ds elapsed\n", host, start.Add(time.Duration(i)*step).Format("2006/01/02  15:04:05"), int(step.Seconds()))
+		fmt.Fprintf(&log, "MEM | tot 16.0G | free %.1fG |\n", 8-0.1*float64(i))
+		log.WriteString("SWP | tot 2.0G | free 1.5G |\n")
+	}
+	return log.String()
+}
+
+func TestTrimWarmup(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		trim      int
+		wantCount int
+		wantFirst time.Time
+	}{
+		{"no trim", 0, 4, start},
+		{"trim one", 1, 3, start.Add(10 * time.Second)},
+		{"trim three", 3, 1, start.Add(30 * time.Second)},
+		{"trim all", 4, 0, time.Time{}},
+		{"trim more than samples", 10, 0, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := parseTestLog(t, blocksLog("web1", start, 4, 10*time.Second), ParseOptions{TrimWarmup: tt.trim})
+			if len(data) != tt.wantCount {
+				t.Fatalf("剩余 %d 条记录，期望 %d 条", len(data), tt.wantCount)
+			}
+			if len(data) > 0 && !data[0].Timestamp.Equal(tt.wantFirst) {
+				t.Errorf("第一条记录的时间为 %v，期望 %v", data[0].Timestamp, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestTrimWarmupPerFile(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	// 两个文件在时间上相接，按文件分别丢弃开头的样本，而不是只丢弃合并后最早的样本
+	for i, name := range []string{"atop_1.txt", "atop_2.txt"} {
+		content := blocksLog("web1", start.Add(time.Duration(i)*time.Minute), 3, 10*time.Second)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := ParseDirectory(dir, ParseOptions{TrimWarmup: 1, Log: io.Discard}, NewDetectionInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var times []string
+	for _, record := range data {
+		times = append(times, record.Timestamp.Format("15:04:05"))
+	}
+	want := []string{"10:00:10", "10:00:20", "10:01:10", "10:01:20"}
+	if !reflect.DeepEqual(times, want) {
+		t.Errorf("剩余记录的时间为 %v，期望 %v", times, want)
+	}
+}
+
+func TestParseLastLineWithoutNewline(t *testing.T) {
+	const first = "ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed\nMEM | tot 16.0G | free 4.0G |\nSWP | tot 2.0G | free 1.5G |\n"
+	const header = "ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed\n"
+	tests := []struct {
+		name         string
+		last         string
+		wantCount    int
+		wantSwapFree float64 // 最后一条记录的交换空间剩余量
+	}{
+		{"swp with newline", "MEM | tot 16.0G | free 3.0G |\nSWP | tot 2.0G | free 1.0G |\n", 2, 1},
+		{"swp without newline", "MEM | tot 16.0G | free 3.0G |\nSWP | tot 2.0G | free 1.0G |", 2, 1},
+		{"mem only with newline", "MEM | tot 16.0G | free 3.0G |\n", 2, 0},
+		{"mem only without newline", "MEM | tot 16.0G | free 3.0G |", 2, 0},
+		{"truncated swp without newline", "MEM | tot 16.0G | free 3.0G |\nSWP | tot 2.0G | fr", 2, 0},
+		{"header only without newline", "ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed", 1, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := first + header + tt.last
+			if strings.HasPrefix(tt.last, "ATOP") {
+				content = first + tt.last
+			}
+			data, _ := parseTestLog(t, content, ParseOptions{})
+			if len(data) != tt.wantCount {
+				t.Fatalf("解析出 %d 条记录，期望 %d 条", len(data), tt.wantCount)
+			}
+			last := data[len(data)-1]
+			if last.SwapFree != tt.wantSwapFree {
+				t.Errorf("最后一条记录的swp_free为 %v，期望 %v", last.SwapFree, tt.wantSwapFree)
+			}
+			if tt.wantCount == 2 && last.MemFree != 3 {
+				t.Errorf("最后一条记录的mem_free为 %v，期望 3", last.MemFree)
+			}
+		})
+	}
+}
+
+func TestDuplicateMemLines(t *testing.T) {
+	// 第一个采样块按内存区域输出了多条MEM行，第一条为系统总量；第二个采样块只有一条MEM行
+	const log = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G | cache 2.0G | buff 0.5G |
+MEM | tot 1.0G | free 0.5G | cache 0.2G | buff 0.1G |
+MEM | tot 15.0G | free 3.5G | cache 1.8G | buff 0.4G |
+SWP | tot 2.0G | free 1.5G |
+SWP | tot 1.0G | free 0.5G |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+MEM | tot 16.0G | free 3.0G | cache 2.0G | buff 0.5G |
+SWP | tot 2.0G | free 1.0G |
+`
+	tests := []struct {
+		name      string
+		memLines  string
+		swapLines string
+		want      [4]float64 // 第一条记录的 mem_tot, mem_free, mem_cache, swp_tot
+	}{
+		{"zero value keeps first lines", "", "", [4]float64{16, 4, 2, 2}},
+		{"first mem and sum swap", "first", "sum", [4]float64{16, 4, 2, 3}},
+		{"sum mem zones", "sum", "first", [4]float64{32, 8, 4, 2}},
+		{"sum both", "sum", "sum", [4]float64{32, 8, 4, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, info := parseTestLog(t, log, ParseOptions{MemLines: tt.memLines, SwapLines: tt.swapLines})
+			if len(data) != 2 {
+				t.Fatalf("应解析出2条记录，实际 %d 条", len(data))
+			}
+			first := data[0]
+			got := [4]float64{first.MemTotal, first.MemFree, first.MemCache, first.SwapTotal}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("第一条记录为 %v，期望 %v", got, tt.want)
+					break
+				}
+			}
+			if data[1].MemTotal != 16 || data[1].MemFree != 3 {
+				t.Errorf("只有一条MEM行的采样块不受影响，实际 %+v", data[1])
+			}
+			if info.MultiMemBlocks != 1 || info.MultiSwapBlocks != 1 {
+				t.Errorf("含多条MEM/SWP行的采样块为 %d/%d，期望 1/1", info.MultiMemBlocks, info.MultiSwapBlocks)
+			}
+		})
+	}
+}
+
+func TestMultipleSwapDevices(t *testing.T) {
+	const log = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+SWP | tot 512.0M | free 256.0M |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+SWP | tot 2.0G | free 1.0G |
+MEM | tot 16.0G | free 3.0G |
+SWP | tot 512.0M | free 128.0M |
+`
+	tests := []struct {
+		name      string
+		swapLines string
+		wantTotal [2]float64
+		wantFree  [2]float64
+	}{
+		{"sum devices", "sum", [2]float64{2.5, 2.5}, [2]float64{1.75, 1.125}},
+		{"first device", "first", [2]float64{2, 2}, [2]float64{1.5, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, info := parseTestLog(t, log, ParseOptions{SwapLines: tt.swapLines})
+			if len(data) != 2 {
+				t.Fatalf("应解析出2条记录，实际 %d 条", len(data))
+			}
+			for i, record := range data {
+				if math.Abs(record.SwapTotal-tt.wantTotal[i]) > 1e-9 || math.Abs(record.SwapFree-tt.wantFree[i]) > 1e-9 {
+					t.Errorf("第 %d 条记录的交换空间为 %v/%v，期望 %v/%v", i, record.SwapFree, record.SwapTotal, tt.wantFree[i], tt.wantTotal[i])
+				}
+			}
+			if info.MultiSwapBlocks != 2 {
+				t.Errorf("含多条SWP行的采样块为 %d，期望 2", info.MultiSwapBlocks)
+			}
+		})
+	}
+}
+
+func TestBannerLines(t *testing.T) {
+	const header = "ATOP - web1  2024/06/11  10:00:%02d  --------  10s elapsed\n"
+	tests := []struct {
+		name   string
+		banner string
+	}{
+		{"banner", "=== web1 start ==="},
+		{"comment", "# capture resumed"},
+		{"blank line", ""},
+		{"plain text", "ssh: connection to web1 restored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 注入的行出现在第一个日志头之前、采样块之间，以及MEM行与SWP行之间
+			log := tt.banner + "\n" +
+				fmt.Sprintf(header, 0) + "MEM | tot 16.0G | free 4.0G |\n" + tt.banner + "\n" + "SWP | tot 2.0G | free 1.5G |\n" +
+				tt.banner + "\n" +
+				fmt.Sprintf(header, 10) + "MEM | tot 16.0G | free 3.0G |\n" + tt.banner + "\n" + tt.banner + "\n" + "SWP | tot 2.0G | free 1.0G |\n"
+			data, info := parseTestLog(t, log, ParseOptions{})
+			want := []MemoryRecord{
+				{MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1.5},
+				{MemTotal: 16, MemFree: 3, SwapTotal: 2, SwapFree: 1},
+			}
+			if len(data) != len(want) {
+				t.Fatalf("解析出 %d 条记录，期望 %d 条", len(data), len(want))
+			}
+			for i, record := range data {
+				if record.MemTotal != want[i].MemTotal || record.MemFree != want[i].MemFree ||
+					record.SwapTotal != want[i].SwapTotal || record.SwapFree != want[i].SwapFree {
+					t.Errorf("第 %d 条记录为 %+v", i, record)
+				}
+			}
+			if info.MalformedLines != 0 {
+				t.Errorf("注入的行不应计为格式错误，实际 %d 行", info.MalformedLines)
+			}
+		})
+	}
+}
+
+func TestCapacityUnits(t *testing.T) {
+	const header = "ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed\n"
+	tests := []struct {
+		name        string
+		mem, swp    string
+		wantRecords int
+		wantMem     [2]float64 // tot, free
+		wantSwap    [2]float64
+		wantWarn    string
+	}{
+		{"terabytes", "MEM | tot 1.5T | free 0.5T |", "SWP | tot 0.25T | free 0.125T |", 1, [2]float64{1536, 512}, [2]float64{256, 128}, ""},
+		{"gigabytes", "MEM | tot 16.0G | free 4.0G |", "SWP | tot 2.0G | free 1.5G |", 1, [2]float64{16, 4}, [2]float64{2, 1.5}, ""},
+		{"megabytes", "MEM | tot 2048.0M | free 512.0M |", "SWP | tot 1024.0M | free 256.0M |", 1, [2]float64{2, 0.5}, [2]float64{1, 0.25}, ""},
+		{"kilobytes", "MEM | tot 1048576.0K | free 524288.0K |", "SWP | tot 512.0K | free 256.0K |", 1, [2]float64{1, 0.5}, [2]float64{512.0 / (1024 * 1024), 256.0 / (1024 * 1024)}, ""},
+		{"mixed units", "MEM | tot 1.0T | free 512.0G |", "SWP | tot 1.0G | free 512.0M |", 1, [2]float64{1024, 512}, [2]float64{1, 0.5}, ""},
+		{"unknown mem unit", "MEM | tot 1.0P | free 0.5P |", "SWP | tot 2.0G | free 1.5G |", 0, [2]float64{}, [2]float64{}, `未知的容量单位 "P"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			data, _ := parseTestLog(t, header+tt.mem+"\n"+tt.swp+"\n", ParseOptions{Log: &log})
+			if len(data) != tt.wantRecords {
+				t.Fatalf("解析出 %d 条记录，期望 %d 条", len(data), tt.wantRecords)
+			}
+			if tt.wantWarn != "" {
+				if !strings.Contains(log.String(), tt.wantWarn) {
+					t.Errorf("输出中没有 %q:\n%s", tt.wantWarn, log.String())
+				}
+				return
+			}
+			record := data[0]
+			got := [4]float64{record.MemTotal, record.MemFree, record.SwapTotal, record.SwapFree}
+			want := [4]float64{tt.wantMem[0], tt.wantMem[1], tt.wantSwap[0], tt.wantSwap[1]}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-12 {
+					t.Errorf("换算为 %v，期望 %v", got, want)
+					break
+				}
+			}
+			if strings.Contains(log.String(), "未知的容量单位") {
+				t.Errorf("已知单位不应产生警告:\n%s", log.String())
+			}
+		})
+	}
+}
+
+func TestDateLayouts(t *testing.T) {
+	const block = "MEM | tot 16.0G | free 4.0G |\nSWP | tot 2.0G | free 1.5G |\n"
+	tests := []struct {
+		name       string
+		date       string
+		dateLayout string
+		want       time.Time
+		wantLayout string
+	}{
+		{"atop default", "2024/06/11  10:00:00", "", time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), "2006/01/02 15:04:05"},
+		{"DD-MM-YYYY", "11-06-2024  10:00:00", "", time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), "02-01-2006 15:04:05"},
+		{"DD/MM/YYYY", "11/06/2024  10:00:00", "", time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), "02/01/2006 15:04:05"},
+		{"DD.MM.YYYY", "11.06.2024  10:00:00", "", time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), "02.01.2006 15:04:05"},
+		{"ambiguous day first", "03-04-2024  10:00:00", "", time.Date(2024, 4, 3, 10, 0, 0, 0, time.UTC), "02-01-2006 15:04:05"},
+		{"date layout overrides detection", "03-04-2024  10:00:00", "01-02-2006 15:04:05", time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC), "01-02-2006 15:04:05"},
+		{"localized month", "11 juin 2024  10:00:00", "", time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC), "2 Jan 2006 15:04:05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := "ATOP - web1  " + tt.date + "  --------  10s elapsed\n" + block
+			data, info := parseTestLog(t, log, ParseOptions{DateLayout: tt.dateLayout})
+			if len(data) != 1 {
+				t.Fatalf("解析出 %d 条记录，期望 1 条", len(data))
+			}
+			if !data[0].Timestamp.Equal(tt.want) {
+				t.Errorf("时间戳为 %v，期望 %v", data[0].Timestamp, tt.want)
+			}
+			if !reflect.DeepEqual(info.Dates, map[string]int{tt.wantLayout: 1}) {
+				t.Errorf("匹配的格式为 %v，期望 %s", info.Dates, tt.wantLayout)
+			}
+			var summary bytes.Buffer
+			PrintDetectionSummary(&summary, info)
+			if !strings.Contains(summary.String(), fmt.Sprintf("时间戳格式: %q=1", tt.wantLayout)) {
+				t.Errorf("摘要中没有匹配的格式:\n%s", summary.String())
+			}
+		})
+	}
+
+	// 指定的格式与日志不符时不再自动尝试其他格式
+	data, info := parseTestLog(t, "ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed\n"+block, ParseOptions{DateLayout: "02-01-2006 15:04:05"})
+	if len(data) != 0 || info.UnparsedTimestamps != 1 {
+		t.Errorf("格式不符时解析出 %d 条记录、%d 个无法解析的时间戳，期望 0 和 1", len(data), info.UnparsedTimestamps)
+	}
+}
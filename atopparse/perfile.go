@@ -0,0 +1,54 @@
+package atopparse
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PerFilePrefix 返回单个来源文件报告的输出前缀：<前缀>_<不含扩展名的文件名>，
+// 重名时追加序号
+func PerFilePrefix(outputPrefix, source string, used map[string]bool) string {
+	base := filepath.Base(source)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	prefix := outputPrefix + "_" + base
+	for i := 2; used[prefix]; i++ {
+		prefix = fmt.Sprintf("%s_%s_%d", outputPrefix, base, i)
+	}
+	used[prefix] = true
+	return prefix
+}
+
+// GeneratePerFileReports 为每个来源文件单独生成一份报告，选项与合并报告相同，
+// 重启标注只保留落在该文件时间范围内的时间点。没有来源文件的记录（如--seed-from载入的）不单独输出
+func GeneratePerFileReports(data []MemoryRecord, outputPrefix string, opts ReportOptions) error {
+	groups := GroupBySource(data)
+	sources := make([]string, 0, len(groups))
+	for source := range groups {
+		if source != "" {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+
+	used := make(map[string]bool)
+	for _, source := range sources {
+		records := groups[source]
+		fileOpts := opts
+		// 单独报告的CSV总是写入文件，标准输出只输出合并后的CSV
+		fileOpts.Stdout = false
+		fileOpts.Chart.Reboots = nil
+		for _, reboot := range opts.Chart.Reboots {
+			if !reboot.Before(records[0].Timestamp) && !reboot.After(records[len(records)-1].Timestamp) {
+				fileOpts.Chart.Reboots = append(fileOpts.Chart.Reboots, reboot)
+			}
+		}
+
+		fmt.Fprintf(opts.console(), Tr("生成文件 %s 的单独报告（%d 条记录）\n"), source, len(records))
+		if err := GenerateReport(records, PerFilePrefix(outputPrefix, source, used), fileOpts); err != nil {
+			return fmt.Errorf(Tr("生成文件 %s 的报告时出错: %v"), source, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package atopparse
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+)
+
+// prometheusName 返回指标在Prometheus文本格式中的名称，数值单位与CSV相同为GB，例如 atop_mem_free_gigabytes
+func prometheusName(metric string) string {
+	return "atop_" + metric + "_gigabytes"
+}
+
+// WritePrometheus 以Prometheus文本格式写出记录，供node_exporter的textfile collector读取。
+// 每个主机（RecordHost）一条序列，带有host标签；all为false时每个主机只输出最新的一条记录且不带时间戳，
+// 为true时输出全部记录并带毫秒时间戳，同一主机重复的时间戳只保留第一个样本。
+// 文件先写到临时文件再改名，collector不会读到写了一半的文件
+func WritePrometheus(data []MemoryRecord, path string, all bool, precision int) error {
+	groups := GroupByHost(data)
+	hosts := make([]string, 0, len(groups))
+	for host, records := range groups {
+		hosts = append(hosts, host)
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+		if !all {
+			groups[host] = records[len(records)-1:]
+			continue
+		}
+		kept := records[:1]
+		for _, record := range records[1:] {
+			if record.Timestamp.UnixMilli() > kept[len(kept)-1].Timestamp.UnixMilli() {
+				kept = append(kept, record)
+			}
+		}
+		groups[host] = kept
+	}
+	sort.Strings(hosts)
+
+	out, err := CreateOutput(path, false)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	// 同一指标的所有样本必须连续输出，因此外层按指标循环
+	for _, metric := range MetricNames {
+		name := prometheusName(metric)
+		fmt.Fprintf(w, "# HELP %s %s\n", name, metricHelp[metric])
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+
+		for _, host := range hosts {
+			labels := ""
+			if host != "" {
+				labels = fmt.Sprintf(`{host="%s"}`, escapeLabelValue(host))
+			}
+			for _, record := range groups[host] {
+				value, _ := MetricValue(record, metric)
+				if all {
+					fmt.Fprintf(w, "%s%s %s %d\n", name, labels, FormatValue(value, precision), record.Timestamp.UnixMilli())
+				} else {
+					fmt.Fprintf(w, "%s%s %s\n", name, labels, FormatValue(value, precision))
+				}
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
@@ -0,0 +1,82 @@
+package atopparse
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParsePSIMemory(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantSome float64
+		wantFull float64
+		wantOK   bool
+		wantErr  bool
+	}{
+		{"wide format", "PSI | cpusome    3% | memsome   12% | memfull    4% | iosome     2% | iofull     1% |", 12, 4, true, false},
+		{"narrow format", "PSI | cs  3/2/1 | ms 7.5/5/2 | mf 1.5/1/0 | is  0/0/0 | if  0/0/0 |", 7.5, 1.5, true, false},
+		{"no memory section", "PSI | cpusome    3% | iosome     2% | iofull     1% |", 0, 0, false, false},
+		{"only some", "PSI | memsome   12% | iosome     2% |", 0, 0, false, false},
+		{"malformed percentage", "PSI | memsome 1.2.3% | memfull    4% |", 0, 0, false, true},
+		{"not a PSI line", "MEM | tot 16.0G | free 4.0G |", 0, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			some, full, ok, err := parsePSIMemory(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望出错 %t", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || some != tt.wantSome || full != tt.wantFull {
+				t.Errorf("解析结果为 some=%v full=%v ok=%t，期望 some=%v full=%v ok=%t", some, full, ok, tt.wantSome, tt.wantFull, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParsePSILog(t *testing.T) {
+	const psiLog = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+PSI | cpusome    3% | memsome   12% | memfull    4% | iosome     2% | iofull     1% |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+MEM | tot 16.0G | free 3.0G |
+SWP | tot 2.0G | free 1.0G |
+PSI | cpusome    3% | memsome   20% | memfull    8% | iosome     2% | iofull     1% |
+`
+	tests := []struct {
+		name       string
+		content    string
+		wantBlocks int
+		wantSome   []float64
+	}{
+		{"log with PSI", psiLog, 2, []float64{12, 20}},
+		{"older log without PSI", remoteTestLog, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, info := parseTestLog(t, tt.content, ParseOptions{})
+			if len(data) != 2 {
+				t.Fatalf("解析出 %d 条记录，期望 2 条", len(data))
+			}
+			if info.PSIBlocks != tt.wantBlocks {
+				t.Errorf("含PSI的采样块为 %d，期望 %d", info.PSIBlocks, tt.wantBlocks)
+			}
+			// 没有PSI行时不生成PSI图表
+			psi := psiRecords(data)
+			if len(psi) != len(tt.wantSome) {
+				t.Fatalf("带PSI数据的记录 %d 条，期望 %d 条", len(psi), len(tt.wantSome))
+			}
+			for i, record := range psi {
+				if record.PSIMemSome != tt.wantSome[i] {
+					t.Errorf("第 %d 条记录的mem some为 %v，期望 %v", i, record.PSIMemSome, tt.wantSome[i])
+				}
+			}
+			var summary bytes.Buffer
+			PrintDetectionSummary(&summary, info)
+			if got := bytes.Contains(summary.Bytes(), []byte("含PSI内存压力数据的采样块")); got != (tt.wantBlocks > 0) {
+				t.Errorf("摘要中出现PSI采样块为 %t:\n%s", got, summary.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package atopparse
+
+import (
+	"sort"
+	"time"
+)
+
+// GroupBySource 按来源文件分组，每组按时间排序
+func GroupBySource(data []MemoryRecord) map[string][]MemoryRecord {
+	groups := make(map[string][]MemoryRecord)
+	for _, record := range data {
+		groups[record.Source] = append(groups[record.Source], record)
+	}
+	for _, records := range groups {
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].Timestamp.Before(records[j].Timestamp)
+		})
+	}
+	return groups
+}
+
+// MedianInterval 返回相邻样本间隔的中位数
+func MedianInterval(records []MemoryRecord) time.Duration {
+	if len(records) < 2 {
+		return 0
+	}
+	gaps := make([]time.Duration, 0, len(records)-1)
+	for i := 1; i < len(records); i++ {
+		gaps = append(gaps, records[i].Timestamp.Sub(records[i-1].Timestamp))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+// dedupRecords 合并已排序记录中同一主机时间戳相同的记录，只保留每组的第一条，返回去除的记录数；
+// 不同主机在同一时间点的记录都会保留
+func dedupRecords(data []MemoryRecord) ([]MemoryRecord, int) {
+	deduped := data[:0]
+	var hosts map[string]bool // 当前时间点已保留记录的主机
+	for i, record := range data {
+		if i == 0 || !record.Timestamp.Equal(deduped[len(deduped)-1].Timestamp) {
+			hosts = make(map[string]bool)
+		}
+		host := RecordHost(record)
+		if hosts[host] {
+			continue
+		}
+		hosts[host] = true
+		deduped = append(deduped, record)
+	}
+	return deduped, len(data) - len(deduped)
+}
+
+// FilterRecords 返回满足条件的记录
+func FilterRecords(data []MemoryRecord, keep func(MemoryRecord) bool) []MemoryRecord {
+	var filtered []MemoryRecord
+	for _, record := range data {
+		if keep(record) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
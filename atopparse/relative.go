@@ -0,0 +1,36 @@
+package atopparse
+
+import (
+	"fmt"
+	"time"
+
+	"gonum.org/v1/plot"
+)
+
+// elapsedColumn 是--relative-axis时CSV末尾追加的列，值为距第一个样本的时间
+const elapsedColumn = "elapsed"
+
+// formatElapsed 将经过的时间格式化为 HH:MM:SS，小时数超过99时照常增长
+func formatElapsed(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	seconds := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+}
+
+// elapsedTicks 在默认刻度的位置上，把以小时为单位的X值标注为距开始的 HH:MM:SS
+type elapsedTicks struct{}
+
+// Ticks 实现plot.Ticker
+func (elapsedTicks) Ticks(min, max float64) []plot.Tick {
+	ticks := plot.DefaultTicks{}.Ticks(min, max)
+	for i := range ticks {
+		if ticks[i].Label != "" {
+			ticks[i].Label = formatElapsed(time.Duration(ticks[i].Value * float64(time.Hour)))
+		}
+	}
+	return ticks
+}
@@ -0,0 +1,67 @@
+package atopparse
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// 基本认证使用的环境变量
+const (
+	httpUserEnv     = "ATOP_HTTP_USER"
+	httpPasswordEnv = "ATOP_HTTP_PASSWORD"
+)
+
+// IsRemoteLog 判断日志路径是否为HTTP(S)地址
+func IsRemoteLog(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// gzipBody 在关闭gzip解压流的同时关闭底层的响应体
+type gzipBody struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+// Close 关闭解压流和响应体
+func (g gzipBody) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// openRemoteLog 通过HTTP(S)获取atop日志，响应为gzip编码时自动解压
+func openRemoteLog(url string, timeout time.Duration) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 显式声明接受gzip，由下面根据Content-Encoding自行解压
+	req.Header.Set("Accept-Encoding", "gzip")
+	if user := os.Getenv(httpUserEnv); user != "" {
+		req.SetBasicAuth(user, os.Getenv(httpPasswordEnv))
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf(Tr("获取 %s 失败: HTTP %s"), url, resp.Status)
+	}
+
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf(Tr("解压 %s 的响应失败: %v"), url, err)
+		}
+		return gzipBody{Reader: gz, body: resp.Body}, nil
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,96 @@
+package atopparse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const remoteTestLog = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+MEM | tot 16.0G | free 3.0G |
+SWP | tot 2.0G | free 1.0G |
+`
+
+func TestParseRemoteLog(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(remoteTestLog))
+	gz.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, remoteTestLog)
+	})
+	mux.HandleFunc("/gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	})
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "reader" || password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, remoteTestLog)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		user      string
+		password  string
+		timeout   time.Duration
+		wantCount int
+		wantErr   string
+	}{
+		{name: "plain body", path: "/plain", wantCount: 2},
+		{name: "gzip content encoding", path: "/gzip", wantCount: 2},
+		{name: "basic auth from env", path: "/auth", user: "reader", password: "secret", wantCount: 2},
+		{name: "missing credentials", path: "/auth", wantErr: "401"},
+		{name: "wrong password", path: "/auth", user: "reader", password: "wrong", wantErr: "401"},
+		{name: "not found", path: "/missing", wantErr: "404"},
+		{name: "timeout", path: "/slow", timeout: 50 * time.Millisecond, wantErr: "Timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(httpUserEnv, tt.user)
+			t.Setenv(httpPasswordEnv, tt.password)
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+
+			data, err := ParseLog(server.URL+tt.path, ParseOptions{HTTPTimeout: timeout, Log: io.Discard}, NewDetectionInfo())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误为 %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != tt.wantCount {
+				t.Fatalf("解析出 %d 条记录，期望 %d 条", len(data), tt.wantCount)
+			}
+			if data[1].MemFree != 3 || data[1].SwapFree != 1 {
+				t.Errorf("第二条记录为 %+v", data[1])
+			}
+		})
+	}
+}
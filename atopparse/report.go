@@ -0,0 +1,1062 @@
+package atopparse
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// ChartOptions 控制内存使用图表的附加内容
+type ChartOptions struct {
+	Reboots    []time.Time   // 需要标注的疑似重启时间点
+	MaxFillGap time.Duration // 不超过该长度的数据缺口线性插值，更长的缺口断开折线；为0时不处理
+	MaxGap     float64       // 大于0时超过采样间隔该倍数的缺口断开折线，优先于MaxFillGap
+	// RelativeAxis 为true时X轴标注为距第一个样本的经过时间 HH:MM:SS
+	RelativeAxis bool
+	Smooth       int        // 大于1时图表曲线为Smooth点居中移动平均，CSV仍为原始数据
+	MaxPoints    int        // 大于0时每个图表最多绘制的点数，超过时分桶平均降采样，CSV仍为原始数据
+	Colors       LineColors // 内存/交换空间曲线的颜色，零值使用默认颜色
+	HideMemAvail bool       // 不在PNG和HTML内存图表中绘制估算可用内存曲线
+}
+
+// ReportOptions 控制生成哪些报告文件
+type ReportOptions struct {
+	Chart           ChartOptions
+	HTML            bool            // 生成交互式HTML报告
+	NoPNG           bool            // 不生成PNG内存使用图表
+	TidyCSV         bool            // 额外生成每行一个观测值的长格式CSV
+	Precision       int             // CSV中数值的小数位数
+	Gzip            bool            // CSV输出经gzip压缩并追加.gz后缀
+	OpenMetrics     bool            // 额外生成带时间戳的OpenMetrics文本，用于回填历史数据
+	InteractiveSVG  bool            // 额外生成数据点带悬停提示的SVG图表
+	VegaLite        bool            // 额外生成内联数据的Vega-Lite规范
+	HTMLPageSize    int             // HTML报告每页的样本数，0表示不分页
+	Descending      bool            // CSV按时间倒序输出（图表仍按时间从左到右）
+	Provenance      string          // 写入CSV和HTML页脚的来源说明，为空时不写
+	Histogram       bool            // 生成内存分布直方图
+	HistogramMetric string          // 直方图统计的指标: free或used
+	HistogramBins   int             // 直方图分桶数
+	Derived         []DerivedSeries // --derive定义的派生指标，追加为CSV列并单独绘图
+	Fold            string          // 按daily或weekly叠加各周期的已用内存曲线，为空时不生成
+	Stdout          bool            // CSV写到标准输出而不是<前缀>.csv
+	Format          string          // 主输出格式: csv（默认，为空时也按csv）或json
+	HTMLUsagePct    bool            // HTML报告中附加内存/交换空间使用率图表
+	HTMLOffline     bool            // HTML报告内联内嵌的Chart.js，不从CDN加载
+	HTMLAnomalyP    float64         // HTML报告中标出已用内存高于该百分位数的数据点，0表示不标出
+	Markdown        bool            // 生成包含时间范围、统计摘要和空闲内存最低时刻的Markdown报告
+	Columns         []string        // 主CSV输出的列及其顺序，为空时输出全部列
+	Delimiter       rune            // CSV（含长格式和磁盘CSV）的分隔符，0表示逗号
+	ChartFormats    []string        // 内存使用图表的格式（png、svg、pdf），每种格式一个文件，为空时只生成png
+	CompareMetric   string          // 时段对比叠加的指标（CompareMetricNames之一），为空时为已用内存
+	ThrashWeight    float64         // 换页抖动分数中单向换页的权重（0到1），0时只计同时换入和换出的部分
+	CPUHeatmap      bool            // 日志中有单核cpu行时生成各核心利用率的热力图
+	Log             io.Writer       // 进度信息和统计摘要的输出位置，为nil时写到标准输出
+}
+
+// console 返回生成报告时进度信息和统计摘要的输出位置
+func (opts ReportOptions) console() io.Writer {
+	return logOutput(opts.Log)
+}
+
+// GenerateReport 生成内存使用报告和图表
+func GenerateReport(data []MemoryRecord, outputPrefix string, opts ReportOptions) error {
+	if len(data) == 0 {
+		fmt.Fprintln(opts.console(), Tr("没有找到有效数据"))
+		return nil
+	}
+
+	// CSV行顺序，图表始终按时间正序绘制
+	rows := data
+	if opts.Descending {
+		rows = reversedRecords(data)
+	}
+
+	// 保存主输出：宽格式CSV，--format json时改为JSON
+	switch {
+	case opts.Format == "json" && opts.Stdout:
+		if err := writeJSONFile(rows, StdoutPath, opts); err != nil {
+			return err
+		}
+		fmt.Fprintln(opts.console(), Tr("已将JSON写入标准输出"))
+	case opts.Format == "json":
+		jsonFile := OutputName(outputPrefix+".json", opts.Gzip)
+		if err := writeJSONFile(rows, jsonFile, opts); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存JSON文件: %s\n"), jsonFile)
+	case opts.Stdout:
+		if err := writeCSV(rows, StdoutPath, opts); err != nil {
+			return err
+		}
+		fmt.Fprintln(opts.console(), Tr("已将CSV写入标准输出"))
+	default:
+		csvFile := OutputName(outputPrefix+".csv", opts.Gzip)
+		if err := writeCSV(rows, csvFile, opts); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存CSV文件: %s\n"), csvFile)
+	}
+
+	// 保存长格式CSV
+	if opts.TidyCSV {
+		tidyFile := OutputName(outputPrefix+"_tidy.csv", opts.Gzip)
+		if err := writeTidyCSV(rows, tidyFile, opts); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存长格式CSV文件: %s\n"), tidyFile)
+	}
+
+	// 保存OpenMetrics文本
+	if opts.OpenMetrics {
+		omFile := outputPrefix + "_openmetrics.txt"
+		skipped, err := writeOpenMetrics(data, omFile, opts.Precision)
+		if err != nil {
+			return err
+		}
+		if skipped > 0 {
+			fmt.Fprintf(opts.console(), Tr("警告: OpenMetrics 输出中跳过了 %d 个同一主机内时间戳重复的样本\n"), skipped)
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存OpenMetrics文件: %s\n"), omFile)
+	}
+
+	// 绘制内存使用图表，每种格式一个文件
+	if !opts.NoPNG {
+		for _, format := range MemoryChartFormats(opts) {
+			memChartFile := outputPrefix + "_memory_swap." + format
+			if err := saveChart(data, memChartFile, opts.Chart); err != nil {
+				return err
+			}
+			fmt.Fprintf(opts.console(), Tr("已保存内存使用图表: %s\n"), memChartFile)
+		}
+	}
+
+	// 生成不依赖JavaScript的交互式SVG
+	if opts.InteractiveSVG {
+		svgFile := outputPrefix + "_memory_swap.svg"
+		if err := generateInteractiveSVG(data, svgFile, opts.Chart.Colors); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存交互式SVG图表: %s\n"), svgFile)
+	}
+
+	// 生成Vega-Lite规范
+	if opts.VegaLite {
+		vegaFile := outputPrefix + "_memory_swap.vl.json"
+		if err := generateVegaLite(data, vegaFile, opts.Chart.Colors); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存Vega-Lite规范: %s\n"), vegaFile)
+	}
+
+	// 日志中有PSI数据时绘制内存压力图表
+	if psi := psiRecords(data); len(psi) > 0 && !opts.NoPNG {
+		psiChartFile := outputPrefix + "_psi.png"
+		if err := generatePSIChart(downsampleRecords(psi, opts.Chart.MaxPoints), psiChartFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存内存压力(PSI)图表: %s\n"), psiChartFile)
+	}
+
+	// 日志中有PAG行时绘制换入/换出速率图表和换页抖动分数图表
+	if pag := pagRecords(data); len(pag) > 0 && !opts.NoPNG {
+		swapRateFile := outputPrefix + "_swap_rate.png"
+		if err := generateSwapRateChart(pag, swapRateFile, opts.Chart.MaxPoints); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存换入/换出速率图表: %s\n"), swapRateFile)
+
+		thrashFile := outputPrefix + "_thrash.png"
+		if err := generateThrashChart(pag, thrashFile, opts.ThrashWeight, opts.Chart.MaxPoints); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存换页抖动分数图表: %s\n"), thrashFile)
+	}
+
+	// 日志中有DSK行时保存各磁盘设备的统计并绘制忙碌百分比和吞吐量图表；--output - 时没有文件前缀，不写磁盘统计CSV
+	if disks := DiskRecords(data); len(disks) > 0 {
+		if outputPrefix != StdoutPath {
+			diskFile := OutputName(outputPrefix+"_disk.csv", opts.Gzip)
+			if err := writeDiskCSV(disks, diskFile, opts); err != nil {
+				return err
+			}
+			fmt.Fprintf(opts.console(), Tr("已保存磁盘统计CSV文件: %s\n"), diskFile)
+		}
+
+		if !opts.NoPNG {
+			diskChartFile := outputPrefix + "_disk_busy.png"
+			if err := generateDiskBusyChart(disks, diskChartFile); err != nil {
+				return err
+			}
+			fmt.Fprintf(opts.console(), Tr("已保存磁盘忙碌图表: %s\n"), diskChartFile)
+
+			if throughput := throughputDisks(disks); len(throughput) > 0 {
+				throughputFile := outputPrefix + "_disk_throughput.png"
+				if err := generateDiskThroughputChart(throughput, throughputFile); err != nil {
+					return err
+				}
+				fmt.Fprintf(opts.console(), Tr("已保存磁盘吞吐量图表: %s\n"), throughputFile)
+			}
+		}
+	}
+
+	// 日志中有NET接口行时保存各接口的统计并绘制收/发速率图表；--output - 时没有文件前缀，不写网络统计CSV
+	if nets := NetRecords(data); len(nets) > 0 {
+		if outputPrefix != StdoutPath {
+			netFile := OutputName(outputPrefix+"_net.csv", opts.Gzip)
+			if err := writeNetCSV(nets, netFile, opts); err != nil {
+				return err
+			}
+			fmt.Fprintf(opts.console(), Tr("已保存网络统计CSV文件: %s\n"), netFile)
+		}
+
+		if !opts.NoPNG {
+			netChartFile := outputPrefix + "_net.png"
+			if err := generateNetChart(nets, netChartFile); err != nil {
+				return err
+			}
+			fmt.Fprintf(opts.console(), Tr("已保存网络速率图表: %s\n"), netChartFile)
+		}
+	}
+
+	// 日志中有CPU汇总行时绘制CPU使用率图表
+	if cpu := cpuRecords(data); len(cpu) > 0 && !opts.NoPNG {
+		cpuChartFile := outputPrefix + "_cpu.png"
+		if err := generateCPUChart(downsampleRecords(cpu, opts.Chart.MaxPoints), cpuChartFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存CPU使用率图表: %s\n"), cpuChartFile)
+	}
+
+	// 各核心利用率热力图，需要日志中有单核cpu行（atop的输出要包含cpu标签）
+	if opts.CPUHeatmap && !opts.NoPNG {
+		if HasCores(data) {
+			heatmapFile := outputPrefix + "_cpu_heatmap.png"
+			if err := generateCPUHeatmap(data, heatmapFile); err != nil {
+				return err
+			}
+			fmt.Fprintf(opts.console(), Tr("已保存CPU核心热力图: %s\n"), heatmapFile)
+		} else {
+			fmt.Fprintln(opts.console(), Tr("警告: 日志中没有单核cpu行，不生成CPU核心热力图"))
+		}
+	}
+
+	// 内存和交换空间使用率，Y轴为0-100%
+	if !opts.NoPNG {
+		usagePctFile := outputPrefix + "_usage_pct.png"
+		if err := generateUsagePctChart(data, usagePctFile, opts.Chart); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存使用率图表: %s\n"), usagePctFile)
+	}
+
+	// 派生指标的量纲与内存曲线不同，单独绘制
+	if len(opts.Derived) > 0 && !opts.NoPNG {
+		derivedChartFile := outputPrefix + "_derived.png"
+		if err := generateDerivedChart(downsampleRecords(data, opts.Chart.MaxPoints), opts.Derived, derivedChartFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存派生指标图表: %s\n"), derivedChartFile)
+	}
+
+	// 按天或按周折叠，叠加各周期的曲线以便比较周期性规律
+	if opts.Fold != "" {
+		foldFile := outputPrefix + "_fold_" + opts.Fold + ".png"
+		if err := generateFoldChart(data, opts.Fold, foldFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存周期叠加图表: %s\n"), foldFile)
+	}
+
+	// 如果指定了HTML，则生成交互式HTML报告
+	if opts.HTML {
+		htmlFiles, err := generateHTMLPages(data, outputPrefix, opts)
+		if err != nil {
+			return err
+		}
+		if len(htmlFiles) == 1 {
+			fmt.Fprintf(opts.console(), Tr("已保存交互式HTML报告: %s\n"), htmlFiles[0])
+		} else {
+			fmt.Fprintf(opts.console(), Tr("已保存交互式HTML报告: %s 等 %d 页\n"), htmlFiles[0], len(htmlFiles))
+		}
+	}
+
+	// 生成内存分布直方图
+	if opts.Histogram {
+		histFile := outputPrefix + "_histogram.png"
+		if err := generateHistogram(opts.console(), data, histFile, opts.HistogramMetric, opts.HistogramBins); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存内存分布直方图: %s\n"), histFile)
+	}
+
+	// 输出已用内存和交换空间的统计摘要；写到标准输出的模式下只打印，不写文件
+	stats := computeStats(data)
+	stats.Thrash, stats.ThrashWeight = worstThrashWindows(pagRecords(data), opts.ThrashWeight, maxThrashWindows), opts.ThrashWeight
+	fmt.Fprint(opts.console(), formatStats(stats, opts.Precision))
+	if !opts.Stdout {
+		summaryFile := outputPrefix + "_summary.txt"
+		if err := writeStatsSummary(stats, summaryFile, opts.Precision); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存统计摘要: %s\n"), summaryFile)
+	}
+
+	// Markdown报告复用上面的统计结果，优先嵌入PNG图表，其次SVG；PDF无法作为图片嵌入
+	if opts.Markdown {
+		markdownFile := outputPrefix + ".md"
+		chartFile := ""
+		if !opts.NoPNG {
+			for _, format := range []string{"png", "svg"} {
+				if slices.Contains(MemoryChartFormats(opts), format) {
+					chartFile = outputPrefix + "_memory_swap." + format
+					break
+				}
+			}
+		}
+		if err := writeMarkdownReport(data, stats, markdownFile, chartFile, opts.Precision); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.console(), Tr("已保存Markdown报告: %s\n"), markdownFile)
+	}
+
+	return nil
+}
+
+// writeCSV 以csvHeader的宽格式写出所有记录，RelativeAxis时在末尾追加elapsed列
+func writeCSV(data []MemoryRecord, outputFile string, opts ReportOptions) error {
+	out, err := CreateOutput(outputFile, opts.Gzip)
+	if err != nil {
+		return err
+	}
+
+	var start time.Time
+	if opts.Chart.RelativeAxis {
+		// data可能是倒序的，经过时间从最早的样本算起
+		start = data[0].Timestamp
+		for _, record := range data {
+			if record.Timestamp.Before(start) {
+				start = record.Timestamp
+			}
+		}
+	}
+
+	rows, err := newCSVRowWriter(out, opts, hasCPU(data), start)
+	if err != nil {
+		out.Abort()
+		return err
+	}
+	for _, record := range data {
+		if err := rows.write(record); err != nil {
+			out.Abort()
+			return err
+		}
+	}
+
+	if err := writeCSVFooter(out, rows.writer, opts.Provenance); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// csvRowWriter 以csvHeader的宽格式逐条写出记录，供writeCSV和流式写出共用
+type csvRowWriter struct {
+	writer  *csv.Writer
+	opts    ReportOptions
+	withCPU bool      // 是否输出CPU列，没有CPU数据的记录留空
+	start   time.Time // RelativeAxis时elapsed列的起点，为零时取第一条写出的记录
+	columns []int     // opts.Columns对应的完整行中的位置，为nil时输出全部列
+}
+
+// CSVColumns 返回按opts可以输出到主CSV的全部列：基本列、使用率列、CPU列（含cpu_wait）、host列、派生指标和elapsed列（RelativeAxis时）
+func CSVColumns(opts ReportOptions) []string {
+	columns := append(csvHeader[:len(csvHeader):len(csvHeader)], usagePctColumns...)
+	columns = append(columns, memAvailColumn)
+	columns = append(columns, cpuColumns...)
+	columns = append(columns, cpuWaitColumn, hostColumn)
+	for _, series := range opts.Derived {
+		columns = append(columns, series.Name)
+	}
+	if opts.Chart.RelativeAxis {
+		columns = append(columns, elapsedColumn)
+	}
+	return columns
+}
+
+// ValidateCSVColumns 检查opts.Columns中的列名都是CSVColumns中的列且没有重复
+func ValidateCSVColumns(opts ReportOptions) error {
+	valid := make(map[string]bool)
+	for _, column := range CSVColumns(opts) {
+		valid[column] = true
+	}
+	seen := make(map[string]bool)
+	for _, column := range opts.Columns {
+		if !valid[column] {
+			return fmt.Errorf(Tr("未知的CSV列 %q，可选: %s"), column, strings.Join(CSVColumns(opts), ","))
+		}
+		if seen[column] {
+			return fmt.Errorf(Tr("CSV列 %q 重复"), column)
+		}
+		seen[column] = true
+	}
+	return nil
+}
+
+// ParseCSVDelimiter 将命令行中的分隔符转换为CSV分隔符，\t 表示制表符；
+// 分隔符必须是单个字符，且不能是双引号或换行
+func ParseCSVDelimiter(value string) (rune, error) {
+	if value == `\t` {
+		return '\t', nil
+	}
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' || runes[0] == utf8.RuneError {
+		return 0, fmt.Errorf(Tr("分隔符必须是单个字符（不能是双引号或换行），制表符写作 \\t"))
+	}
+	return runes[0], nil
+}
+
+// newCSVWriter 返回使用opts.Delimiter作为分隔符的csv.Writer
+func newCSVWriter(w io.Writer, opts ReportOptions) *csv.Writer {
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	return writer
+}
+
+// newCSVRowWriter 写出表头并返回逐行写出记录的writer。指定了opts.Columns时只按该顺序输出这些列，
+// 此时总是生成CPU列以便选择，没有CPU数据的记录留空
+func newCSVRowWriter(w io.Writer, opts ReportOptions, withCPU bool, start time.Time) (*csvRowWriter, error) {
+	if len(opts.Columns) > 0 {
+		withCPU = true
+	}
+	header := append(csvHeader[:len(csvHeader):len(csvHeader)], usagePctColumns...)
+	header = append(header, memAvailColumn)
+	if withCPU {
+		header = append(header[:len(header):len(header)], cpuColumns...)
+		header = append(header, cpuWaitColumn)
+	}
+	header = append(header[:len(header):len(header)], hostColumn)
+	for _, series := range opts.Derived {
+		header = append(header[:len(header):len(header)], series.Name)
+	}
+	if opts.Chart.RelativeAxis {
+		header = append(header[:len(header):len(header)], elapsedColumn)
+	}
+
+	rows := &csvRowWriter{writer: newCSVWriter(w, opts), opts: opts, withCPU: withCPU, start: start}
+	if len(opts.Columns) > 0 {
+		if err := ValidateCSVColumns(opts); err != nil {
+			return nil, err
+		}
+		position := make(map[string]int, len(header))
+		for i, column := range header {
+			position[column] = i
+		}
+		for _, column := range opts.Columns {
+			rows.columns = append(rows.columns, position[column])
+		}
+		header = rows.selected(header)
+	}
+	if err := rows.writer.Write(header); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// selected 按columns从完整的一行中取出选择的列
+func (c *csvRowWriter) selected(row []string) []string {
+	if c.columns == nil {
+		return row
+	}
+	picked := make([]string, len(c.columns))
+	for i, position := range c.columns {
+		picked[i] = row[position]
+	}
+	return picked
+}
+
+// write 写出一条记录
+func (c *csvRowWriter) write(record MemoryRecord) error {
+	precision := c.opts.Precision
+	row := []string{
+		record.Timestamp.Format("2006-01-02 15:04:05"),
+		FormatValue(record.MemTotal, precision),
+		FormatValue(record.MemFree, precision),
+		FormatValue(record.SwapTotal, precision),
+		FormatValue(record.SwapFree, precision),
+		FormatValue(record.MemCache, precision),
+		FormatValue(record.MemBuff, precision),
+		FormatValue(record.MemSlabRec, precision),
+	}
+	row = append(row, usagePctValues(record, precision)...)
+	row = append(row, FormatValue(record.MemAvailableEst(), precision))
+	if c.withCPU {
+		row = append(row, cpuValues(record, precision)...)
+	}
+	row = append(row, RecordHost(record))
+	row = append(row, derivedValues(c.opts.Derived, record, precision)...)
+	if c.opts.Chart.RelativeAxis {
+		if c.start.IsZero() {
+			c.start = record.Timestamp
+		}
+		row = append(row, formatElapsed(record.Timestamp.Sub(c.start)))
+	}
+	return c.writer.Write(c.selected(row))
+}
+
+// writeTidyCSV 以 timestamp,metric,device,value 的长格式写出所有观测值，
+// metric使用与宽格式CSV相同的列名，device对系统级指标留空
+func writeTidyCSV(data []MemoryRecord, outputFile string, opts ReportOptions) error {
+	out, err := CreateOutput(outputFile, opts.Gzip)
+	if err != nil {
+		return err
+	}
+
+	writer := newCSVWriter(out, opts)
+	if err := writer.Write([]string{"timestamp", "metric", "device", "value"}); err != nil {
+		out.Abort()
+		return err
+	}
+
+	for _, record := range data {
+		timestamp := record.Timestamp.Format("2006-01-02 15:04:05")
+		for _, name := range MetricNames {
+			value, _ := MetricValue(record, name)
+			if err := writer.Write([]string{timestamp, name, "", FormatValue(value, opts.Precision)}); err != nil {
+				out.Abort()
+				return err
+			}
+		}
+	}
+
+	if err := writeCSVFooter(out, writer, opts.Provenance); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// writeCSVFooter 刷新CSV缓冲区，并在文件末尾以#注释行写入来源说明
+func writeCSVFooter(file io.Writer, writer *csv.Writer, provenance string) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if provenance == "" {
+		return nil
+	}
+	// 多行的来源说明（例如附加了输入校验和）每行都写成注释
+	for _, line := range strings.Split(provenance, "\n") {
+		if _, err := fmt.Fprintf(file, "# %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// swapDisabled 判断交换空间是否在整个时间段内都未启用（总量始终为0）
+func swapDisabled(data []MemoryRecord) bool {
+	for _, record := range data {
+		if record.SwapTotal != 0 {
+			return false
+		}
+	}
+	return len(data) > 0
+}
+
+// RenderChart 按默认图表选项将内存/交换空间使用图表以format格式（png、svg或pdf）写入w，
+// 供需要在内存中取得图片的库使用者调用，例如作为邮件附件
+func RenderChart(data []MemoryRecord, w io.Writer, format string) error {
+	return renderChart(data, w, format, ChartOptions{})
+}
+
+// renderChart 将内存/交换空间使用图表按指定格式（png、svg、pdf等）写入w
+func renderChart(data []MemoryRecord, w io.Writer, format string, opts ChartOptions) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的数据"))
+	}
+
+	p := plot.New()
+
+	p.Title.Text = "Memory/Swap Usage Over Time"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Size (GB)"
+	p.X.Tick.Marker = timeAxis()
+
+	// 横坐标默认为实际时间；RelativeAxis时为距第一个样本的小时数，标注为经过时间
+	baseTime := data[0].Timestamp
+	x := func(t time.Time) float64 { return timeAxisX(t) }
+	if opts.RelativeAxis {
+		p.X.Label.Text = "Elapsed (HH:MM:SS)"
+		p.X.Tick.Marker = elapsedTicks{}
+		x = func(t time.Time) float64 { return t.Sub(baseTime).Hours() }
+	}
+
+	// 按缺口切分后分段绘制，长缺口处折线断开
+	segments := plotSegments(data, opts)
+	colors := opts.Colors.withDefaults(defaultLineColors)
+	series := []struct {
+		label string
+		color color.RGBA
+		value func(MemoryRecord) float64
+	}{
+		{"MEM Total (GB)", colors.MemTotal, func(r MemoryRecord) float64 { return r.MemTotal }},
+		{"MEM Free (GB)", colors.MemFree, func(r MemoryRecord) float64 { return r.MemFree }},
+		{"MEM Cache (GB)", colors.MemCache, func(r MemoryRecord) float64 { return r.MemCache }},
+		{"MEM Buffers (GB)", colors.MemBuff, func(r MemoryRecord) float64 { return r.MemBuff }},
+	}
+	// 估算可用内存（free + cache + buff + slrec）默认绘制，比空闲内存更接近实际还能使用的内存
+	if !opts.HideMemAvail {
+		series = append(series, struct {
+			label string
+			color color.RGBA
+			value func(MemoryRecord) float64
+		}{"MEM Available est. (GB)", memAvailColor, MemoryRecord.MemAvailableEst})
+	}
+
+	// 未启用交换空间时省略两条恒为0的交换空间曲线
+	if swapDisabled(data) {
+		p.Title.Text += " (swap disabled)"
+	} else {
+		series = append(series, []struct {
+			label string
+			color color.RGBA
+			value func(MemoryRecord) float64
+		}{
+			{"SWAP Total (GB)", colors.SwapTotal, func(r MemoryRecord) float64 { return r.SwapTotal }},
+			{"SWAP Free (GB)", colors.SwapFree, func(r MemoryRecord) float64 { return r.SwapFree }},
+		}...)
+	}
+
+	for _, s := range series {
+		for i, segment := range segments {
+			points := make(plotter.XYs, len(segment))
+			for j, record := range segment {
+				points[j].X = x(record.Timestamp)
+				points[j].Y = s.value(record)
+			}
+
+			line, err := plotter.NewLine(points)
+			if err != nil {
+				return err
+			}
+			line.Color = s.color
+			p.Add(line)
+			if i == 0 {
+				p.Legend.Add(s.label+smoothLabel(opts.Smooth), line)
+			}
+		}
+	}
+
+	// 标注疑似重启
+	if len(opts.Reboots) > 0 {
+		yMax := 0.0
+		for _, record := range data {
+			yMax = math.Max(yMax, math.Max(record.MemTotal, record.SwapTotal))
+		}
+		if err := addRebootMarks(p, opts.Reboots, x, yMax); err != nil {
+			return err
+		}
+	}
+
+	writerTo, err := p.WriterTo(8*vg.Inch, 4*vg.Inch, format)
+	if err != nil {
+		return err
+	}
+	_, err = writerTo.WriteTo(w)
+	return err
+}
+
+// saveChart 将图表保存到文件，图片格式由文件扩展名决定
+func saveChart(data []MemoryRecord, chartFile string, opts ChartOptions) error {
+	file, err := os.Create(chartFile)
+	if err != nil {
+		return err
+	}
+
+	format := strings.TrimPrefix(filepath.Ext(chartFile), ".")
+	if err := renderChart(data, file, format, opts); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// chartFormats --chart-format 支持的内存使用图表格式
+var chartFormats = []string{"png", "svg", "pdf"}
+
+// ParseChartFormats 解析逗号分隔的图表格式列表，去掉重复项；不支持的格式返回错误
+func ParseChartFormats(value string) ([]string, error) {
+	var formats []string
+	for _, format := range strings.Split(value, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !slices.Contains(chartFormats, format) {
+			return nil, fmt.Errorf(Tr("不支持的图表格式 %q，可选: %s"), format, strings.Join(chartFormats, ", "))
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats, nil
+}
+
+// MemoryChartFormats 返回按opts生成的内存使用图表格式，未指定时为png
+func MemoryChartFormats(opts ReportOptions) []string {
+	if len(opts.ChartFormats) == 0 {
+		return []string{"png"}
+	}
+	return opts.ChartFormats
+}
+
+// WriteHTMLReport 将不分页的交互式HTML报告写到w，供HTTP服务等不写文件的场合使用
+func WriteHTMLReport(w io.Writer, data []MemoryRecord, opts ReportOptions) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有找到有效数据"))
+	}
+	return writeHTMLReport(w, data, opts, "", usedMemoryPercentile(data, opts.HTMLAnomalyP))
+}
+
+// generateHTMLReport 将交互式HTML报告写入文件，参数同writeHTMLReport
+func generateHTMLReport(data []MemoryRecord, outputFile string, opts ReportOptions, nav string, anomalyThreshold float64) error {
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	if err := writeHTMLReport(file, data, opts, nav, anomalyThreshold); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// htmlReportData 交互式HTML报告模板的数据。各序列为已编码的JSON数组，以template.JS原样写入脚本，
+// 避免数值数组被当作字符串转义
+type htmlReportData struct {
+	ChartJS     template.HTML // 引用或内联Chart.js的<script>标签
+	Nav         template.HTML // 分页导航，不分页时为空
+	SwapNote    string        // 未启用交换空间的说明，为空时不显示
+	AnomalyNote string        // 异常点的说明，为空时不显示
+
+	Timestamps template.JS
+	MemTotal   template.JS
+	MemFree    template.JS
+	MemCache   template.JS
+	MemBuff    template.JS
+	MemAvail   template.JS // 估算可用内存，ShowMemAvail为false时不输出
+	SwpTotal   template.JS
+	SwpFree    template.JS
+	Anomalies  template.JS // 与数据点一一对应的异常标记
+
+	Colors htmlLineColors // 各曲线的CSS颜色
+
+	SwapDisabled  bool          // 为true时省略交换空间曲线及其数据
+	ShowMemAvail  bool          // 与PNG内存图表一致，默认绘制估算可用内存曲线
+	MemAvailColor string        // 估算可用内存曲线的CSS颜色，与PNG相同，不随 --palette 变化
+	LabelSuffix   string        // 图例后缀，例如平滑说明
+	UsagePct      *htmlUsagePct // 为nil时不附加使用率图表
+	Provenance    []string      // 来源说明的各行，为空时不显示页脚
+}
+
+// htmlReportTemplate 交互式HTML报告的模板，数据为htmlReportData
+var htmlReportTemplate = template.Must(template.New("report").Parse(`
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Memory/Swap Usage Over Time</title>
+    {{.ChartJS}}
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; }
+        .chart-container { width: 80%; margin: 0 auto; }
+        .provenance { color: #888; font-size: 12px; margin-top: 20px; }
+        .note { color: #555; }
+        .nav { font-size: 14px; }
+    </style>
+</head>
+<body>
+    <h1>Memory/Swap Usage Over Time (Interactive)</h1>
+    {{.Nav}}{{with .SwapNote}}<p class="note">{{.}}</p>{{end}}{{with .AnomalyNote}}<p class="note">{{.}}</p>{{end}}
+    <div class="chart-container">
+        <canvas id="memoryChart"></canvas>
+    </div>
+    <script>
+        const timestamps = {{.Timestamps}};
+        const memTotal = {{.MemTotal}};
+        const memFree = {{.MemFree}};
+        const memCache = {{.MemCache}};
+        const memBuff = {{.MemBuff}};
+        {{- if .ShowMemAvail}}
+        const memAvail = {{.MemAvail}};
+        {{- end}}
+        {{- if not .SwapDisabled}}
+        const swpTotal = {{.SwpTotal}};
+        const swpFree = {{.SwpFree}};
+        {{- end}}
+        const labelSuffix = {{.LabelSuffix}};
+        const anomalies = {{.Anomalies}};
+
+        const ctx = document.getElementById('memoryChart').getContext('2d');
+        const chart = new Chart(ctx, {
+            type: 'line',
+            data: {
+                labels: timestamps,
+                datasets: [
+                    {
+                        label: 'MEM Total (GB)' + labelSuffix,
+                        data: memTotal,
+                        borderColor: {{.Colors.MemTotal}},
+                        fill: false,
+                        tension: 0.1
+                    },
+                    {
+                        label: 'MEM Free (GB)' + labelSuffix,
+                        data: memFree,
+                        borderColor: {{.Colors.MemFree}},
+                        pointBackgroundColor: ctx => anomalies[ctx.dataIndex] ? 'rgb(220, 0, 120)' : 'rgba(0, 0, 0, 0.1)',
+                        pointRadius: ctx => anomalies[ctx.dataIndex] ? 6 : 3,
+                        fill: false,
+                        tension: 0.1
+                    },
+                    {
+                        label: 'MEM Cache (GB)' + labelSuffix,
+                        data: memCache,
+                        borderColor: {{.Colors.MemCache}},
+                        fill: false,
+                        tension: 0.1
+                    },
+                    {
+                        label: 'MEM Buffers (GB)' + labelSuffix,
+                        data: memBuff,
+                        borderColor: {{.Colors.MemBuff}},
+                        fill: false,
+                        tension: 0.1
+                    }{{if .ShowMemAvail}},
+                    {
+                        label: 'MEM Available est. (GB)' + labelSuffix,
+                        data: memAvail,
+                        borderColor: {{.MemAvailColor}},
+                        fill: false,
+                        tension: 0.1
+                    }{{end}}{{if not .SwapDisabled}},
+                    {
+                        label: 'SWAP Total (GB)' + labelSuffix,
+                        data: swpTotal,
+                        borderColor: {{.Colors.SwapTotal}},
+                        fill: false,
+                        tension: 0.1
+                    },
+                    {
+                        label: 'SWAP Free (GB)' + labelSuffix,
+                        data: swpFree,
+                        borderColor: {{.Colors.SwapFree}},
+                        fill: false,
+                        tension: 0.1
+                    }{{end}}
+                ]
+            },
+            options: {
+                responsive: true,
+                plugins: {
+                    title: {
+                        display: true,
+                        text: 'Memory/Swap Usage Over Time'
+                    },
+                    tooltip: {
+                        mode: 'index',
+                        intersect: false,
+                    }
+                },
+                scales: {
+                    x: {
+                        title: {
+                            display: true,
+                            text: 'Time'
+                        }
+                    },
+                    y: {
+                        title: {
+                            display: true,
+                            text: 'Size (GB)'
+                        }
+                    }
+                }
+            }
+        });
+    </script>
+    {{- with .UsagePct}}
+    <div class="chart-container">
+        <canvas id="usagePctChart"></canvas>
+    </div>
+    <script>
+        new Chart(document.getElementById('usagePctChart').getContext('2d'), {
+            type: 'line',
+            data: {
+                labels: timestamps,
+                datasets: [
+                    {
+                        label: 'MEM Used (%)' + labelSuffix,
+                        data: {{.MemPct}},
+                        borderColor: 'rgb(255, 0, 0)',
+                        fill: false,
+                        tension: 0.1
+                    }{{if not $.SwapDisabled}},
+                    {
+                        label: 'SWAP Used (%)' + labelSuffix,
+                        data: {{.SwpPct}},
+                        borderColor: 'rgb(0, 0, 255)',
+                        fill: false,
+                        tension: 0.1
+                    }{{end}}
+                ]
+            },
+            options: {
+                responsive: true,
+                plugins: {
+                    title: {
+                        display: true,
+                        text: 'Memory/Swap Usage (%)'
+                    },
+                    tooltip: {
+                        mode: 'index',
+                        intersect: false,
+                    }
+                },
+                scales: {
+                    x: {
+                        title: {
+                            display: true,
+                            text: 'Time'
+                        }
+                    },
+                    y: {
+                        min: 0,
+                        max: 100,
+                        title: {
+                            display: true,
+                            text: 'Used (%)'
+                        }
+                    }
+                }
+            }
+        });
+    </script>
+    {{- end}}
+    {{with .Provenance}}<p class="provenance">{{range $i, $line := .}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>{{end}}
+</body>
+</html>
+`))
+
+// jsonJS 将v编码为JSON，用于在模板的脚本中原样写入
+func jsonJS(v interface{}) template.JS {
+	encoded, _ := json.Marshal(v)
+	return template.JS(encoded)
+}
+
+// writeHTMLReport 将交互式HTML报告写到w，nav不为空时作为分页导航显示在标题下方。
+// HTMLAnomalyP大于0时，已用内存高于anomalyThreshold的数据点以醒目的颜色和尺寸标出
+func writeHTMLReport(w io.Writer, data []MemoryRecord, opts ReportOptions, nav string, anomalyThreshold float64) error {
+	// 准备数据，长缺口处插入空值使Chart.js断开折线
+	segments := plotSegments(data, opts.Chart)
+	var timestamps []string
+	var memTotal, memFree, memCache, memBuff, memAvail, swpTotal, swpFree []*float64
+	value := func(v float64) *float64 { return &v }
+
+	for i, segment := range segments {
+		if i > 0 {
+			timestamps = append(timestamps, "")
+			memTotal = append(memTotal, nil)
+			memFree = append(memFree, nil)
+			memCache = append(memCache, nil)
+			memBuff = append(memBuff, nil)
+			memAvail = append(memAvail, nil)
+			swpTotal = append(swpTotal, nil)
+			swpFree = append(swpFree, nil)
+		}
+		for _, record := range segment {
+			timestamps = append(timestamps, record.Timestamp.Format("2006-01-02 15:04:05"))
+			memTotal = append(memTotal, value(record.MemTotal))
+			memFree = append(memFree, value(record.MemFree))
+			memCache = append(memCache, value(record.MemCache))
+			memBuff = append(memBuff, value(record.MemBuff))
+			memAvail = append(memAvail, value(record.MemAvailableEst()))
+			swpTotal = append(swpTotal, value(record.SwapTotal))
+			swpFree = append(swpFree, value(record.SwapFree))
+		}
+	}
+
+	report := htmlReportData{
+		ChartJS:       template.HTML(chartJSTag(opts.HTMLOffline)),
+		Nav:           template.HTML(nav),
+		Timestamps:    jsonJS(timestamps),
+		MemTotal:      jsonJS(memTotal),
+		MemFree:       jsonJS(memFree),
+		MemCache:      jsonJS(memCache),
+		MemBuff:       jsonJS(memBuff),
+		MemAvail:      jsonJS(memAvail),
+		SwpTotal:      jsonJS(swpTotal),
+		SwpFree:       jsonJS(swpFree),
+		Anomalies:     jsonJS([]bool{}),
+		Colors:        newHTMLLineColors(opts.Chart.Colors.withDefaults(defaultLineColors)),
+		SwapDisabled:  swapDisabled(data),
+		ShowMemAvail:  !opts.Chart.HideMemAvail,
+		MemAvailColor: cssColor(memAvailColor),
+		LabelSuffix:   smoothLabel(opts.Chart.Smooth),
+	}
+	if report.SwapDisabled {
+		report.SwapNote = Tr("swap disabled：整个时间段内交换空间总量为 0，已省略交换空间曲线")
+	}
+	// 标记按绘制的数据点计算，与平滑、降采样后的曲线一致
+	if opts.HTMLAnomalyP > 0 {
+		anomalies := anomalyFlags(segments, anomalyThreshold)
+		report.Anomalies = jsonJS(anomalies)
+		report.AnomalyNote = fmt.Sprintf(Tr("已用内存高于第 %[1]g 百分位数（%[2]s GB）的 %[3]d 个数据点在 MEM Free 曲线上以洋红色大点标出"),
+			opts.HTMLAnomalyP, FormatValue(anomalyThreshold, opts.Precision), countAnomalies(anomalies))
+	}
+	if opts.HTMLUsagePct {
+		report.UsagePct = usagePctSeries(segments)
+	}
+	if opts.Provenance != "" {
+		report.Provenance = strings.Split(opts.Provenance, "\n")
+	}
+
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// addRebootMarks 在图表中为每次疑似重启画一条标有"reboot"的竖线，
+// toX将时间换算为横坐标，与数据点的横坐标一致
+func addRebootMarks(p *plot.Plot, reboots []time.Time, toX func(time.Time) float64, yMax float64) error {
+	for _, t := range reboots {
+		x := toX(t)
+		line, err := plotter.NewLine(plotter.XYs{{X: x, Y: 0}, {X: x, Y: yMax}})
+		if err != nil {
+			return err
+		}
+		line.Color = color.RGBA{R: 128, G: 128, B: 128, A: 255}
+		line.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+		p.Add(line)
+
+		labels, err := plotter.NewLabels(plotter.XYLabels{
+			XYs:    []plotter.XY{{X: x, Y: yMax}},
+			Labels: []string{"reboot"},
+		})
+		if err != nil {
+			return err
+		}
+		p.Add(labels)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package atopparse
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderChart(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := make([]MemoryRecord, 3)
+	for i := range data {
+		data[i] = MemoryRecord{Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+			MemTotal: 16, MemFree: 4 - float64(i)/2, MemCache: 2, MemBuff: 0.5, SwapTotal: 2, SwapFree: 1.5}
+	}
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, image []byte)
+	}{
+		{"png", func(t *testing.T, image []byte) {
+			if _, err := png.Decode(bytes.NewReader(image)); err != nil {
+				t.Errorf("不是有效的PNG: %v", err)
+			}
+		}},
+		{"svg", func(t *testing.T, image []byte) {
+			if !strings.Contains(string(image), "<svg") {
+				t.Errorf("不是SVG: %.80q", image)
+			}
+		}},
+		{"pdf", func(t *testing.T, image []byte) {
+			if !bytes.HasPrefix(image, []byte("%PDF-")) {
+				t.Errorf("不是PDF: %.20q", image)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderChart(data, &buf, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, buf.Bytes())
+		})
+	}
+
+	if err := RenderChart(nil, &bytes.Buffer{}, "png"); err == nil {
+		t.Error("没有数据时应返回错误")
+	}
+	if err := RenderChart(data, &bytes.Buffer{}, "bmp"); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
+
+func TestSwapDisabledCharts(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	records := func(swapTotal float64) []MemoryRecord {
+		data := make([]MemoryRecord, 3)
+		for i := range data {
+			data[i] = MemoryRecord{Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+				MemTotal: 16, MemFree: 4 - float64(i)/2, SwapTotal: swapTotal, SwapFree: swapTotal / 2}
+		}
+		return data
+	}
+
+	tests := []struct {
+		name        string
+		data        []MemoryRecord
+		wantSwap    bool
+		wantNote    bool
+		wantPNGSwap bool
+	}{
+		{"swap enabled", records(2), true, false, true},
+		{"swap disabled", records(0), false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var html bytes.Buffer
+			if err := writeHTMLReport(&html, tt.data, ReportOptions{HTMLUsagePct: true}, "", 0); err != nil {
+				t.Fatal(err)
+			}
+			for _, label := range []string{"'SWAP Total (GB)'", "'SWAP Free (GB)'", "'SWAP Used (%)'", "const swpTotal"} {
+				if got := strings.Contains(html.String(), label); got != tt.wantSwap {
+					t.Errorf("HTML中包含 %s 为 %t，期望 %t", label, got, tt.wantSwap)
+				}
+			}
+			if got := strings.Contains(html.String(), "swap disabled"); got != tt.wantNote {
+				t.Errorf("HTML中包含 swap disabled 说明为 %t，期望 %t", got, tt.wantNote)
+			}
+
+			// PNG中按默认的交换空间总量颜色（纯蓝）查找曲线和图例
+			var buf bytes.Buffer
+			if err := renderChart(tt.data, &buf, "png", ChartOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			bounds := img.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y && !found; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if r, g, b, _ := img.At(x, y).RGBA(); r == 0 && g == 0 && b == 0xffff {
+						found = true
+						break
+					}
+				}
+			}
+			if found != tt.wantPNGSwap {
+				t.Errorf("PNG中有交换空间曲线为 %t，期望 %t", found, tt.wantPNGSwap)
+			}
+		})
+	}
+}
+
+func TestHTMLMemAvail(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := []MemoryRecord{
+		{Timestamp: start, MemTotal: 16, MemFree: 4, MemCache: 2, MemBuff: 0.5, MemSlabRec: 0.5},
+		{Timestamp: start.Add(10 * time.Second), MemTotal: 16, MemFree: 3, MemCache: 2, MemBuff: 0.5, MemSlabRec: 0.5},
+	}
+	tests := []struct {
+		name string
+		hide bool
+		want bool
+	}{
+		{"shown by default", false, true},
+		{"hidden like the PNG", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeHTMLReport(&buf, data, ReportOptions{Chart: ChartOptions{HideMemAvail: tt.hide}}, "", 0); err != nil {
+				t.Fatal(err)
+			}
+			html := buf.String()
+			// 数值与PNG曲线相同，为 free + cache + buff + slrec
+			for _, want := range []string{"'MEM Available est. (GB)'", "const memAvail = [7,6]", cssColor(memAvailColor)} {
+				if got := strings.Contains(html, want); got != tt.want {
+					t.Errorf("HTML中包含 %s 为 %t，期望 %t", want, got, tt.want)
+				}
+			}
+		})
+	}
+}
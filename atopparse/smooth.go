@@ -0,0 +1,62 @@
+package atopparse
+
+import "fmt"
+
+// plotSegments 返回图表实际绘制的数据：先按GapSegments切分并插值，
+// Smooth大于1时再在每段内分别做移动平均，平滑不会跨过断开的长缺口；最后按MaxPoints降采样
+func plotSegments(data []MemoryRecord, opts ChartOptions) [][]MemoryRecord {
+	segments, _ := GapSegments(data, opts)
+	if opts.Smooth > 1 {
+		for i, segment := range segments {
+			segments[i] = smoothRecords(segment, opts.Smooth)
+		}
+	}
+	return downsampleSegments(segments, opts.MaxPoints)
+}
+
+// smoothRecords 对内存和交换空间各字段做n点居中移动平均，返回新的记录，不修改data。
+// 两端的窗口超出数据范围时缩小为范围内的样本，因此不会丢弃数据点
+func smoothRecords(data []MemoryRecord, n int) []MemoryRecord {
+	// n为偶数时窗口中当前样本之后多一个样本
+	before, after := (n-1)/2, n/2
+
+	smoothed := make([]MemoryRecord, len(data))
+	for i, record := range data {
+		lo, hi := i-before, i+after
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(data)-1 {
+			hi = len(data) - 1
+		}
+
+		var sum MemoryRecord
+		for _, r := range data[lo : hi+1] {
+			sum.MemTotal += r.MemTotal
+			sum.MemFree += r.MemFree
+			sum.MemCache += r.MemCache
+			sum.MemBuff += r.MemBuff
+			sum.MemSlabRec += r.MemSlabRec
+			sum.SwapTotal += r.SwapTotal
+			sum.SwapFree += r.SwapFree
+		}
+		count := float64(hi - lo + 1)
+		record.MemTotal = sum.MemTotal / count
+		record.MemFree = sum.MemFree / count
+		record.MemCache = sum.MemCache / count
+		record.MemBuff = sum.MemBuff / count
+		record.MemSlabRec = sum.MemSlabRec / count
+		record.SwapTotal = sum.SwapTotal / count
+		record.SwapFree = sum.SwapFree / count
+		smoothed[i] = record
+	}
+	return smoothed
+}
+
+// smoothLabel 返回平滑后曲线图例的后缀，例如 " (smoothed, 5)"；未平滑时为空
+func smoothLabel(n int) string {
+	if n <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (smoothed, %d)", n)
+}
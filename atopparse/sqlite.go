@@ -0,0 +1,116 @@
+package atopparse
+
+import (
+	"database/sql"
+	"fmt"
+
+	// 纯Go实现的SQLite驱动，不需要cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema 创建mem_records表：每个主机每个时间点一行，(timestamp, hostname)为主键，
+// 多次运行写入同一数据库时按主键更新而不是重复插入。没有CPU或PSI数据的记录对应列为NULL
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS mem_records (
+	timestamp    TEXT NOT NULL,
+	hostname     TEXT NOT NULL DEFAULT '',
+	source       TEXT,
+	mem_tot      REAL NOT NULL,
+	mem_free     REAL NOT NULL,
+	swp_tot      REAL NOT NULL,
+	swp_free     REAL NOT NULL,
+	mem_cache    REAL NOT NULL,
+	mem_buff     REAL NOT NULL,
+	mem_used_pct REAL NOT NULL,
+	swp_used_pct REAL NOT NULL,
+	cpu_sys      REAL,
+	cpu_user     REAL,
+	cpu_idle     REAL,
+	psi_mem_some REAL,
+	psi_mem_full REAL,
+	PRIMARY KEY (timestamp, hostname)
+);
+CREATE INDEX IF NOT EXISTS mem_records_timestamp ON mem_records (timestamp);
+`
+
+// sqliteUpsert 插入一条记录，(timestamp, hostname)已存在时用本次的值覆盖
+const sqliteUpsert = `
+INSERT INTO mem_records (
+	timestamp, hostname, source, mem_tot, mem_free, swp_tot, swp_free, mem_cache, mem_buff,
+	mem_used_pct, swp_used_pct, cpu_sys, cpu_user, cpu_idle, psi_mem_some, psi_mem_full
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (timestamp, hostname) DO UPDATE SET
+	source = excluded.source,
+	mem_tot = excluded.mem_tot,
+	mem_free = excluded.mem_free,
+	swp_tot = excluded.swp_tot,
+	swp_free = excluded.swp_free,
+	mem_cache = excluded.mem_cache,
+	mem_buff = excluded.mem_buff,
+	mem_used_pct = excluded.mem_used_pct,
+	swp_used_pct = excluded.swp_used_pct,
+	cpu_sys = excluded.cpu_sys,
+	cpu_user = excluded.cpu_user,
+	cpu_idle = excluded.cpu_idle,
+	psi_mem_some = excluded.psi_mem_some,
+	psi_mem_full = excluded.psi_mem_full
+`
+
+// WriteSQLite 将记录写入SQLite数据库的mem_records表（不存在时创建），在一个事务中完成。
+// 时间戳以 "2006-01-02 15:04:05" 文本保存，与CSV一致且可按字符串排序；
+// 主机名取RecordHost，日志头没有主机名时为来源文件名
+func WriteSQLite(data []MemoryRecord, path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf(Tr("无法创建 SQLite 表: %v"), err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(sqliteUpsert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	// nullable 对没有该数据的记录返回nil，写入NULL
+	nullable := func(has bool, value float64) interface{} {
+		if !has {
+			return nil
+		}
+		return value
+	}
+	for _, record := range data {
+		_, err := stmt.Exec(
+			record.Timestamp.Format("2006-01-02 15:04:05"),
+			RecordHost(record),
+			record.Source,
+			record.MemTotal,
+			record.MemFree,
+			record.SwapTotal,
+			record.SwapFree,
+			record.MemCache,
+			record.MemBuff,
+			record.MemUsedPct(),
+			record.SwapUsedPct(),
+			nullable(record.HasCPU, record.CPUSys),
+			nullable(record.HasCPU, record.CPUUser),
+			nullable(record.HasCPU, record.CPUIdle),
+			nullable(record.HasPSI, record.PSIMemSome),
+			nullable(record.HasPSI, record.PSIMemFull),
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,108 @@
+package atopparse
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// seriesStats 一个指标的统计值（GB）
+type seriesStats struct {
+	Min  float64
+	Max  float64
+	Mean float64
+	P50  float64
+	P95  float64
+	P99  float64
+}
+
+// memoryStats 已用内存和已用交换空间的统计摘要
+type memoryStats struct {
+	Samples  int
+	MemUsed  seriesStats
+	SwapUsed seriesStats
+
+	// 日志中有PAG行时换页抖动最严重的采样间隔，ThrashWeight为计算分数时的权重
+	Thrash       []thrashScore
+	ThrashWeight float64
+}
+
+// computeStats 计算已用内存（MemTotal-MemFree）和已用交换空间的最小值、最大值、平均值和百分位数
+func computeStats(data []MemoryRecord) memoryStats {
+	memUsed := make([]float64, len(data))
+	swapUsed := make([]float64, len(data))
+	for i, record := range data {
+		memUsed[i] = record.MemTotal - record.MemFree
+		swapUsed[i] = record.SwapTotal - record.SwapFree
+	}
+	return memoryStats{
+		Samples:  len(data),
+		MemUsed:  summarize(memUsed),
+		SwapUsed: summarize(swapUsed),
+	}
+}
+
+// summarize 计算一组数值的统计值，values会被排序；为空时返回零值
+func summarize(values []float64) seriesStats {
+	if len(values) == 0 {
+		return seriesStats{}
+	}
+	sort.Float64s(values)
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return seriesStats{
+		Min:  values[0],
+		Max:  values[len(values)-1],
+		Mean: sum / float64(len(values)),
+		P50:  percentile(values, 50),
+		P95:  percentile(values, 95),
+		P99:  percentile(values, 99),
+	}
+}
+
+// percentile 在已排序的数值上按线性插值计算第p百分位数，
+// 位置为 p/100*(n-1)，只有一个值时直接返回该值
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// formatStats 将统计摘要格式化为对齐的文本表格
+func formatStats(stats memoryStats, precision int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, Tr("内存使用统计（共 %d 个样本，单位 GB）:\n"), stats.Samples)
+	fmt.Fprintf(&b, "  %-10s %10s %10s %10s %10s %10s %10s\n", "", "min", "max", "mean", "p50", "p95", "p99")
+	rows := []struct {
+		name  string
+		stats seriesStats
+	}{
+		{"mem_used", stats.MemUsed},
+		{"swp_used", stats.SwapUsed},
+	}
+	for _, row := range rows {
+		s := row.stats
+		fmt.Fprintf(&b, "  %-10s %10s %10s %10s %10s %10s %10s\n", row.name,
+			FormatValue(s.Min, precision), FormatValue(s.Max, precision), FormatValue(s.Mean, precision),
+			FormatValue(s.P50, precision), FormatValue(s.P95, precision), FormatValue(s.P99, precision))
+	}
+	b.WriteString(formatThrashWindows(stats.Thrash, stats.ThrashWeight, precision))
+	return b.String()
+}
+
+// writeStatsSummary 将统计摘要写入文本文件
+func writeStatsSummary(stats memoryStats, outputFile string, precision int) error {
+	return os.WriteFile(outputFile, []byte(formatStats(stats, precision)), 0644)
+}
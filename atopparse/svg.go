@@ -0,0 +1,128 @@
+package atopparse
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// svgMaxTooltipPoints 每条曲线最多带提示的数据点数，样本更多时按固定步长抽取，避免SVG过大
+const svgMaxTooltipPoints = 1000
+
+// generateInteractiveSVG 直接生成SVG图表，数据点上的<title>元素在浏览器中悬停时显示原生提示，
+// 不依赖JavaScript，适合嵌入静态页面。colors中为零值的曲线颜色使用svgLineColors
+func generateInteractiveSVG(data []MemoryRecord, outputFile string, colors LineColors) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的数据"))
+	}
+
+	const (
+		width, height            = 960.0, 480.0
+		left, right, top, bottom = 60.0, 170.0, 40.0, 50.0
+	)
+	plotWidth := width - left - right
+	plotHeight := height - top - bottom
+
+	colors = colors.withDefaults(svgLineColors)
+	series := []struct {
+		label string
+		color string
+		value func(MemoryRecord) float64
+	}{
+		{"MEM Total (GB)", cssColor(colors.MemTotal), func(r MemoryRecord) float64 { return r.MemTotal }},
+		{"MEM Free (GB)", cssColor(colors.MemFree), func(r MemoryRecord) float64 { return r.MemFree }},
+		{"MEM Cache (GB)", cssColor(colors.MemCache), func(r MemoryRecord) float64 { return r.MemCache }},
+		{"MEM Buffers (GB)", cssColor(colors.MemBuff), func(r MemoryRecord) float64 { return r.MemBuff }},
+		{"SWAP Total (GB)", cssColor(colors.SwapTotal), func(r MemoryRecord) float64 { return r.SwapTotal }},
+		{"SWAP Free (GB)", cssColor(colors.SwapFree), func(r MemoryRecord) float64 { return r.SwapFree }},
+	}
+	title := "Memory/Swap Usage Over Time"
+	if swapDisabled(data) {
+		series = series[:4]
+		title += " (swap disabled)"
+	}
+
+	yMax := 0.0
+	for _, record := range data {
+		for _, s := range series {
+			yMax = math.Max(yMax, s.value(record))
+		}
+	}
+	if yMax == 0 {
+		yMax = 1
+	}
+	yMax *= 1.05
+
+	start, end := data[0].Timestamp, data[len(data)-1].Timestamp
+	span := end.Sub(start).Seconds()
+	x := func(record MemoryRecord) float64 {
+		if span == 0 {
+			return left + plotWidth/2
+		}
+		return left + record.Timestamp.Sub(start).Seconds()/span*plotWidth
+	}
+	y := func(value float64) float64 {
+		return top + plotHeight - value/yMax*plotHeight
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="Arial, sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(w, `<text x="%.1f" y="24" text-anchor="middle" font-size="16">%s</text>`+"\n", left+plotWidth/2, html.EscapeString(title))
+
+	// 坐标轴和刻度
+	fmt.Fprintf(w, `<g stroke="#444"><line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/><line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/></g>`+"\n",
+		left, top, left, top+plotHeight, left, top+plotHeight, left+plotWidth, top+plotHeight)
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		value := yMax * float64(i) / ticks
+		ty := y(value)
+		fmt.Fprintf(w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`+"\n", left, ty, left+plotWidth, ty)
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="end">%.1f</text>`+"\n", left-6, ty+4, value)
+
+		tx := left + plotWidth*float64(i)/ticks
+		tickTime := start.Add(time.Duration(float64(end.Sub(start)) * float64(i) / ticks))
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", tx, top+plotHeight+18, tickTime.Format("01-02 15:04"))
+	}
+	fmt.Fprintf(w, `<text x="16" y="%.1f" transform="rotate(-90 16 %.1f)" text-anchor="middle">Size (GB)</text>`+"\n", top+plotHeight/2, top+plotHeight/2)
+	fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="middle">Time</text>`+"\n", left+plotWidth/2, height-10)
+
+	stride := (len(data) + svgMaxTooltipPoints - 1) / svgMaxTooltipPoints
+	for i, s := range series {
+		points := make([]string, len(data))
+		for j, record := range data {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(record), y(s.value(record)))
+		}
+		fmt.Fprintf(w, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`+"\n", s.color, strings.Join(points, " "))
+
+		// 数据点及悬停提示
+		fmt.Fprintf(w, `<g fill="%s">`+"\n", s.color)
+		for j := 0; j < len(data); j += stride {
+			record := data[j]
+			fmt.Fprintf(w, `<circle cx="%.1f" cy="%.1f" r="3"><title>%s %s: %.2f</title></circle>`+"\n",
+				x(record), y(s.value(record)), record.Timestamp.Format("2006-01-02 15:04:05"), html.EscapeString(s.label), s.value(record))
+		}
+		fmt.Fprintln(w, `</g>`)
+
+		// 图例
+		ly := top + 10 + float64(i)*20
+		fmt.Fprintf(w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="3"/>`+"\n", width-right+15, ly, width-right+35, ly, s.color)
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f">%s</text>`+"\n", width-right+40, ly+4, html.EscapeString(s.label))
+	}
+	fmt.Fprintln(w, `</svg>`)
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,114 @@
+package atopparse
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// maxThrashWindows 统计摘要中列出的换页抖动最严重的采样间隔数
+const maxThrashWindows = 5
+
+// thrashScore 一个采样间隔的换页抖动分数（页/秒）以及对应的换入/换出速率
+type thrashScore struct {
+	Timestamp time.Time // 采样间隔结束的时间，即样本的时间戳
+	Interval  time.Duration
+	Score     float64
+	In        float64
+	Out       float64
+}
+
+// thrashValue 按 min(in, out) + weight × |in − out| 合并换入/换出速率。
+// 同时大量换入和换出是抖动的特征，weight为0时只计两者同时发生的部分，为1时等于两者中较大的一个
+func thrashValue(in, out, weight float64) float64 {
+	low, high := in, out
+	if low > high {
+		low, high = high, low
+	}
+	return low + weight*(high-low)
+}
+
+// thrashScores 计算每个带有PAG数据的样本的换页抖动分数，速率的换算与换入/换出速率图表相同
+func thrashScores(data []MemoryRecord, weight float64) []thrashScore {
+	rates := swapRates(data)
+	scores := make([]thrashScore, len(rates))
+	for i, rate := range rates {
+		scores[i] = thrashScore{
+			Timestamp: rate.Timestamp,
+			Interval:  data[i].Interval,
+			Score:     thrashValue(rate.In, rate.Out, weight),
+			In:        rate.In,
+			Out:       rate.Out,
+		}
+	}
+	return scores
+}
+
+// worstThrashWindows 返回分数最高的至多n个采样间隔，按分数从高到低排列，分数为0的不列出
+func worstThrashWindows(data []MemoryRecord, weight float64, n int) []thrashScore {
+	var scores []thrashScore
+	for _, score := range thrashScores(data, weight) {
+		if score.Score > 0 {
+			scores = append(scores, score)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	return scores
+}
+
+// formatThrashWindows 将换页抖动最严重的采样间隔格式化为统计摘要的一节，没有时返回空字符串
+func formatThrashWindows(windows []thrashScore, weight float64, precision int) string {
+	if len(windows) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, Tr("换页抖动最严重的采样间隔（分数 = min(swin, swout) + %s × |swin − swout|，单位 页/秒）:\n"), FormatValue(weight, 2))
+	fmt.Fprintf(&b, "  %-19s %8s %10s %10s %10s\n", "timestamp", "interval", "score", "swin/s", "swout/s")
+	for _, window := range windows {
+		interval := "-"
+		if window.Interval > 0 {
+			interval = window.Interval.String()
+		}
+		fmt.Fprintf(&b, "  %-19s %8s %10s %10s %10s\n", window.Timestamp.Format("2006-01-02 15:04:05"), interval,
+			FormatValue(window.Score, precision), FormatValue(window.In, precision), FormatValue(window.Out, precision))
+	}
+	return b.String()
+}
+
+// generateThrashChart 绘制换页抖动分数随时间的变化，超过maxPoints个点时降采样
+func generateThrashChart(data []MemoryRecord, outputFile string, weight float64, maxPoints int) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的换页数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Swap Thrash Score"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Pages/s"
+	p.Y.Min = 0
+	p.X.Tick.Marker = timeAxis()
+
+	scores := thrashScores(data, weight)
+	points := make(plotter.XYs, len(scores))
+	for i, score := range scores {
+		points[i].X, points[i].Y = timeAxisX(score.Timestamp), score.Score
+	}
+	line, err := plotter.NewLine(downsampleXYs(points, maxPoints))
+	if err != nil {
+		return err
+	}
+	line.Color = color.RGBA{R: 200, B: 200, A: 255}
+	p.Add(line)
+	p.Legend.Add(fmt.Sprintf("thrash score (weight %s)", FormatValue(weight, 2)), line)
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
@@ -0,0 +1,90 @@
+package atopparse
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// thrashTestData 每10秒一个样本的PAG数据，每个元素为采样间隔内换入和换出的页数
+func thrashTestData(pages [][2]float64) []MemoryRecord {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := make([]MemoryRecord, len(pages))
+	for i, p := range pages {
+		data[i] = MemoryRecord{Source: "a", Timestamp: start.Add(time.Duration(i*10) * time.Second), Interval: 10 * time.Second,
+			HasPAG: true, SwapIn: p[0], SwapOut: p[1]}
+	}
+	return data
+}
+
+func TestThrashScores(t *testing.T) {
+	// 换入/换出速率（页/秒）：只换入、只换出、同时换入换出、都没有
+	data := thrashTestData([][2]float64{{1000, 0}, {0, 500}, {800, 600}, {0, 0}})
+
+	tests := []struct {
+		name   string
+		weight float64
+		want   []float64
+	}{
+		{"simultaneous only", 0, []float64{0, 0, 60, 0}},
+		{"half", 0.5, []float64{50, 25, 70, 0}},
+		{"larger of the two", 1, []float64{100, 50, 80, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, score := range thrashScores(data, tt.weight) {
+				if math.Abs(score.Score-tt.want[i]) > 1e-9 {
+					t.Errorf("第 %d 个样本的分数为 %v，期望 %v", i, score.Score, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWorstThrashWindows(t *testing.T) {
+	data := thrashTestData([][2]float64{{100, 100}, {0, 900}, {500, 400}, {300, 300}, {0, 0}, {200, 250}, {50, 60}, {70, 80}})
+
+	tests := []struct {
+		name   string
+		weight float64
+		n      int
+		want   []int // 按分数从高到低的样本下标
+	}{
+		{"simultaneous only", 0, 3, []int{2, 3, 5}},
+		{"one-sided counts fully", 1, 2, []int{1, 2}},
+		{"zero scores skipped", 0, 10, []int{2, 3, 5, 0, 7, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := worstThrashWindows(data, tt.weight, tt.n)
+			if len(windows) != len(tt.want) {
+				t.Fatalf("列出 %d 个采样间隔，期望 %d 个", len(windows), len(tt.want))
+			}
+			for i, window := range windows {
+				if !window.Timestamp.Equal(data[tt.want[i]].Timestamp) {
+					t.Errorf("第 %d 个为 %s，期望 %s", i, window.Timestamp, data[tt.want[i]].Timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatStatsThrash(t *testing.T) {
+	data := thrashTestData([][2]float64{{0, 0}, {800, 600}})
+	for i := range data {
+		data[i].MemTotal, data[i].MemFree = 16, 4
+	}
+
+	stats := computeStats(data)
+	if strings.Contains(formatStats(stats, 2), "swin/s") {
+		t.Errorf("没有抖动数据时不应列出换页抖动")
+	}
+	stats.Thrash, stats.ThrashWeight = worstThrashWindows(data, 0.5, maxThrashWindows), 0.5
+	table := strings.Join(strings.Fields(formatStats(stats, 2)), " ")
+	for _, want := range []string{"0.50 × |swin − swout|", "2024-06-11 10:00:10 10s 70.00 80.00 60.00"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("统计摘要中没有 %q:\n%s", want, formatStats(stats, 2))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package atopparse
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"gonum.org/v1/plot"
+)
+
+// timeAxisFormat 是PNG图表时间轴刻度的标签格式
+const timeAxisFormat = "01-02 15:04"
+
+// maxTimeTicks 是时间轴最多的主刻度数，8英寸宽的图表上 "MM-DD HH:MM" 标签不会重叠
+const maxTimeTicks = 6
+
+// timeTickSteps 是时间轴主刻度可选的间隔，从小到大依次尝试
+var timeTickSteps = []time.Duration{
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour,
+}
+
+// timeAxisX 返回时间在图表横坐标上的值（Unix秒），配合timeAxis显示实际的日期时间
+func timeAxisX(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// timeAxis 返回将横坐标显示为 MM-DD HH:MM 的刻度。时间戳按日志中的本地时间解析为UTC，
+// 因此按UTC格式化即为日志中的时间
+func timeAxis() plot.Ticker {
+	return plot.TimeTicks{Ticker: timeStepTicks{}, Format: timeAxisFormat}
+}
+
+// timeStepTicks 在整分钟、整点或整天的位置放置主刻度，间隔随时间跨度增大，
+// 使主刻度不超过maxTimeTicks个；每个主刻度间再放一个无标签的次刻度
+type timeStepTicks struct{}
+
+// Ticks 实现plot.Ticker
+func (timeStepTicks) Ticks(min, max float64) []plot.Tick {
+	span := max - min
+	step := 0.0
+	for _, candidate := range timeTickSteps {
+		if span/candidate.Seconds() <= maxTimeTicks {
+			step = candidate.Seconds()
+			break
+		}
+	}
+	if step == 0 {
+		// 超过几个月的跨度按整天数取间隔
+		day := (24 * time.Hour).Seconds()
+		step = math.Ceil(span/maxTimeTicks/day) * day
+	}
+
+	var ticks []plot.Tick
+	for value := math.Ceil(min/step) * step; value <= max; value += step {
+		// 标签由plot.TimeTicks格式化为时间，这里只需非空
+		ticks = append(ticks, plot.Tick{Value: value, Label: strconv.FormatFloat(value, 'f', 0, 64)})
+		if minor := value + step/2; minor <= max {
+			ticks = append(ticks, plot.Tick{Value: minor})
+		}
+	}
+	if len(ticks) == 0 {
+		// 只有一个样本等跨度为0的情况
+		ticks = append(ticks, plot.Tick{Value: min, Label: strconv.FormatFloat(min, 'f', 0, 64)})
+	}
+	return ticks
+}
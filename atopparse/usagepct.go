@@ -0,0 +1,114 @@
+package atopparse
+
+import (
+	"fmt"
+	"html/template"
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// usagePctColumns 是紧跟在csvHeader之后的内存/交换空间使用率列，由其他列计算得出
+var usagePctColumns = []string{"mem_used_pct", "swp_used_pct"}
+
+// MemUsedPct 返回已用内存占总量的百分比，总量为0时返回0
+func (r MemoryRecord) MemUsedPct() float64 {
+	return usedPct(r.MemTotal, r.MemFree)
+}
+
+// SwapUsedPct 返回已用交换空间占总量的百分比，未启用交换空间时返回0
+func (r MemoryRecord) SwapUsedPct() float64 {
+	return usedPct(r.SwapTotal, r.SwapFree)
+}
+
+// usedPct 计算 (total-free)/total×100，total为0时返回0而不是NaN
+func usedPct(total, free float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return (total - free) / total * 100
+}
+
+// usagePctValues 返回记录的使用率列
+func usagePctValues(record MemoryRecord, precision int) []string {
+	return []string{
+		FormatValue(record.MemUsedPct(), precision),
+		FormatValue(record.SwapUsedPct(), precision),
+	}
+}
+
+// generateUsagePctChart 绘制内存和交换空间使用率，Y轴固定为0-100%，便于比较内存大小不同的主机
+func generateUsagePctChart(data []MemoryRecord, outputFile string, opts ChartOptions) error {
+	if len(data) == 0 {
+		return fmt.Errorf(Tr("没有可绘制的数据"))
+	}
+
+	p := plot.New()
+	p.Title.Text = "Memory/Swap Usage (%)"
+	p.X.Label.Text = "Time"
+	p.Y.Label.Text = "Used (%)"
+	p.Y.Min = 0
+	p.Y.Max = 100
+	p.X.Tick.Marker = timeAxis()
+
+	series := []struct {
+		label string
+		color color.RGBA
+		value func(MemoryRecord) float64
+	}{
+		{"MEM Used (%)", color.RGBA{R: 255, A: 255}, MemoryRecord.MemUsedPct},
+		{"SWAP Used (%)", color.RGBA{B: 255, A: 255}, MemoryRecord.SwapUsedPct},
+	}
+	if swapDisabled(data) {
+		series = series[:1]
+		p.Title.Text += " (swap disabled)"
+	}
+
+	// 与内存图表一样在长缺口处断开折线，并做相同的平滑
+	segments := plotSegments(data, opts)
+	for _, s := range series {
+		for i, segment := range segments {
+			points := make(plotter.XYs, len(segment))
+			for j, record := range segment {
+				points[j].X = timeAxisX(record.Timestamp)
+				points[j].Y = s.value(record)
+			}
+			line, err := plotter.NewLine(points)
+			if err != nil {
+				return err
+			}
+			line.Color = s.color
+			p.Add(line)
+			if i == 0 {
+				p.Legend.Add(s.label+smoothLabel(opts.Smooth), line)
+			}
+		}
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outputFile)
+}
+
+// htmlUsagePct HTML报告中使用率图表的数据，序列为已编码的JSON数组
+type htmlUsagePct struct {
+	MemPct template.JS
+	SwpPct template.JS
+}
+
+// usagePctSeries 返回HTML报告中使用率图表的序列，与内存图表共用时间轴标签，分段之间插入空值
+func usagePctSeries(segments [][]MemoryRecord) *htmlUsagePct {
+	var memPct, swpPct []*float64
+	value := func(v float64) *float64 { return &v }
+	for i, segment := range segments {
+		if i > 0 {
+			memPct = append(memPct, nil)
+			swpPct = append(swpPct, nil)
+		}
+		for _, record := range segment {
+			memPct = append(memPct, value(record.MemUsedPct()))
+			swpPct = append(swpPct, value(record.SwapUsedPct()))
+		}
+	}
+	return &htmlUsagePct{MemPct: jsonJS(memPct), SwpPct: jsonJS(swpPct)}
+}
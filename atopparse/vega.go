@@ -0,0 +1,77 @@
+package atopparse
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// vegaPoint Vega-Lite内联数据中的一个值，使用长格式以便按series着色
+type vegaPoint struct {
+	Timestamp string  `json:"timestamp"`
+	Series    string  `json:"series"`
+	Value     float64 `json:"value"`
+}
+
+// generateVegaLite 输出内联数据的Vega-Lite规范（多折线的内存/交换空间图表），
+// 可直接在Vega编辑器中打开，或在其他工具中重新设置样式。lineColors中为零值的曲线颜色使用默认颜色
+func generateVegaLite(data []MemoryRecord, outputFile string, lineColors LineColors) error {
+	lineColors = lineColors.withDefaults(defaultLineColors)
+	series := []struct {
+		label string
+		color string
+		value func(MemoryRecord) float64
+	}{
+		{"MEM Total (GB)", cssColor(lineColors.MemTotal), func(r MemoryRecord) float64 { return r.MemTotal }},
+		{"MEM Free (GB)", cssColor(lineColors.MemFree), func(r MemoryRecord) float64 { return r.MemFree }},
+		{"MEM Cache (GB)", cssColor(lineColors.MemCache), func(r MemoryRecord) float64 { return r.MemCache }},
+		{"MEM Buffers (GB)", cssColor(lineColors.MemBuff), func(r MemoryRecord) float64 { return r.MemBuff }},
+		{"SWAP Total (GB)", cssColor(lineColors.SwapTotal), func(r MemoryRecord) float64 { return r.SwapTotal }},
+		{"SWAP Free (GB)", cssColor(lineColors.SwapFree), func(r MemoryRecord) float64 { return r.SwapFree }},
+	}
+	title := "Memory/Swap Usage Over Time"
+	if swapDisabled(data) {
+		series = series[:4]
+		title += " (swap disabled)"
+	}
+
+	values := make([]vegaPoint, 0, len(data)*len(series))
+	labels := make([]string, len(series))
+	colors := make([]string, len(series))
+	for i, s := range series {
+		labels[i] = s.label
+		colors[i] = s.color
+		for _, record := range data {
+			// 不带时区的ISO时间，Vega按浏览器本地时间解释，与日志中的本地时间一致
+			values = append(values, vegaPoint{
+				Timestamp: record.Timestamp.Format("2006-01-02T15:04:05"),
+				Series:    s.label,
+				Value:     s.value(record),
+			})
+		}
+	}
+
+	spec := map[string]any{
+		"$schema": "https://vega.github.io/schema/vega-lite/v5.json",
+		"title":   title,
+		"width":   800,
+		"height":  400,
+		"data":    map[string]any{"values": values},
+		"mark":    map[string]any{"type": "line", "tooltip": true},
+		"encoding": map[string]any{
+			"x": map[string]any{"field": "timestamp", "type": "temporal", "title": "Time"},
+			"y": map[string]any{"field": "value", "type": "quantitative", "title": "Size (GB)"},
+			"color": map[string]any{
+				"field": "series",
+				"type":  "nominal",
+				"title": nil,
+				"scale": map[string]any{"domain": labels, "range": colors},
+			},
+		},
+	}
+
+	content, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputFile, append(content, '\n'), 0644)
+}
@@ -0,0 +1,82 @@
+package atopparse
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ListLogFiles 返回目录模式下要解析的文件路径，按路径排序。recursive为false时只列出第一层的文件；
+// 为true时递归进入子目录，指向目录的符号链接也会进入，同一个真实目录只访问一次，避免符号链接循环。
+// 无法读取的子目录跳过并向w输出警告
+func ListLogFiles(dirPath string, recursive bool, w io.Writer) ([]string, error) {
+	if !recursive {
+		entries, err := os.ReadDir(dirPath)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dirPath, entry.Name()))
+			}
+		}
+		return files, nil
+	}
+
+	var files []string
+	visited := make(map[string]bool)
+	// enter 在第一次遇到某个真实目录时返回true
+	enter := func(path string) bool {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			real = path
+		}
+		if visited[real] {
+			return false
+		}
+		visited[real] = true
+		return true
+	}
+
+	var walk func(root string) error
+	walk = func(root string) error {
+		return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dirPath {
+					return err
+				}
+				// 子目录不可读时跳过，不影响其他目录
+				fmt.Fprintf(w, Tr("警告: 无法读取 %s: %v\n"), path, err)
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && !enter(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// WalkDir不跟随符号链接，指向目录的链接在这里单独进入
+			if entry.Type()&fs.ModeSymlink != 0 {
+				if target, err := os.Stat(path); err == nil && target.IsDir() {
+					// 末尾加上分隔符，使WalkDir把链接本身当作目录展开
+					if enter(path) {
+						return walk(path + string(filepath.Separator))
+					}
+					return nil
+				}
+			}
+			files = append(files, path)
+			return nil
+		})
+	}
+	enter(dirPath)
+	if err := walk(dirPath); err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,66 @@
+package atopparse
+
+import (
+	"context"
+	"runtime"
+)
+
+// fileResult 是目录模式下单个文件的解析结果
+type fileResult struct {
+	records []MemoryRecord
+	info    *DetectionInfo
+	err     error
+}
+
+// parseFunc 解析单个文件，与RecordCache.Parse的签名相同
+type parseFunc func(filePath string, opts ParseOptions, info *DetectionInfo) ([]MemoryRecord, error)
+
+// poolSize 返回目录模式下实际同时解析的文件数：Workers为0时取runtime.NumCPU()，
+// 再以MaxConcurrency（大于0时）为上限；为1时逐个文件串行解析
+func poolSize(opts ParseOptions) int {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if opts.MaxConcurrency > 0 && workers > opts.MaxConcurrency {
+		workers = opts.MaxConcurrency
+	}
+	return workers
+}
+
+// parseFiles 用最多workers个goroutine调用parse并发解析files，返回与files一一对应的结果通道。
+// 调用方按files的顺序读取结果，输出顺序因此与并发度无关；ctx取消后不再开始解析新的文件
+func parseFiles(ctx context.Context, files []string, opts ParseOptions, workers int, parse parseFunc) []chan fileResult {
+	results := make([]chan fileResult, len(files))
+	for i := range results {
+		// 带一个缓冲，调用方提前返回时worker也不会阻塞
+		results[i] = make(chan fileResult, 1)
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				// 每个文件使用独立的识别信息，由调用方按顺序合并
+				info := NewDetectionInfo()
+				records, err := parse(files[i], opts, info)
+				results[i] <- fileResult{records: records, info: info, err: err}
+			}
+		}()
+	}
+	return results
+}
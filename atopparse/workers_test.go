@@ -0,0 +1,82 @@
+package atopparse
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ParseOptions
+		want int
+	}{
+		{"workers", ParseOptions{Workers: 4}, 4},
+		{"clamped", ParseOptions{Workers: 8, MaxConcurrency: 2}, 2},
+		{"below cap", ParseOptions{Workers: 2, MaxConcurrency: 8}, 2},
+		{"serial", ParseOptions{Workers: 8, MaxConcurrency: 1}, 1},
+		{"default", ParseOptions{}, runtime.NumCPU()},
+		{"default clamped", ParseOptions{MaxConcurrency: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := poolSize(tt.opts); got != tt.want {
+				t.Errorf("poolSize = %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilesConcurrencyCap(t *testing.T) {
+	files := make([]string, 12)
+	for i := range files {
+		files[i] = fmt.Sprintf("atop%02d.txt", i)
+	}
+
+	for _, limit := range []int{1, 3} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			var mu sync.Mutex
+			var active, peak int
+			var order []string
+			parse := func(filePath string, opts ParseOptions, info *DetectionInfo) ([]MemoryRecord, error) {
+				mu.Lock()
+				active++
+				if active > peak {
+					peak = active
+				}
+				order = append(order, filePath)
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return []MemoryRecord{{Source: filePath}}, nil
+			}
+
+			opts := ParseOptions{Workers: 8, MaxConcurrency: limit}
+			results := parseFiles(context.Background(), files, opts, poolSize(opts), parse)
+			for i, result := range results {
+				if r := <-result; r.err != nil || r.records[0].Source != files[i] {
+					t.Fatalf("第 %d 个结果不对应 %s: %+v", i, files[i], r)
+				}
+			}
+			if peak > limit {
+				t.Errorf("同时解析了 %d 个文件，上限为 %d", peak, limit)
+			}
+			if limit == 1 {
+				// 串行时按文件顺序逐个解析
+				for i, file := range order {
+					if file != files[i] {
+						t.Fatalf("串行解析的顺序为 %v", order)
+					}
+				}
+			}
+		})
+	}
+}
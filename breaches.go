@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// breachWindow 是写入告警摘要的一段阈值越界时间
+type breachWindow struct {
+	Condition string     `json:"condition"`
+	Severity  string     `json:"severity"` // 命中的规则的级别，--transition-* 阈值为warning
+	Start     time.Time  `json:"start"`
+	End       time.Time  `json:"end"`                 // 最后一个越界样本
+	Peak      float64    `json:"peak"`                // 窗口内最严重的值(GB)
+	Recovered *time.Time `json:"recovered,omitempty"` // 恢复正常的样本时间，直到数据结束仍越界时为空
+}
+
+// findBreaches 按各状态条件找出所有越界窗口，按开始时间排序
+func findBreaches(data []atopparse.MemoryRecord, conditions []stateCondition) []breachWindow {
+	var breaches []breachWindow
+	for _, cond := range conditions {
+		for _, w := range findStateWindows(data, cond) {
+			breach := breachWindow{
+				Condition: cond.Label,
+				Severity:  cond.Severity,
+				Start:     w.Start,
+				End:       w.End,
+				Peak:      w.Peak,
+			}
+			if !w.ExitAt.IsZero() {
+				recovered := w.ExitAt
+				breach.Recovered = &recovered
+			}
+			breaches = append(breaches, breach)
+		}
+	}
+	sort.SliceStable(breaches, func(i, j int) bool {
+		return breaches[i].Start.Before(breaches[j].Start)
+	})
+	return breaches
+}
+
+// writeBreachesCSV 将越界窗口写为CSV，峰值保留precision位小数，compress为true时经gzip压缩
+func writeBreachesCSV(breaches []breachWindow, outputFile string, precision int, compress bool) error {
+	out, err := atopparse.CreateOutput(outputFile, compress)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(out)
+	if err := writer.Write([]string{"condition", "severity", "start", "end", "peak", "recovered"}); err != nil {
+		out.Abort()
+		return err
+	}
+	for _, b := range breaches {
+		recovered := ""
+		if b.Recovered != nil {
+			recovered = b.Recovered.Format("2006-01-02 15:04:05")
+		}
+		row := []string{
+			b.Condition,
+			b.Severity,
+			b.Start.Format("2006-01-02 15:04:05"),
+			b.End.Format("2006-01-02 15:04:05"),
+			atopparse.FormatValue(b.Peak, precision),
+			recovered,
+		}
+		if err := writer.Write(row); err != nil {
+			out.Abort()
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// writeBreachesJSON 将越界窗口写为JSON数组，峰值舍入到precision位小数，compress为true时经gzip压缩
+func writeBreachesJSON(breaches []breachWindow, outputFile string, precision int, compress bool) error {
+	rounded := make([]breachWindow, len(breaches))
+	for i, b := range breaches {
+		b.Peak = atopparse.RoundValue(b.Peak, precision)
+		rounded[i] = b
+	}
+	content, err := json.MarshalIndent(rounded, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := atopparse.CreateOutput(outputFile, compress)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(append(content, '\n')); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
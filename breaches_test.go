@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+func TestFindBreachesSeverity(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	var data []atopparse.MemoryRecord
+	for i, free := range []float64{2, 0.8, 0.4, 2} {
+		data = append(data, atopparse.MemoryRecord{Timestamp: start.Add(time.Duration(i) * 10 * time.Second), MemTotal: 16, MemFree: free})
+	}
+	rules := []rule{
+		{Name: "mem-free-warn", Metric: "mem_free", Comparator: "<", Value: 1, Severity: severityWarning},
+		{Name: "mem-free-critical", Metric: "mem_free", Comparator: "<", Value: 0.5, Severity: severityCritical},
+	}
+
+	tests := []struct {
+		name       string
+		conditions []stateCondition
+		want       map[string]string // 条件名到级别
+		exitCode   int
+	}{
+		{"rules", ruleConditions(rules), map[string]string{"mem-free-warn": severityWarning, "mem-free-critical": severityCritical}, 3},
+		{"rules warning only", ruleConditions(rules[:1]), map[string]string{"mem-free-warn": severityWarning}, 2},
+		{"transition thresholds", transitionConditions(1, 0), map[string]string{fmt.Sprintf(tr("空闲内存低于 %.2fG"), 1.0): severityWarning}, 2},
+		{"no breach", transitionConditions(0.1, 0), map[string]string{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaches := findBreaches(data, tt.conditions)
+			if len(breaches) != len(tt.want) {
+				t.Fatalf("越界窗口 %d 个，期望 %d 个: %+v", len(breaches), len(tt.want), breaches)
+			}
+			for _, b := range breaches {
+				if b.Severity != tt.want[b.Condition] {
+					t.Errorf("%s 的级别为 %q，期望 %q", b.Condition, b.Severity, tt.want[b.Condition])
+				}
+			}
+			if code := ruleExitCode(breaches); code != tt.exitCode {
+				t.Errorf("退出码 %d，期望 %d", code, tt.exitCode)
+			}
+		})
+	}
+}
+
+func TestWriteBreachesSeverity(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	breaches := []breachWindow{{Condition: "mem-free-critical", Severity: severityCritical, Start: start, End: start, Peak: 0.4}}
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "breaches.csv")
+	if err := writeBreachesCSV(breaches, csvFile, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(csvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][1] != "severity" || rows[1][1] != severityCritical {
+		t.Errorf("CSV中没有severity列: %v", rows)
+	}
+
+	jsonFile := filepath.Join(dir, "breaches.json")
+	if err := writeBreachesJSON(breaches, jsonFile, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0]["severity"] != severityCritical {
+		t.Errorf("JSON中没有severity字段: %s", content)
+	}
+}
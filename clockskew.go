@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// clockOffset 某个源文件相对参考文件的估计时钟偏移
+type clockOffset struct {
+	Source      string
+	Offset      time.Duration // 该来源的时钟比参考来源快多少，校正时从其时间戳中减去
+	Correlation float64       // 在该偏移下两条已用内存序列的相关系数
+	Points      int           // 参与比较的样本数
+}
+
+// minSkewPoints 估计偏移至少需要的重叠样本数
+const minSkewPoints = 5
+
+// usedAt 在已排序的记录中按时间线性插值已用内存，t超出范围时返回false
+func usedAt(records []atopparse.MemoryRecord, t time.Time) (float64, bool) {
+	i := sort.Search(len(records), func(i int) bool {
+		return !records[i].Timestamp.Before(t)
+	})
+	if i == len(records) {
+		return 0, false
+	}
+	used := func(r atopparse.MemoryRecord) float64 { return r.MemTotal - r.MemFree }
+	if records[i].Timestamp.Equal(t) {
+		return used(records[i]), true
+	}
+	if i == 0 {
+		return 0, false
+	}
+
+	prev, next := records[i-1], records[i]
+	ratio := float64(t.Sub(prev.Timestamp)) / float64(next.Timestamp.Sub(prev.Timestamp))
+	return used(prev) + (used(next)-used(prev))*ratio, true
+}
+
+// correlation 计算皮尔逊相关系数，任一序列方差为0时返回false
+func correlation(x, y []float64) (float64, bool) {
+	n := float64(len(x))
+	var sumX, sumY float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
+
+// estimateClockOffsets 以记录最多的来源为参考，在±maxSkew范围内寻找使两条已用内存序列
+// 相关性最高的时间偏移，作为其他来源的估计时钟偏移。没有足够重叠或序列平坦的来源不会出现在结果中
+func estimateClockOffsets(data []atopparse.MemoryRecord, maxSkew time.Duration) (string, []clockOffset) {
+	groups := atopparse.GroupBySource(data)
+	sources := make([]string, 0, len(groups))
+	for source := range groups {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	if len(sources) < 2 {
+		return "", nil
+	}
+
+	reference := sources[0]
+	for _, source := range sources {
+		if len(groups[source]) > len(groups[reference]) {
+			reference = source
+		}
+	}
+	refRecords := groups[reference]
+
+	// 以采样间隔的1/5为步长搜索，插值后可以分辨小于一个采样间隔的偏移
+	step := atopparse.MedianInterval(refRecords) / 5
+	if step < time.Second {
+		step = time.Second
+	}
+
+	var offsets []clockOffset
+	for _, source := range sources {
+		if source == reference {
+			continue
+		}
+
+		best := clockOffset{Source: source, Correlation: math.Inf(-1)}
+		for lag := -maxSkew; lag <= maxSkew; lag += step {
+			var refValues, srcValues []float64
+			for _, record := range groups[source] {
+				if value, ok := usedAt(refRecords, record.Timestamp.Add(-lag)); ok {
+					refValues = append(refValues, value)
+					srcValues = append(srcValues, record.MemTotal-record.MemFree)
+				}
+			}
+			if len(refValues) < minSkewPoints {
+				continue
+			}
+			if corr, ok := correlation(refValues, srcValues); ok && corr > best.Correlation {
+				best.Offset = lag
+				best.Correlation = corr
+				best.Points = len(refValues)
+			}
+		}
+
+		if best.Points > 0 {
+			offsets = append(offsets, best)
+		}
+	}
+	return reference, offsets
+}
+
+// printClockOffsets 输出各来源的估计时钟偏移
+func printClockOffsets(w io.Writer, reference string, offsets []clockOffset) {
+	if reference == "" {
+		fmt.Fprintln(w, tr("时钟偏移估计: 只有一个来源，无需比较"))
+		return
+	}
+	fmt.Fprintf(w, tr("时钟偏移估计（参考来源 %s）:\n"), reference)
+	if len(offsets) == 0 {
+		fmt.Fprintln(w, tr("  没有与参考来源有足够重叠且有波动的来源"))
+	}
+	for _, o := range offsets {
+		sign := "+"
+		if o.Offset < 0 {
+			sign = ""
+		}
+		fmt.Fprintf(w, tr("  %s: %s%v (相关系数 %.2f，%d 个样本)\n"), o.Source, sign, o.Offset, o.Correlation, o.Points)
+	}
+}
+
+// alignClocks 按估计偏移校正各来源的时间戳，并重新按时间排序
+func alignClocks(data []atopparse.MemoryRecord, offsets []clockOffset) []atopparse.MemoryRecord {
+	shift := make(map[string]time.Duration, len(offsets))
+	for _, o := range offsets {
+		shift[o.Source] = o.Offset
+	}
+
+	aligned := make([]atopparse.MemoryRecord, len(data))
+	for i, record := range data {
+		record.Timestamp = record.Timestamp.Add(-shift[record.Source])
+		aligned[i] = record
+	}
+	sort.SliceStable(aligned, func(i, j int) bool {
+		return aligned[i].Timestamp.Before(aligned[j].Timestamp)
+	})
+	return aligned
+}
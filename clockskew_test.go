@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// skewedSource 生成从start开始每10秒一个样本的已用内存序列，时钟比真实时间快skew；
+// 序列不规则，只有在真实偏移处两个来源才完全吻合。取其中一段作为另一个来源时真实时间与参考来源一致
+func skewedSource(source string, start time.Time, n int, skew time.Duration) []atopparse.MemoryRecord {
+	records := make([]atopparse.MemoryRecord, n)
+	seed := uint32(12345)
+	for i := range records {
+		seed = seed*1103515245 + 12345 // 线性同余生成的伪随机序列，没有周期性
+		used := 2 + float64((seed>>16)%1000)/100
+		records[i] = atopparse.MemoryRecord{
+			Timestamp: start.Add(time.Duration(i)*10*time.Second + skew),
+			Source:    source,
+			MemTotal:  16,
+			MemFree:   16 - used,
+		}
+	}
+	return records
+}
+
+func TestEstimateClockOffsets(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		skew       time.Duration
+		maxSkew    time.Duration
+		wantOffset time.Duration
+		wantFound  bool
+	}{
+		{"source ahead", 30 * time.Second, 2 * time.Minute, 30 * time.Second, true},
+		{"source behind", -20 * time.Second, 2 * time.Minute, -20 * time.Second, true},
+		{"less than one interval", 4 * time.Second, 2 * time.Minute, 4 * time.Second, true},
+		{"in sync", 0, 2 * time.Minute, 0, true},
+		{"skew beyond search range", 90 * time.Second, 30 * time.Second, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 参考来源的样本更多，因此被选为参考
+			data := append(skewedSource("a.txt", start, 60, 0), skewedSource("b.txt", start, 60, tt.skew)[6:46]...)
+			reference, offsets := estimateClockOffsets(data, tt.maxSkew)
+			if reference != "a.txt" {
+				t.Fatalf("参考来源为 %q，期望 a.txt", reference)
+			}
+			if len(offsets) != 1 {
+				t.Fatalf("应估计出1个来源的偏移，实际 %+v", offsets)
+			}
+			found := offsets[0].Offset == tt.wantOffset && offsets[0].Correlation > 0.999
+			if found != tt.wantFound {
+				t.Errorf("估计偏移 %v（相关系数 %.3f），期望 %v 找到 %t", offsets[0].Offset, offsets[0].Correlation, tt.wantOffset, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestEstimateClockOffsetsNotComparable(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	flat := skewedSource("b.txt", start, 40, 0)
+	for i := range flat {
+		flat[i].MemFree = 8
+	}
+	tests := []struct {
+		name          string
+		data          []atopparse.MemoryRecord
+		wantReference string
+	}{
+		{"single source", skewedSource("a.txt", start, 60, 0), ""},
+		{"flat series", append(skewedSource("a.txt", start, 60, 0), flat...), "a.txt"},
+		{"no overlap", append(skewedSource("a.txt", start, 60, 0), skewedSource("b.txt", start.Add(time.Hour), 40, 0)...), "a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reference, offsets := estimateClockOffsets(tt.data, time.Minute)
+			if reference != tt.wantReference || len(offsets) != 0 {
+				t.Errorf("参考来源 %q、偏移 %+v，期望参考来源 %q 且没有偏移", reference, offsets, tt.wantReference)
+			}
+		})
+	}
+}
+
+func TestAlignClocks(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	data := append(skewedSource("a.txt", start, 60, 0), skewedSource("b.txt", start, 60, 30*time.Second)[6:46]...)
+	reference, offsets := estimateClockOffsets(data, 2*time.Minute)
+	aligned := alignClocks(data, offsets)
+
+	// 校正后b.txt的样本与参考来源同一时刻的样本时间戳相同、已用内存相同
+	byTime := make(map[time.Time]float64)
+	for _, record := range aligned {
+		if record.Source == reference {
+			byTime[record.Timestamp] = record.MemFree
+		}
+	}
+	for i, record := range aligned {
+		if i > 0 && record.Timestamp.Before(aligned[i-1].Timestamp) {
+			t.Fatalf("校正后的记录没有按时间排序")
+		}
+		if record.Source != "b.txt" {
+			continue
+		}
+		free, ok := byTime[record.Timestamp]
+		if !ok || free != record.MemFree {
+			t.Errorf("b.txt 在 %v 的样本没有对齐到参考来源", record.Timestamp)
+		}
+	}
+
+	var report strings.Builder
+	printClockOffsets(&report, reference, offsets)
+	if !strings.Contains(report.String(), "b.txt: +30s") {
+		t.Errorf("偏移报告中没有 b.txt: +30s:\n%s", report.String())
+	}
+}
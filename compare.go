@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"atop_parser/atopparse"
+)
+
+// isDirectory 判断path是否为本地目录；不存在、远程地址和标准输入都按日志文件处理
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// parseComparePeriod 解析 --compare 指定的对比数据，目录按目录模式解析，其余按单个日志文件解析。
+// 对比数据只用于对比，不输出格式识别摘要
+func parseComparePeriod(path string, opts atopparse.ParseOptions) ([]atopparse.MemoryRecord, error) {
+	info := atopparse.NewDetectionInfo()
+	if isDirectory(path) {
+		return atopparse.ParseDirectory(path, opts, info)
+	}
+	return opts.Cache.Parse(path, opts, info)
+}
+
+// periodLabel 返回对比图例和对比表中使用的时段名称，取日志文件或目录的最后一级名称
+func periodLabel(logFile, dirPath string) string {
+	path := logFile
+	if path == "" {
+		path = dirPath
+	}
+	if path == atopparse.StdinPath {
+		return "stdin"
+	}
+	return filepath.Base(path)
+}
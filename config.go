@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configAliases 简写参数在配置文件中按对应的长名称处理
+var configAliases = map[string]string{
+	"f": "log_file",
+	"d": "dir",
+	"o": "output",
+}
+
+// configInputFlags 输入源参数。命令行指定了其中任何一个时忽略配置文件中的输入源，
+// 避免配置中的默认目录与命令行的 -f 同时生效
+var configInputFlags = []string{"log_file", "dir"}
+
+// loadConfig 读取 --config 指定的配置文件。文件为YAML（JSON是YAML的子集，同样可以读取），
+// 顶层为参数长名称（不带 --）到参数值的映射，例如 dir: /var/log/atop；
+// 可重复指定的参数（如 derive）写成列表。空文件返回空的映射
+func loadConfig(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(tr("无法读取配置文件: %v"), err)
+	}
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf(tr("解析配置文件 %s 失败: %v"), path, err)
+	}
+	return config, nil
+}
+
+// applyConfig 将配置中的值设置到命令行没有显式指定的参数上，命令行参数优先。
+// 配置中的参数名必须是已有的参数，值的格式与命令行相同
+func applyConfig(config map[string]interface{}, path string) error {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if long, ok := configAliases[name]; ok {
+			name = long
+		}
+		explicit[name] = true
+	})
+	for _, name := range configInputFlags {
+		if explicit[name] {
+			for _, input := range configInputFlags {
+				explicit[input] = true
+			}
+			break
+		}
+	}
+
+	// 按名称顺序设置，出错时报告的参数与配置文件中的顺序无关且稳定
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		name := key
+		if long, ok := configAliases[name]; ok {
+			name = long
+		}
+		f := flag.Lookup(name)
+		if f == nil || name == "config" {
+			return fmt.Errorf(tr("配置文件 %s 中的参数 %s 不存在"), path, key)
+		}
+		if explicit[name] {
+			continue
+		}
+
+		values, err := configValues(config[key])
+		if err != nil {
+			return fmt.Errorf(tr("配置文件 %s 中参数 %s 的值无效: %v"), path, key, err)
+		}
+		if _, repeatable := f.Value.(*deriveFlag); len(values) > 1 && !repeatable {
+			return fmt.Errorf(tr("配置文件 %s 中的参数 %s 不能指定多个值"), path, key)
+		}
+		for _, value := range values {
+			if err := flag.Set(name, value); err != nil {
+				return fmt.Errorf(tr("配置文件 %s 中参数 %s 的值无效: %v"), path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configValues 将配置中的值转换为命令行形式的字符串，列表中的每一项对应一次指定
+func configValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf(tr("值为空"))
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case int:
+		return []string{strconv.Itoa(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			converted, err := configValues(item)
+			if err != nil {
+				return nil, err
+			}
+			if len(converted) != 1 {
+				return nil, fmt.Errorf(tr("列表中不能嵌套列表"))
+			}
+			values = append(values, converted...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf(tr("不支持的值 %s"), strings.TrimSpace(fmt.Sprint(v)))
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// 非零退出时写到标准错误的退出原因代码
+const (
+	exitReasonInvalidArgs = "invalid_args" // 命令行参数错误
+	exitReasonParseError  = "parse_error"  // 读取或解析日志失败
+	exitReasonNoData      = "no_data"      // 没有可用的内存数据
+	exitReasonReportError = "report_error" // 生成报告文件失败
+	exitReasonServeError  = "serve_error"  // HTTP服务异常退出
+	exitReasonSchemaError = "schema_error" // --validate-schema 校验未通过
+	exitReasonInterrupted = "interrupted"  // 解析超过 --timeout 或被Ctrl-C中断，且没有指定 --partial
+
+	exitReasonThresholdBreached = "threshold_breached" // 数据越过了设定的阈值，退出码为2（--rules命中critical时为3）
+)
+
+// exitStatus 是写到标准错误的机器可读退出信息
+type exitStatus struct {
+	Exit    int    `json:"exit"`
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
+}
+
+// exitWith 在标准错误输出一行JSON格式的退出原因后以code退出，
+// 便于脚本不解析中文提示也能判断失败原因
+func exitWith(code int, reason string, message string) {
+	line, _ := json.Marshal(exitStatus{Exit: code, Reason: reason, Message: message})
+	fmt.Fprintln(os.Stderr, string(line))
+	os.Exit(code)
+}
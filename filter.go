@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayNames 星期缩写到time.Weekday的映射
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// timeWindow 描述每天的时段和每周的星期范围，用于只保留工作时间内的样本
+type timeWindow struct {
+	HasHours bool
+	Start    time.Duration         // 时段开始（距当天零点），包含
+	End      time.Duration         // 时段结束（距当天零点），不包含；小于Start时表示跨越午夜
+	Weekdays map[time.Weekday]bool // 为nil时不按星期过滤
+}
+
+// parseHoursRange 解析形如 09:00-18:00 的每日时段
+func parseHoursRange(value string) (time.Duration, time.Duration, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf(tr("无效的时段 %q，格式应为 HH:MM-HH:MM"), value)
+	}
+
+	var bounds [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return 0, 0, fmt.Errorf(tr("无效的时间 %q，格式应为 HH:MM"), part)
+		}
+		bounds[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if bounds[0] == bounds[1] {
+		return 0, 0, fmt.Errorf(tr("无效的时段 %q，开始和结束时间不能相同"), value)
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// parseWeekdays 解析形如 mon-fri 或 sat,sun 的星期列表，范围可以跨越周末（如 fri-mon）
+func parseWeekdays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, item := range strings.Split(strings.ToLower(value), ",") {
+		item = strings.TrimSpace(item)
+		bounds := strings.Split(item, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf(tr("无效的星期范围 %q"), item)
+		}
+
+		first, ok := weekdayNames[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf(tr("无效的星期 %q，可选 mon, tue, wed, thu, fri, sat, sun"), bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdayNames[bounds[1]]; !ok {
+				return nil, fmt.Errorf(tr("无效的星期 %q，可选 mon, tue, wed, thu, fri, sat, sun"), bounds[1])
+			}
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// rangeLayout 是--start和--end的时间格式，与CSV中的时间戳一致
+const rangeLayout = "2006-01-02 15:04:05"
+
+// parseRangeBound 解析--start或--end的时间，与日志时间戳一样按本地时间解析、不做时区转换
+func parseRangeBound(name, value string) (time.Time, error) {
+	t, err := time.Parse(rangeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf(tr("无效的 --%s %q，格式应为 %s"), name, value, rangeLayout)
+	}
+	return t, nil
+}
+
+// contains 判断时间点是否落在时段和星期范围内
+func (w timeWindow) contains(t time.Time) bool {
+	if w.Weekdays != nil && !w.Weekdays[t.Weekday()] {
+		return false
+	}
+	if !w.HasHours {
+		return true
+	}
+
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
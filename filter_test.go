@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHoursRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStart time.Duration
+		wantEnd   time.Duration
+		wantErr   bool
+	}{
+		{value: "09:00-18:00", wantStart: 9 * time.Hour, wantEnd: 18 * time.Hour},
+		{value: "22:30-06:00", wantStart: 22*time.Hour + 30*time.Minute, wantEnd: 6 * time.Hour},
+		{value: " 08:15 - 12:45 ", wantStart: 8*time.Hour + 15*time.Minute, wantEnd: 12*time.Hour + 45*time.Minute},
+		{value: "09:00-09:00", wantErr: true},
+		{value: "09:00", wantErr: true},
+		{value: "9am-5pm", wantErr: true},
+		{value: "09:00-25:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			start, end, err := parseHoursRange(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望出错 %t", err, tt.wantErr)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("时段为 %v-%v，期望 %v-%v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{value: "mon-fri", want: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{value: "sat,sun", want: []time.Weekday{time.Saturday, time.Sunday}},
+		{value: "fri-mon", want: []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+		{value: "Mon,WED", want: []time.Weekday{time.Monday, time.Wednesday}},
+		{value: "mon-tue-wed", wantErr: true},
+		{value: "monday", wantErr: true},
+		{value: "mon-xyz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			days, err := parseWeekdays(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望出错 %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := make(map[time.Weekday]bool)
+			for _, day := range tt.want {
+				want[day] = true
+			}
+			if !reflect.DeepEqual(days, want) {
+				t.Errorf("星期为 %v，期望 %v", days, want)
+			}
+		})
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	// 2024-06-14 为星期五，06-15、06-16 为周末
+	at := func(value string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04:05", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	workdays, err := parseWeekdays("mon-fri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	business := timeWindow{HasHours: true, Start: 9 * time.Hour, End: 18 * time.Hour, Weekdays: workdays}
+	overnight := timeWindow{HasHours: true, Start: 22 * time.Hour, End: 6 * time.Hour}
+
+	tests := []struct {
+		name   string
+		window timeWindow
+		at     string
+		want   bool
+	}{
+		{"start is included", business, "2024-06-14 09:00:00", true},
+		{"just before start", business, "2024-06-14 08:59:59", false},
+		{"just before end", business, "2024-06-14 17:59:59", true},
+		{"end is excluded", business, "2024-06-14 18:00:00", false},
+		{"saturday excluded", business, "2024-06-15 12:00:00", false},
+		{"sunday excluded", business, "2024-06-16 12:00:00", false},
+		{"weekdays only", timeWindow{Weekdays: workdays}, "2024-06-14 23:30:00", true},
+		{"weekend without hours", timeWindow{Weekdays: workdays}, "2024-06-15 00:00:00", false},
+		{"overnight before midnight", overnight, "2024-06-14 22:00:00", true},
+		{"overnight after midnight", overnight, "2024-06-15 05:59:59", true},
+		{"overnight end excluded", overnight, "2024-06-15 06:00:00", false},
+		{"overnight daytime", overnight, "2024-06-15 12:00:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.contains(at(tt.at)); got != tt.want {
+				t.Errorf("%s 在时段内为 %t，期望 %t", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusinessHoursFilterCLI(t *testing.T) {
+	var log strings.Builder
+	for _, ts := range []string{"2024/06/14  08:59:50", "2024/06/14  09:00:00", "2024/06/14  17:59:50", "2024/06/14  18:00:00", "2024/06/15  10:00:00"} {
+		log.WriteString("ATOP - web1  " + ts + "  --------  10s elapsed\nMEM | tot 16.0G | free 4.0G |\nSWP | tot 2.0G | free 1.5G |\n")
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantRows []string
+	}{
+		{"hours and weekdays", []string{"--hours", "09:00-18:00", "--weekdays", "mon-fri"}, 0, []string{"2024-06-14 09:00:00", "2024-06-14 17:59:50"}},
+		{"weekend only", []string{"--weekdays", "sat,sun"}, 0, []string{"2024-06-15 10:00:00"}},
+		{"nothing left", []string{"--hours", "20:00-21:00"}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", log.String())
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt", "--no-png", "--quiet", "--no-provenance"}, tt.args...)...)
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，输出:\n%s", result.Code, tt.wantCode, result.Stdout)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "memory_report.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rows []string
+			for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n")[1:] {
+				rows = append(rows, strings.SplitN(line, ",", 2)[0])
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("CSV中的记录为 %v，期望 %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
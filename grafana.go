@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// grafanaQueryRequest 是Grafana SimpleJSON数据源/query接口的请求体
+type grafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// grafanaTargets 是/search返回的可查询指标：各容量指标、已用内存/交换空间（GB）、使用率（%）和CPU百分比
+var grafanaTargets = append(atopparse.MetricNames[:len(atopparse.MetricNames):len(atopparse.MetricNames)],
+	"mem_used", "swp_used", "mem_used_pct", "swp_used_pct", "cpu_sys", "cpu_user", "cpu_idle")
+
+// grafanaValue 返回记录中target指标的值，记录没有该项数据（如没有CPU行）时ok为false
+func grafanaValue(record atopparse.MemoryRecord, target string) (float64, bool) {
+	switch target {
+	case "mem_used_pct":
+		return record.MemUsedPct(), true
+	case "swp_used_pct":
+		return record.SwapUsedPct(), true
+	case "cpu_sys":
+		return record.CPUSys, record.HasCPU
+	case "cpu_user":
+		return record.CPUUser, record.HasCPU
+	case "cpu_idle":
+		return record.CPUIdle, record.HasCPU
+	}
+	return atopparse.RuleMetricValue(record, target), true
+}
+
+// grafanaSeriesName 返回某个主机的指标序列在Grafana中的名称，例如 mem_free{host="web1"}；没有主机名时为指标名
+func grafanaSeriesName(target, host string) string {
+	if host == "" {
+		return target
+	}
+	return fmt.Sprintf("%s{host=%q}", target, host)
+}
+
+// grafanaSeries 是/query接口返回的单条时间序列，数据点为[值, 毫秒时间戳]
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// addGrafanaHandlers 在mux上注册实现Grafana SimpleJSON数据源协议的接口，数据为启动时解析的记录；
+// /query 为每个主机（RecordHost）返回单独的序列
+func addGrafanaHandlers(mux *http.ServeMux, data []atopparse.MemoryRecord) {
+	groups := atopparse.GroupByHost(data)
+	hosts := make([]string, 0, len(groups))
+	for host := range groups {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	// 数据源连通性测试；"/" 同时匹配所有未注册的路径，这些路径返回404
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		setGrafanaHeaders(w)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// 返回可查询的指标列表
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		setGrafanaHeaders(w)
+		json.NewEncoder(w).Encode(grafanaTargets)
+	})
+
+	// 按时间范围返回指标数据
+	mux.HandleFunc("/query", func(w http.ResponseWriter, r *http.Request) {
+		setGrafanaHeaders(w)
+		if r.Method == http.MethodOptions {
+			return
+		}
+
+		var req grafanaQueryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf(tr("无效的查询请求: %v"), err), http.StatusBadRequest)
+			return
+		}
+
+		series := make([]grafanaSeries, 0, len(req.Targets)*len(hosts))
+		for _, target := range req.Targets {
+			if !slices.Contains(grafanaTargets, target.Target) {
+				http.Error(w, fmt.Sprintf(tr("未知指标: %s"), target.Target), http.StatusBadRequest)
+				return
+			}
+
+			for _, host := range hosts {
+				points := [][2]float64{}
+				for _, record := range groups[host] {
+					if !req.Range.From.IsZero() && record.Timestamp.Before(req.Range.From) {
+						continue
+					}
+					if !req.Range.To.IsZero() && record.Timestamp.After(req.Range.To) {
+						continue
+					}
+					if value, ok := grafanaValue(record, target.Target); ok {
+						points = append(points, [2]float64{value, float64(record.Timestamp.UnixMilli())})
+					}
+				}
+				series = append(series, grafanaSeries{Target: grafanaSeriesName(target.Target, host), Datapoints: points})
+			}
+		}
+
+		json.NewEncoder(w).Encode(series)
+	})
+
+	// 暂不提供注释数据
+	mux.HandleFunc("/annotations", func(w http.ResponseWriter, r *http.Request) {
+		setGrafanaHeaders(w)
+		w.Write([]byte("[]"))
+	})
+}
+
+// setGrafanaHeaders 设置JSON响应头和允许Grafana跨域访问的CORS头
+func setGrafanaHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "accept, content-type")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+}
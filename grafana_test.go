@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// grafanaTestData 两个主机各两个样本，web2只有第一个样本带CPU数据
+func grafanaTestData() []atopparse.MemoryRecord {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	return []atopparse.MemoryRecord{
+		{Hostname: "web1", Timestamp: ts, MemTotal: 16, MemFree: 4, SwapTotal: 2, SwapFree: 1},
+		{Hostname: "web2", Timestamp: ts, MemTotal: 8, MemFree: 2, SwapTotal: 2, SwapFree: 2, HasCPU: true, CPUIdle: 90},
+		{Hostname: "web1", Timestamp: ts.Add(10 * time.Second), MemTotal: 16, MemFree: 3, SwapTotal: 2, SwapFree: 1},
+		{Hostname: "web2", Timestamp: ts.Add(10 * time.Second), MemTotal: 8, MemFree: 1, SwapTotal: 2, SwapFree: 2},
+	}
+}
+
+func TestGrafanaPaths(t *testing.T) {
+	mux := http.NewServeMux()
+	addGrafanaHandlers(mux, grafanaTestData())
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/", http.StatusOK},
+		{"GET", "/unknown", http.StatusNotFound},
+		{"GET", "/search/extra", http.StatusNotFound},
+		{"POST", "/search", http.StatusOK},
+		{"GET", "/annotations", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("状态码 %d，期望 %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestGrafanaSearch(t *testing.T) {
+	mux := http.NewServeMux()
+	addGrafanaHandlers(mux, grafanaTestData())
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/search", nil))
+
+	var targets []string
+	if err := json.NewDecoder(w.Body).Decode(&targets); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"mem_free", "mem_used", "swp_used", "mem_used_pct", "cpu_sys", "cpu_user", "cpu_idle"} {
+		if !slices.Contains(targets, target) {
+			t.Errorf("/search 缺少 %s: %v", target, targets)
+		}
+	}
+}
+
+func TestGrafanaQuery(t *testing.T) {
+	mux := http.NewServeMux()
+	addGrafanaHandlers(mux, grafanaTestData())
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   []grafanaSeries
+	}{
+		{
+			name:   "one series per host",
+			body:   `{"targets":[{"target":"mem_used"}]}`,
+			status: http.StatusOK,
+			want: []grafanaSeries{
+				{Target: `mem_used{host="web1"}`, Datapoints: [][2]float64{{12, 1718100000000}, {13, 1718100010000}}},
+				{Target: `mem_used{host="web2"}`, Datapoints: [][2]float64{{6, 1718100000000}, {7, 1718100010000}}},
+			},
+		},
+		{
+			name:   "cpu only where present",
+			body:   `{"targets":[{"target":"cpu_idle"}]}`,
+			status: http.StatusOK,
+			want: []grafanaSeries{
+				{Target: `cpu_idle{host="web1"}`, Datapoints: [][2]float64{}},
+				{Target: `cpu_idle{host="web2"}`, Datapoints: [][2]float64{{90, 1718100000000}}},
+			},
+		},
+		{
+			name:   "time range",
+			body:   `{"range":{"from":"2024-06-11T10:00:05Z","to":"2024-06-11T10:01:00Z"},"targets":[{"target":"swp_used"}]}`,
+			status: http.StatusOK,
+			want: []grafanaSeries{
+				{Target: `swp_used{host="web1"}`, Datapoints: [][2]float64{{1, 1718100010000}}},
+				{Target: `swp_used{host="web2"}`, Datapoints: [][2]float64{{0, 1718100010000}}},
+			},
+		},
+		{
+			name:   "unknown target",
+			body:   `{"targets":[{"target":"disk_busy"}]}`,
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("POST", "/query", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("状态码 %d，期望 %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var got []grafanaSeries
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("返回 %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
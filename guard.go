@@ -0,0 +1,144 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"atop_parser/atopparse"
+)
+
+// reportOutputs 返回atopparse.GenerateReport按opts可能写出的文件
+func reportOutputs(prefix string, opts atopparse.ReportOptions) []string {
+	var paths []string
+	if !opts.Stdout {
+		main := prefix + ".csv"
+		if opts.Format == "json" {
+			main = prefix + ".json"
+		}
+		paths = append(paths, atopparse.OutputName(main, opts.Gzip), prefix+"_summary.txt")
+	}
+	// 磁盘和网络统计CSV只在日志中有DSK或NET接口行时生成；--output - 时没有文件前缀，不生成
+	if prefix != atopparse.StdoutPath {
+		paths = append(paths, atopparse.OutputName(prefix+"_disk.csv", opts.Gzip), atopparse.OutputName(prefix+"_net.csv", opts.Gzip))
+	}
+	if opts.TidyCSV {
+		paths = append(paths, atopparse.OutputName(prefix+"_tidy.csv", opts.Gzip))
+	}
+	if opts.OpenMetrics {
+		paths = append(paths, prefix+"_openmetrics.txt")
+	}
+	if !opts.NoPNG {
+		for _, format := range atopparse.MemoryChartFormats(opts) {
+			paths = append(paths, prefix+"_memory_swap."+format)
+		}
+		paths = append(paths, prefix+"_usage_pct.png", prefix+"_psi.png", prefix+"_cpu.png", prefix+"_disk_busy.png", prefix+"_disk_throughput.png", prefix+"_net.png", prefix+"_swap_rate.png", prefix+"_thrash.png")
+		if len(opts.Derived) > 0 {
+			paths = append(paths, prefix+"_derived.png")
+		}
+		if opts.CPUHeatmap {
+			paths = append(paths, prefix+"_cpu_heatmap.png")
+		}
+	}
+	if opts.InteractiveSVG {
+		paths = append(paths, prefix+"_memory_swap.svg")
+	}
+	if opts.VegaLite {
+		paths = append(paths, prefix+"_memory_swap.vl.json")
+	}
+	if opts.HTML {
+		paths = append(paths, prefix+"_memory_swap.html")
+	}
+	if opts.Fold != "" {
+		paths = append(paths, prefix+"_fold_"+opts.Fold+".png")
+	}
+	if opts.Histogram {
+		paths = append(paths, prefix+"_histogram.png")
+	}
+	if opts.Markdown {
+		paths = append(paths, prefix+".md")
+	}
+	return paths
+}
+
+// compareOutputs 返回atopparse.GenerateComparison按opts可能写出的文件
+func compareOutputs(prefix string, opts atopparse.ReportOptions) []string {
+	paths := []string{prefix + "_compare.txt"}
+	if !opts.NoPNG {
+		paths = append(paths, prefix+"_compare.png")
+	}
+	return paths
+}
+
+// hostReportOutputs 返回atopparse.GenerateHostReports为data中各主机可能写出的文件
+func hostReportOutputs(data []atopparse.MemoryRecord, prefix string, opts atopparse.ReportOptions) []string {
+	// 单独报告的CSV总是写入文件
+	opts.Stdout = false
+	var paths []string
+	for _, hostPrefix := range atopparse.HostReportPrefixes(data, prefix) {
+		paths = append(paths, reportOutputs(hostPrefix, opts)...)
+	}
+	return paths
+}
+
+// compareInputs 返回 --compare 会读取的本地日志文件
+func compareInputs(path string, recursive bool) []string {
+	if isDirectory(path) {
+		return inputFiles("", path, recursive)
+	}
+	return inputFiles(path, "", recursive)
+}
+
+// inputFiles 返回本次运行会读取的本地日志文件，目录模式下按路径排序；远程地址和标准输入不计入
+func inputFiles(logFile, dirPath string, recursive bool) []string {
+	if logFile != "" {
+		if atopparse.IsRemoteLog(logFile) || logFile == atopparse.StdinPath {
+			return nil
+		}
+		return []string{logFile}
+	}
+
+	files, err := atopparse.ListLogFiles(dirPath, recursive, io.Discard)
+	if err != nil {
+		// 目录不存在、子目录无法读取等错误和警告由解析阶段报告
+		return nil
+	}
+	return files
+}
+
+// findOutputCollision 返回第一对指向同一文件的输入和输出路径。
+// 输出文件已存在时按文件身份比较（可识别符号链接和硬链接），否则比较绝对路径
+func findOutputCollision(inputs, outputs []string) (string, string, bool) {
+	for _, output := range outputs {
+		outputAbs, _ := filepath.Abs(output)
+		outputInfo, outputErr := os.Stat(output)
+		for _, input := range inputs {
+			if outputErr == nil {
+				if inputInfo, err := os.Stat(input); err == nil && os.SameFile(inputInfo, outputInfo) {
+					return input, output, true
+				}
+			}
+			if inputAbs, _ := filepath.Abs(input); inputAbs == outputAbs {
+				return input, output, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// findDuplicateOutput 返回added中第一个与outputs或added中之前的路径指向同一文件的输出，按绝对路径比较
+func findDuplicateOutput(outputs, added []string) (string, bool) {
+	seen := make(map[string]bool)
+	for _, output := range outputs {
+		abs, _ := filepath.Abs(output)
+		seen[abs] = true
+	}
+	for _, output := range added {
+		abs, _ := filepath.Abs(output)
+		if seen[abs] {
+			return output, true
+		}
+		seen[abs] = true
+	}
+	return "", false
+}
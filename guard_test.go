@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindOutputCollision(t *testing.T) {
+	dir := t.TempDir()
+	input := writeFile(t, dir, "atop.txt", sampleLog)
+	link := filepath.Join(dir, "link.csv")
+	if err := os.Symlink(input, link); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative, err := filepath.Rel(wd, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		outputs []string
+		want    string
+	}{
+		{"same path", []string{filepath.Join(dir, "report.csv"), input}, input},
+		{"relative and absolute path", []string{relative}, relative},
+		{"unclean path", []string{filepath.Join(dir, "sub", "..", "atop.txt")}, filepath.Join(dir, "sub", "..", "atop.txt")},
+		{"symlink to input", []string{link}, link},
+		{"no collision", []string{filepath.Join(dir, "report.csv"), filepath.Join(dir, "report_summary.txt")}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, output, found := findOutputCollision([]string{input}, tt.outputs)
+			if found != (tt.want != "") || output != tt.want {
+				t.Errorf("冲突的输出为 %q（找到 %t），期望 %q", output, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputCollisionCLI(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string // 写入dir的日志文件
+		args   []string
+		victim string // 会被覆盖的输入文件
+	}{
+		{"csv output over input file", []string{"report.csv"}, []string{"-f", "report.csv", "-o", "report"}, "report.csv"},
+		{"summary over log in directory", []string{"logs/atop.txt", "logs/report_summary.txt"}, []string{"-d", "logs", "-o", "logs/report", "--no-png"}, "logs/report_summary.txt"},
+		{"cache over input file", []string{"atop.txt"}, []string{"-f", "atop.txt", "--cache", "atop.txt"}, "atop.txt"},
+		{"host report over log in directory", []string{"logs/atop.txt", "logs/report_web1_summary.txt"}, []string{"-d", "logs", "-o", "logs/report", "--group-by-host", "--no-png"}, "logs/report_web1_summary.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "logs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.files {
+				writeFile(t, dir, name, sampleLog)
+			}
+
+			result := runCLI(t, dir, tt.args...)
+			if result.Code != 1 {
+				t.Fatalf("退出码 %d，期望 1，输出:\n%s", result.Code, result.Stdout)
+			}
+			if !strings.Contains(result.Stdout, "与输入文件") || !strings.Contains(result.Stderr, `"reason":"invalid_args"`) {
+				t.Errorf("没有报告输出与输入冲突:\n%s\n%s", result.Stdout, result.Stderr)
+			}
+			// 输入文件保持原样
+			content, err := os.ReadFile(filepath.Join(dir, tt.victim))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != sampleLog {
+				t.Errorf("输入文件 %s 被改写", tt.victim)
+			}
+		})
+	}
+}
+
+func TestHostOutputDuplicateCLI(t *testing.T) {
+	tests := []struct {
+		name string
+		host string // 日志中的主机名
+		args []string
+		want string // 重复写出的文件
+	}{
+		// 来源文件web1.txt和主机web1的单独报告使用同一前缀
+		{"per-file and host reports", "web1", []string{"-d", "logs", "--per-file-reports", "--group-by-host", "--no-png"}, "memory_report_web1.csv"},
+		// 主机disk的单独报告与合并报告的磁盘统计CSV同名
+		{"host report over disk csv", "disk", []string{"-d", "logs", "--group-by-host", "--no-png"}, "memory_report_disk.csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "logs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, dir, "logs/web1.txt", strings.ReplaceAll(sampleLog, "web1", tt.host))
+
+			result := runCLI(t, dir, tt.args...)
+			if result.Code != 1 {
+				t.Fatalf("退出码 %d，期望 1，输出:\n%s", result.Code, result.Stdout)
+			}
+			if !strings.Contains(result.Stdout, "--group-by-host 的输出文件 "+tt.want) || !strings.Contains(result.Stderr, `"reason":"invalid_args"`) {
+				t.Errorf("没有报告重复的输出文件 %s:\n%s\n%s", tt.want, result.Stdout, result.Stderr)
+			}
+			// 检查在写出任何报告之前进行
+			if _, err := os.Stat(filepath.Join(dir, "memory_report.csv")); !os.IsNotExist(err) {
+				t.Errorf("检查失败时不应写出报告: %v", err)
+			}
+		})
+	}
+}
+
+func TestHostReportsWithoutCollision(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "atop.txt", sampleLog)
+	result := runCLI(t, dir, "-f", "atop.txt", "--group-by-host", "--no-png")
+	if result.Code != 0 {
+		t.Fatalf("退出码 %d，期望 0，输出:\n%s\n%s", result.Code, result.Stdout, result.Stderr)
+	}
+	for _, name := range []string{"memory_report.csv", "memory_report_web1.csv", "memory_report_web1_summary.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("没有生成 %s: %v", name, err)
+		}
+	}
+}
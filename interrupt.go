@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// parseDirectoryInterruptible 解析目录，timeout大于0时到期后中止，收到Ctrl-C (SIGINT) 时也中止。
+// 中止时返回已解析的记录和包装了context.DeadlineExceeded或context.Canceled的错误；
+// 返回前恢复SIGINT的默认处理，之后再按Ctrl-C会直接结束进程
+func parseDirectoryInterruptible(dirPath string, opts atopparse.ParseOptions, info *atopparse.DetectionInfo, timeout time.Duration) ([]atopparse.MemoryRecord, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return atopparse.ParseDirectoryContext(ctx, dirPath, opts, info)
+}
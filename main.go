@@ -0,0 +1,1004 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// deriveFlag 收集可重复指定的--derive参数
+type deriveFlag []string
+
+func (d *deriveFlag) String() string { return strings.Join(*d, ";") }
+
+func (d *deriveFlag) Set(value string) error {
+	*d = append(*d, value)
+	return nil
+}
+
+func main() {
+	// 创建命令行参数解析器
+	configPath := flag.String("config", "", "YAML或JSON配置文件，键为参数的长名称，作为命令行没有指定的参数的默认值")
+	logFile := flag.String("log_file", "", "单个atop日志文件的路径，也可以是http://或https://地址，- 表示标准输入")
+	logFileShort := flag.String("f", "", "单个atop日志文件的路径，也可以是http://或https://地址，- 表示标准输入 (简写)")
+	dirPath := flag.String("dir", "", "包含多个atop日志文件的目录路径")
+	dirPathShort := flag.String("d", "", "包含多个atop日志文件的目录路径 (简写)")
+	recursive := flag.Bool("recursive", false, "目录模式下递归读取子目录中的日志文件（会进入指向目录的符号链接，同一目录只读取一次）")
+	outputPrefix := flag.String("output", "memory_report", "输出文件前缀 (默认: memory_report)")
+	stdout := flag.Bool("stdout", false, "将CSV写到标准输出，默认不生成PNG，诊断信息写到标准错误；为 --output - 的等价写法")
+	outputPrefixShort := flag.String("o", "", "输出文件前缀 (简写)")
+	sqlitePath := flag.String("sqlite", "", "在生成报告之外，将记录写入该SQLite数据库的 mem_records 表，按 (timestamp, hostname) 更新已有的行，便于跨多次运行用SQL查询")
+	prometheusPath := flag.String("prometheus", "", "在生成报告之外，将每个主机最新的记录以Prometheus文本格式写入该文件（如node_exporter textfile collector目录中的 atop.prom），带host标签")
+	prometheusAll := flag.Bool("prometheus-all", false, "--prometheus 写出全部记录并带时间戳，而不是每个主机最新的一条")
+	outDir := flag.String("outdir", "", "所有输出文件写到该目录（不存在时创建），--output 只作为文件名前缀")
+	generateHTML := flag.Bool("html", false, "生成交互式HTML报告，可查看每个时间点的详细数据")
+	compare := flag.String("compare", "", "与另一段采集数据（日志文件或目录）对比：生成两段已用内存叠加的图表 <前缀>_compare.png（各自从0开始计时）和平均值/峰值对比表 <前缀>_compare.txt")
+	thrashWeight := flag.Float64("thrash-weight", 0, "换页抖动分数 min(swin, swout) + W × |swin − swout| 中单向换页的权重W（0到1），0只计同时换入和换出的部分，1为两者中较大的一个")
+	compareMetric := flag.String("compare-metric", "", "--compare 叠加和对比的指标: used（已用内存，默认）、free（空闲内存）、swap_used（已用交换空间）或 used_pct（内存使用率）")
+	markdown := flag.Bool("markdown", false, "生成便于粘贴到工单中的Markdown报告 <前缀>.md")
+	htmlOffline := flag.Bool("html-offline", false, "HTML报告内联内嵌的Chart.js，不依赖CDN，适合无法访问互联网的环境（文件更大）")
+	htmlUsagePct := flag.Bool("html-usage-pct", false, "在HTML报告中附加内存/交换空间使用率(%)图表")
+	htmlAnomalyP := flag.Float64("html-anomaly-percentile", 95, "HTML报告中以醒目的颜色标出已用内存高于该百分位数(0-100)的数据点；0表示不标出")
+	timeout := flag.Duration("timeout", 0, "目录模式下解析的时间上限，例如 5m，超过后中止解析；0表示不限制。Ctrl-C 同样会中止解析")
+	partial := flag.Bool("partial", false, "解析超过 --timeout 或被 Ctrl-C 中断时，用已解析的记录继续生成报告，而不是退出")
+	failFast := flag.Bool("fail-fast", false, "目录模式下遇到第一个解析出错或没有有效数据的文件时立即退出")
+	strict := flag.Bool("strict", false, "遇到数值格式错误的字段时报告文件名和行号并退出，而不是丢弃对应的采样块")
+	quiet := flag.Bool("quiet", false, "不输出格式自动识别摘要")
+	seedFrom := flag.String("seed-from", "", "先载入之前生成的CSV，再与本次解析的记录合并（按主机和时间戳去重，以本次解析结果为准）")
+	appendCSV := flag.Bool("append", false, "追加到已有的 <前缀>.csv：先读入该文件，与本次解析的记录合并（按主机和时间戳去重，以本次为准）后整体重写；重写先写临时文件再改名")
+	retain := flag.String("retain", "", "与 --append 一起使用：只保留最新记录之前这段时间内的记录，例如 7d、36h，更早的行在重写时丢弃")
+	showTransitions := flag.Bool("transitions", false, "输出内存状态越过阈值的进入/恢复事件时间线")
+	transitionMemFree := flag.Float64("transition-mem-free", 1.0, "--transitions 中空闲内存低于该值(GB)视为内存紧张，0表示不跟踪")
+	transitionSwapUsed := flag.Float64("transition-swap-used", 0.5, "--transitions 中交换空间使用超过该值(GB)视为开始使用swap，0表示不跟踪")
+	format := flag.String("format", "csv", "主输出格式: csv (默认) 或 json (写到<前缀>.json，时间戳为ISO-8601格式)")
+	order := flag.String("order", "asc", "CSV行顺序: asc (按时间正序) 或 desc (最新的在前)")
+	showTrend := flag.Bool("trend", false, "对已用内存做线性回归，输出斜率(GB/小时)和R²，用于发现缓慢的内存泄漏")
+	trendSlope := flag.Float64("trend-slope", 0.01, "--trend 中斜率超过该值(GB/小时)且R²不低于--trend-r2时提示可能存在内存泄漏")
+	trendR2 := flag.Float64("trend-r2", 0.8, "--trend 中判定内存泄漏所需的最小R² (0-1)")
+	showSparkline := flag.Bool("sparkline", false, "在终端输出空闲内存和交换空间的迷你趋势图")
+	tidyCSV := flag.Bool("tidy-csv", false, "额外生成长格式CSV (timestamp,metric,device,value)，便于pandas/R分析")
+	noPNG := flag.Bool("no-png", false, "不生成PNG内存使用图表")
+	chartFormat := flag.String("chart-format", "png", "内存使用图表 <前缀>_memory_swap.<格式> 的格式: png、svg 或 pdf，多种格式用逗号分隔，例如 png,pdf")
+	palette := flag.String("palette", "default", "内存/交换空间图表的预设配色: default 或 colorblind (色盲友好的Okabe-Ito配色)")
+	colorMemTotal := flag.String("color-mem-total", "", "MEM Total 曲线的颜色，格式为 #RRGGBB，优先于 --palette")
+	colorMemFree := flag.String("color-mem-free", "", "MEM Free 曲线的颜色，格式为 #RRGGBB，优先于 --palette")
+	colorMemCache := flag.String("color-mem-cache", "", "MEM Cache 曲线的颜色，格式为 #RRGGBB，优先于 --palette")
+	colorMemBuff := flag.String("color-mem-buffers", "", "MEM Buffers 曲线的颜色，格式为 #RRGGBB，优先于 --palette")
+	colorSwapTotal := flag.String("color-swap-total", "", "SWAP Total 曲线的颜色，格式为 #RRGGBB，优先于 --palette")
+	colorSwapFree := flag.String("color-swap-free", "", "SWAP Free 曲线的颜色，格式为 #RRGGBB，优先于 --palette")
+	histogram := flag.Bool("histogram", false, "生成内存分布直方图PNG并输出各区间的样本数")
+	histogramMetric := flag.String("histogram-metric", "free", "直方图统计的指标: free (空闲内存) 或 used (已用内存)")
+	histogramBins := flag.Int("histogram-bins", 20, "直方图分桶数")
+	fold := flag.String("fold", "", "将每天(daily)或每周(weekly)的已用内存曲线叠加在同一坐标轴上，生成<前缀>_fold_<周期>.png")
+	var derive deriveFlag
+	flag.Var(&derive, "derive", "添加派生指标 name=expression，可重复指定；表达式可使用 mem_tot、mem_free、mem_used、mem_cache、mem_buff、swp_tot、swp_free、swp_used 和 + - * / 括号")
+	memFreeThreshold := flag.Float64("mem-free-threshold", 0, "空闲内存低于该值(GB)的样本视为越界，报告生成后以退出码2退出；0表示不检查")
+	swapFreeThreshold := flag.Float64("swap-free-threshold", 0, "空闲交换空间低于该值(GB)的样本视为越界（跳过未启用交换空间的样本），报告生成后以退出码2退出；0表示不检查")
+	startFlag := flag.String("start", "", "只保留不早于该时间的样本，格式 2006-01-02 15:04:05（包含）")
+	endFlag := flag.String("end", "", "只保留不晚于该时间的样本，格式 2006-01-02 15:04:05（包含）")
+	hours := flag.String("hours", "", "只保留每天指定时段内的样本，例如 09:00-18:00（开始包含、结束不包含）")
+	weekdays := flag.String("weekdays", "", "只保留指定星期的样本，例如 mon-fri 或 sat,sun")
+	httpTimeout := flag.Duration("http-timeout", 30*time.Second, "从HTTP(S)地址读取日志的超时时间")
+	memLines := flag.String("mem-lines", "first", "同一采样块出现多条MEM行时的处理方式: first (只保留第一条，即系统总量) 或 sum (累加各行)")
+	swapLines := flag.String("swap-lines", "sum", "同一采样块出现多条SWP行(多个交换设备)时的处理方式: sum (累加为总量) 或 first (只保留第一条)")
+	dateLayout := flag.String("date-layout", "", "日志头时间戳的解析格式（Go时间格式，例如 02-01-2006 15:04:05），默认自动识别")
+	trimWarmup := flag.Int("trim-warmup", 0, "每个日志文件开头丢弃的样本数，用于去掉atop刚启动时不可靠的数据")
+	detectRebootsFlag := flag.Bool("reboots", false, "检测疑似重启（长时间无数据后空闲内存大幅回升），在摘要中列出并在图表中标注")
+	rebootGap := flag.Duration("reboot-gap", 10*time.Minute, "判定重启所需的最小采样间隔")
+	rebootFreeJump := flag.Float64("reboot-free-jump", 1.0, "判定重启所需的空闲内存最小回升量(GB)")
+	aggregate := flag.String("aggregate", "", "按主机和时间戳聚合来自多个日志的记录，目前支持 mean (取平均值)")
+	noProvenance := flag.Bool("no-provenance", false, "不在CSV和HTML报告末尾记录工具版本和命令行")
+	checksum := flag.Bool("checksum", false, "计算各输入文件的SHA-256并写入<前缀>_inputs.json，同时记录在来源说明中")
+	validateSchema := flag.String("validate-schema", "", "只校验指定的CSV文件能否被 --seed-from 读取（列名和字段类型），报告第一个出错的行后退出")
+	topFiles := flag.Int("top-files", 0, "列出空闲内存最低的N个样本分别来自哪些日志文件，0表示不输出")
+	noSort := flag.Bool("no-sort", false, "目录模式下假定按文件名顺序合并的记录已按时间排列而跳过排序；检查发现乱序时仍会排序")
+	assumeSorted := flag.Bool("assume-sorted", false, "流式模式（仅目录模式）：假定按文件名顺序各文件的记录已按时间排列，逐个文件解析并立即写入CSV，内存中只保留一个文件的记录，适合一个月以上的日志。代价是不做全局排序（乱序时只警告，CSV中的顺序即文件顺序），且只生成CSV，不能与图表、统计、过滤等需要全部记录的参数同时使用")
+	dedup := flag.Bool("dedup", false, "目录模式下合并同一主机时间戳相同的记录（日志轮转重叠），只保留文件顺序中的第一条，并报告去除的条数")
+	clockSkew := flag.Bool("clock-skew", false, "比较多个日志文件重叠时段的内存曲线，估计各文件之间的时钟偏移")
+	alignClocksFlag := flag.Bool("align-clocks", false, "按估计的时钟偏移校正各日志文件的时间戳（隐含 --clock-skew）")
+	maxClockSkew := flag.Duration("max-clock-skew", 5*time.Minute, "估计时钟偏移时搜索的最大偏移量")
+	rulesPath := flag.String("rules", "", "YAML规则文件，定义多条带级别的阈值规则；有越界时按命中的最高级别退出（warning为2，critical为3）")
+	breachesOnly := flag.Bool("breaches-only", false, "只输出阈值越界的时间窗口（阈值同 --transition-mem-free/--transition-swap-used），不生成完整报告；有越界时退出码为2")
+	breachesFormat := flag.String("breaches-format", "csv", "--breaches-only 的输出格式: csv 或 json")
+	htmlPaginate := flag.Int("html-paginate", 0, "HTML报告每页的样本数，超过时拆分为多个带上一页/下一页导航的页面；0表示不分页")
+	vega := flag.Bool("vega", false, "额外生成内联数据的Vega-Lite规范 <前缀>_memory_swap.vl.json，可在Vega编辑器或其他工具中重新设置样式")
+	svgInteractive := flag.Bool("svg-interactive", false, "额外生成 <前缀>_memory_swap.svg，悬停在数据点上时由浏览器显示提示，不依赖JavaScript")
+	relativeAxis := flag.Bool("relative-axis", false, "PNG图表的X轴标注为距第一个样本的经过时间 HH:MM:SS，并在CSV末尾追加 elapsed 列，适用于基准测试")
+	maxPoints := flag.Int("max-points", 2000, "PNG和HTML图表每个图最多绘制的点数，超过时分桶平均降采样（保留首尾样本），CSV仍为完整数据；0表示不降采样")
+	cpuHeatmap := flag.Bool("cpu-heatmap", false, "日志中有单核cpu行时生成各核心利用率的热力图 <前缀>_cpu_heatmap.png（X轴为时间，Y轴为核心）")
+	noMemAvail := flag.Bool("no-mem-avail", false, "PNG和HTML内存图表中不绘制估算可用内存（free + cache + buff + slrec）曲线")
+	smooth := flag.Int("smooth", 0, "对PNG和HTML内存图表的曲线做N点居中移动平均，CSV仍为原始数据；0或1表示不平滑")
+	gzipOutput := flag.Bool("gzip-output", false, "CSV（含长格式CSV）和--breaches-only的CSV/JSON输出经gzip压缩，文件名追加.gz；PNG和HTML不压缩")
+	perFileReports := flag.Bool("per-file-reports", false, "目录模式下除合并报告外，再为每个日志文件单独生成一份报告，前缀为 <前缀>_<文件名>")
+	groupByHost := flag.Bool("group-by-host", false, "除合并报告外，再为日志头中的每个主机单独生成一份报告，前缀为 <前缀>_<主机名>；日志头没有主机名时按文件名区分")
+	precision := flag.Int("precision", 2, "CSV和JSON输出中数值（GB）保留的小数位数，图表和统计始终使用完整精度")
+	columns := flag.String("columns", "", "CSV输出的列及其顺序，逗号分隔，例如 timestamp,mem_free,swp_free；默认输出全部列")
+	delimiter := flag.String("delimiter", ",", "CSV的分隔符，例如 ; ，\\t 表示制表符")
+	openMetrics := flag.Bool("openmetrics", false, "额外生成带时间戳的OpenMetrics文本 <前缀>_openmetrics.txt，可用于向时序数据库回填历史数据")
+	localeFlag := flag.String("locale", atopparse.Locale, "控制台消息的语言: zh 或 en，默认按 LANG 环境变量推断")
+	workers := flag.Int("workers", runtime.NumCPU(), "目录模式下同时解析的文件数，默认为CPU核心数")
+	maxConcurrency := flag.Int("max-concurrency", runtime.NumCPU(), "目录模式下同时解析的文件数的上限，优先于 --workers，默认为CPU核心数；1表示逐个文件串行解析")
+	skipEmpty := flag.Bool("skip-empty", false, "目录模式下不逐个提示没有有效数据的文件（仍会计数并在最后汇总）")
+	verbose := flag.Bool("verbose", false, "输出更详细的过程信息，包括 --skip-empty 隐藏的逐文件提示")
+	journald := flag.Bool("journald", false, "输入为 journalctl -u atop 的输出（short、short-iso、cat 或 export 格式），解析前去掉每行的journald前缀")
+	cachePath := flag.String("cache", "", "解析结果缓存文件（如 atop.gob），按源文件修改时间和大小判断，只重新解析有变化的文件")
+	maxGap := flag.Float64("max-gap", 0, "图表中相邻样本相隔超过采样间隔的该倍数时断开折线并列出缺口，例如 3；0表示不处理，优先于 --interpolate-gaps-upto 的断开长度")
+	interpolateGaps := flag.Duration("interpolate-gaps-upto", 0, "图表中不超过该长度的数据缺口线性插值填补，更长的缺口断开折线并列出，例如 2m；0表示不处理")
+	serveAddr := flag.String("serve", "", "不生成报告文件，而是在指定地址启动HTTP服务，例如 :8080：/report?dir=... 按请求解析目录并返回HTML报告，/data.json 返回JSON记录，同时提供Grafana SimpleJSON数据源接口")
+	serveRoot := flag.String("serve-root", "", "--serve 的 dir 参数所在的根目录，dir 为相对于该目录的路径；不指定时不接受 dir 参数")
+
+	// 解析命令行参数
+	flag.Usage = printUsage
+	flag.Parse()
+
+	// 进度和诊断信息的输出位置，作为管道过滤器使用时改为标准错误
+	var console io.Writer = os.Stdout
+
+	// 配置文件中的值只用于命令行没有显式指定的参数
+	if *configPath != "" {
+		config, err := loadConfig(*configPath)
+		if err == nil {
+			err = applyConfig(config, *configPath)
+		}
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: %v\n"), err)
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+
+	if *localeFlag != "zh" && *localeFlag != "en" {
+		fmt.Fprintf(console, tr("错误: 不支持的语言 %s，可选 zh 或 en\n"), *localeFlag)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的语言 %s"), *localeFlag))
+	}
+	atopparse.Locale = *localeFlag
+
+	// 处理简写参数
+	if *logFileShort != "" && *logFile == "" {
+		*logFile = *logFileShort
+	}
+	if *dirPathShort != "" && *dirPath == "" {
+		*dirPath = *dirPathShort
+	}
+	if *outputPrefixShort != "" {
+		*outputPrefix = *outputPrefixShort
+	}
+
+	// 作为管道过滤器使用时，标准输出只留给CSV
+	if *outputPrefix == atopparse.StdoutPath {
+		*stdout = true
+	}
+	if *stdout {
+		console = os.Stderr
+	}
+
+	// 只校验之前生成的CSV
+	if *validateSchema != "" {
+		records, err := atopparse.ReadRecordsCSV(*validateSchema)
+		if err != nil {
+			fmt.Fprintf(console, tr("校验失败: %v\n"), err)
+			exitWith(1, exitReasonSchemaError, err.Error())
+		}
+		fmt.Fprintf(console, tr("校验通过: %s 共 %d 条记录\n"), *validateSchema, len(records))
+		return
+	}
+
+	// 检查必需参数；--serve 可以不指定输入，只按请求解析目录
+	if *logFile == "" && *dirPath == "" && *serveAddr == "" {
+		fmt.Fprintln(console, tr("错误: 必须指定 --log_file (-f) 或 --dir (-d) 参数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("必须指定 --log_file (-f) 或 --dir (-d) 参数"))
+	}
+
+	// 确保不同时指定两个输入源
+	if *logFile != "" && *dirPath != "" {
+		fmt.Fprintln(console, tr("错误: --log_file 和 --dir 参数不能同时使用"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--log_file 和 --dir 参数不能同时使用"))
+	}
+
+	// 标准输入读完后无法再计算校验和
+	if *checksum && *logFile == atopparse.StdinPath {
+		fmt.Fprintln(console, tr("错误: --checksum 不能用于标准输入 (-f -)"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--checksum 不能用于标准输入 (-f -)"))
+	}
+
+	if *perFileReports && *dirPath == "" {
+		fmt.Fprintln(console, tr("错误: --per-file-reports 只能用于目录模式 (-d)"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--per-file-reports 只能用于目录模式 (-d)"))
+	}
+
+	if *prometheusAll && *prometheusPath == "" {
+		fmt.Fprintln(console, tr("错误: --prometheus-all 需要同时指定 --prometheus"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--prometheus-all 需要同时指定 --prometheus"))
+	}
+
+	if *compare != "" && (*serveAddr != "" || *breachesOnly) {
+		fmt.Fprintln(console, tr("错误: --compare 不能与 --serve 或 --breaches-only 同时使用"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--compare 不能与 --serve 或 --breaches-only 同时使用"))
+	}
+
+	var retainWindow time.Duration
+	if *retain != "" {
+		if !*appendCSV {
+			fmt.Fprintln(console, tr("错误: --retain 需要同时指定 --append"))
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, tr("--retain 需要同时指定 --append"))
+		}
+		var err error
+		if retainWindow, err = atopparse.ParseRetention(*retain); err != nil {
+			fmt.Fprintf(console, tr("错误: --retain %v\n"), err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+	// 追加时要能读回已有的CSV，因此只支持写到文件的、逗号分隔的完整宽格式CSV
+	if *appendCSV && (*stdout || *outputPrefix == atopparse.StdoutPath || *format != "csv" || *columns != "" || *delimiter != "," || *serveAddr != "" || *breachesOnly) {
+		fmt.Fprintln(console, tr("错误: --append 不能与 --stdout、--output -、--format json、--columns、--delimiter、--serve 或 --breaches-only 同时使用"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--append 不能与 --stdout、--output -、--format json、--columns、--delimiter、--serve 或 --breaches-only 同时使用"))
+	}
+
+	if *thrashWeight < 0 || *thrashWeight > 1 {
+		fmt.Fprintln(console, tr("错误: --thrash-weight 必须在 0 到 1 之间"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--thrash-weight 必须在 0 到 1 之间"))
+	}
+	if *compareMetric != "" && *compare == "" {
+		fmt.Fprintln(console, tr("错误: --compare-metric 需要同时指定 --compare"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--compare-metric 需要同时指定 --compare"))
+	}
+	if err := atopparse.ValidateCompareMetric(*compareMetric); err != nil {
+		fmt.Fprintf(console, tr("错误: --compare-metric %v\n"), err)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, err.Error())
+	}
+
+	if *serveRoot != "" {
+		if *serveAddr == "" {
+			fmt.Fprintln(console, tr("错误: --serve-root 需要同时指定 --serve"))
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, tr("--serve-root 需要同时指定 --serve"))
+		}
+		root, err := filepath.Abs(*serveRoot)
+		if err == nil {
+			var info os.FileInfo
+			if info, err = os.Stat(root); err == nil && !info.IsDir() {
+				err = fmt.Errorf(tr("%s 不是目录"), root)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: 无效的 --serve-root: %v\n"), err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("无效的 --serve-root: %v"), err))
+		}
+		*serveRoot = root
+	}
+
+	if (*timeout != 0 || *partial) && *dirPath == "" {
+		fmt.Fprintln(console, tr("错误: --timeout 和 --partial 只能用于目录模式 (-d)"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--timeout 和 --partial 只能用于目录模式 (-d)"))
+	}
+	if *timeout < 0 {
+		fmt.Fprintln(console, tr("错误: --timeout 不能为负数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--timeout 不能为负数"))
+	}
+
+	if *assumeSorted && *dirPath == "" {
+		fmt.Fprintln(console, tr("错误: --assume-sorted 只能用于目录模式 (-d)"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--assume-sorted 只能用于目录模式 (-d)"))
+	}
+	if *assumeSorted {
+		if name, found := unsupportedStreamFlag(); found {
+			fmt.Fprintf(console, tr("错误: --assume-sorted 流式模式只生成CSV，不支持 --%s\n"), name)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("--assume-sorted 不支持 --%s"), name))
+		}
+	}
+
+	if *recursive && *dirPath == "" {
+		fmt.Fprintln(console, tr("错误: --recursive 只能用于目录模式 (-d)"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--recursive 只能用于目录模式 (-d)"))
+	}
+
+	if *perFileReports && *aggregate != "" {
+		fmt.Fprintln(console, tr("错误: --per-file-reports 不能与 --aggregate 同时使用，聚合后的记录不再区分来源文件"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--per-file-reports 不能与 --aggregate 同时使用"))
+	}
+
+	if *dedup && *aggregate != "" {
+		fmt.Fprintln(console, tr("错误: --dedup 不能与 --aggregate 同时使用，聚合依赖同一主机不同日志中时间戳相同的记录"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--dedup 不能与 --aggregate 同时使用"))
+	}
+
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(console, tr("错误: 不支持的输出格式 %s，可选 csv 或 json\n"), *format)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的输出格式 %s"), *format))
+	}
+
+	if *order != "asc" && *order != "desc" {
+		fmt.Fprintf(console, tr("错误: 不支持的排序方式 %s，可选 asc 或 desc\n"), *order)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的排序方式 %s"), *order))
+	}
+
+	if *memLines != "first" && *memLines != "sum" {
+		fmt.Fprintf(console, tr("错误: 不支持的MEM行处理方式 %s，可选 first 或 sum\n"), *memLines)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的MEM行处理方式 %s"), *memLines))
+	}
+
+	if *aggregate != "" && *aggregate != "mean" {
+		fmt.Fprintf(console, tr("错误: 不支持的聚合方式 %s，可选 mean\n"), *aggregate)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的聚合方式 %s"), *aggregate))
+	}
+
+	if *swapLines != "sum" && *swapLines != "first" {
+		fmt.Fprintf(console, tr("错误: 不支持的SWP行处理方式 %s，可选 sum 或 first\n"), *swapLines)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的SWP行处理方式 %s"), *swapLines))
+	}
+
+	if *breachesFormat != "csv" && *breachesFormat != "json" {
+		fmt.Fprintf(console, tr("错误: 不支持的越界摘要格式 %s，可选 csv 或 json\n"), *breachesFormat)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的越界摘要格式 %s"), *breachesFormat))
+	}
+
+	if *precision < 0 {
+		fmt.Fprintln(console, tr("错误: --precision 不能为负数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--precision 不能为负数"))
+	}
+
+	if *htmlOffline && !atopparse.ChartJSEmbedded() {
+		fmt.Fprintln(console, tr("错误: 此版本构建时未内嵌 Chart.js，无法使用 --html-offline（构建前运行 go generate ./atopparse 下载）"))
+		exitWith(1, exitReasonInvalidArgs, tr("未内嵌 Chart.js"))
+	}
+
+	if *htmlAnomalyP < 0 || *htmlAnomalyP > 100 {
+		fmt.Fprintln(console, tr("错误: --html-anomaly-percentile 必须在 0 到 100 之间"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--html-anomaly-percentile 必须在 0 到 100 之间"))
+	}
+	if *htmlPaginate < 0 {
+		fmt.Fprintln(console, tr("错误: --html-paginate 不能为负数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--html-paginate 不能为负数"))
+	}
+
+	if *trimWarmup < 0 {
+		fmt.Fprintln(console, tr("错误: --trim-warmup 不能为负数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--trim-warmup 不能为负数"))
+	}
+
+	if *trendR2 < 0 || *trendR2 > 1 {
+		fmt.Fprintln(console, tr("错误: --trend-r2 必须在 0 到 1 之间"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--trend-r2 必须在 0 到 1 之间"))
+	}
+
+	if *memFreeThreshold < 0 || *swapFreeThreshold < 0 {
+		fmt.Fprintln(console, tr("错误: --mem-free-threshold 和 --swap-free-threshold 不能为负数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--mem-free-threshold 和 --swap-free-threshold 不能为负数"))
+	}
+
+	if *maxPoints < 0 || *maxPoints == 1 {
+		fmt.Fprintln(console, tr("错误: --max-points 必须为0或不小于2"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--max-points 必须为0或不小于2"))
+	}
+
+	if *maxGap < 0 || (*maxGap > 0 && *maxGap <= 1) {
+		fmt.Fprintln(console, tr("错误: --max-gap 必须为0或大于1"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--max-gap 必须为0或大于1"))
+	}
+
+	if *smooth < 0 {
+		fmt.Fprintln(console, tr("错误: --smooth 不能为负数"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--smooth 不能为负数"))
+	}
+
+	if *workers <= 0 {
+		fmt.Fprintln(console, tr("错误: --workers 必须大于0"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--workers 必须大于0"))
+	}
+	if *maxConcurrency <= 0 {
+		fmt.Fprintln(console, tr("错误: --max-concurrency 必须大于0"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--max-concurrency 必须大于0"))
+	}
+
+	if *fold != "" && *fold != "daily" && *fold != "weekly" {
+		fmt.Fprintf(console, tr("错误: 不支持的折叠周期 %s，可选 daily 或 weekly\n"), *fold)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的折叠周期 %s"), *fold))
+	}
+
+	if *histogram {
+		if *histogramMetric != "free" && *histogramMetric != "used" {
+			fmt.Fprintf(console, tr("错误: 不支持的直方图指标 %s，可选 free 或 used\n"), *histogramMetric)
+			exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("不支持的直方图指标 %s"), *histogramMetric))
+		}
+		if *histogramBins <= 0 {
+			fmt.Fprintln(console, tr("错误: --histogram-bins 必须大于0"))
+			exitWith(1, exitReasonInvalidArgs, tr("--histogram-bins 必须大于0"))
+		}
+	}
+
+	var rangeStart, rangeEnd time.Time
+	if *startFlag != "" {
+		var err error
+		if rangeStart, err = parseRangeBound("start", *startFlag); err != nil {
+			fmt.Fprintf(console, tr("错误: %v\n"), err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+	if *endFlag != "" {
+		var err error
+		if rangeEnd, err = parseRangeBound("end", *endFlag); err != nil {
+			fmt.Fprintf(console, tr("错误: %v\n"), err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+	if !rangeStart.IsZero() && !rangeEnd.IsZero() && rangeEnd.Before(rangeStart) {
+		fmt.Fprintf(console, tr("错误: --end %s 早于 --start %s\n"), *endFlag, *startFlag)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--end 早于 --start"))
+	}
+
+	var window timeWindow
+	if *hours != "" {
+		start, end, err := parseHoursRange(*hours)
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: %v\n"), err)
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+		window.HasHours = true
+		window.Start = start
+		window.End = end
+	}
+	if *weekdays != "" {
+		days, err := parseWeekdays(*weekdays)
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: %v\n"), err)
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+		window.Weekdays = days
+	}
+
+	opts := atopparse.ParseOptions{
+		FailFast:       *failFast,
+		TrimWarmup:     *trimWarmup,
+		DateLayout:     *dateLayout,
+		HTTPTimeout:    *httpTimeout,
+		MemLines:       *memLines,
+		SwapLines:      *swapLines,
+		NoSort:         *noSort,
+		Dedup:          *dedup,
+		Journald:       *journald,
+		SkipEmpty:      *skipEmpty && !*verbose,
+		Recursive:      *recursive,
+		Workers:        *workers,
+		MaxConcurrency: *maxConcurrency,
+		Strict:         *strict,
+		Log:            console,
+	}
+	if *cachePath != "" {
+		opts.Cache = atopparse.LoadRecordCache(*cachePath, opts)
+	}
+
+	report := atopparse.ReportOptions{
+		HTML:            *generateHTML,
+		NoPNG:           *noPNG || *stdout,
+		TidyCSV:         *tidyCSV,
+		Precision:       *precision,
+		Gzip:            *gzipOutput,
+		OpenMetrics:     *openMetrics,
+		InteractiveSVG:  *svgInteractive,
+		VegaLite:        *vega,
+		HTMLPageSize:    *htmlPaginate,
+		Descending:      *order == "desc",
+		Histogram:       *histogram,
+		HistogramMetric: *histogramMetric,
+		HistogramBins:   *histogramBins,
+		Fold:            *fold,
+		Stdout:          *stdout,
+		Format:          *format,
+		HTMLUsagePct:    *htmlUsagePct,
+		HTMLOffline:     *htmlOffline,
+		HTMLAnomalyP:    *htmlAnomalyP,
+		Markdown:        *markdown,
+		CompareMetric:   *compareMetric,
+		ThrashWeight:    *thrashWeight,
+		CPUHeatmap:      *cpuHeatmap,
+		Log:             console,
+	}
+	// --output - 时没有前缀可用于其他输出文件
+	if *outputPrefix == atopparse.StdoutPath && (len(reportOutputs(*outputPrefix, report)) > 0 || *compare != "" || *perFileReports || *groupByHost || *checksum) {
+		fmt.Fprintln(console, tr("错误: --output - 只能输出CSV，需要其他文件时请使用 --stdout 并用 --output 指定前缀"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--output - 不能与生成其他文件的参数同时使用"))
+	}
+	if len(derive) > 0 {
+		var err error
+		report.Derived, err = atopparse.ParseDerivedSeries(derive)
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: --derive %v\n"), err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+	if *columns != "" {
+		if *format == "json" {
+			fmt.Fprintln(console, tr("错误: --columns 只能用于CSV输出，不能与 --format json 同时使用"))
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, tr("--columns 不能与 --format json 同时使用"))
+		}
+		report.Columns = strings.Split(*columns, ",")
+		// elapsed列是否可选取决于--relative-axis，此时report.Chart尚未设置
+		check := report
+		check.Chart.RelativeAxis = *relativeAxis
+		if err := atopparse.ValidateCSVColumns(check); err != nil {
+			fmt.Fprintf(console, tr("错误: --columns %v\n"), err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+	if formats, err := atopparse.ParseChartFormats(*chartFormat); err != nil {
+		fmt.Fprintf(console, tr("错误: --chart-format %v\n"), err)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, err.Error())
+	} else {
+		report.ChartFormats = formats
+	}
+	// 两者写入同一个 <前缀>_memory_swap.svg
+	if *svgInteractive && slices.Contains(report.ChartFormats, "svg") {
+		fmt.Fprintln(console, tr("错误: --chart-format svg 不能与 --svg-interactive 同时使用，两者都写入 <前缀>_memory_swap.svg"))
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, tr("--chart-format svg 不能与 --svg-interactive 同时使用"))
+	}
+	if comma, err := atopparse.ParseCSVDelimiter(*delimiter); err != nil {
+		fmt.Fprintf(console, tr("错误: --delimiter %v\n"), err)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, err.Error())
+	} else {
+		report.Delimiter = comma
+	}
+
+	// 先取预设配色，再用单独指定的颜色覆盖
+	var colors atopparse.LineColors
+	if preset, err := atopparse.PaletteColors(*palette); err != nil {
+		fmt.Fprintf(console, tr("错误: --palette %v\n"), err)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, err.Error())
+	} else {
+		colors = preset
+	}
+	for _, c := range []struct {
+		name  string
+		value string
+		field *color.RGBA
+	}{
+		{"color-mem-total", *colorMemTotal, &colors.MemTotal},
+		{"color-mem-free", *colorMemFree, &colors.MemFree},
+		{"color-mem-cache", *colorMemCache, &colors.MemCache},
+		{"color-mem-buffers", *colorMemBuff, &colors.MemBuff},
+		{"color-swap-total", *colorSwapTotal, &colors.SwapTotal},
+		{"color-swap-free", *colorSwapFree, &colors.SwapFree},
+	} {
+		if c.value == "" {
+			continue
+		}
+		value, err := atopparse.ParseHexColor(c.value)
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: --%s %v\n"), c.name, err)
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+		*c.field = value
+	}
+
+	var rules []rule
+	if *rulesPath != "" {
+		var err error
+		rules, err = loadRules(*rulesPath)
+		if err != nil {
+			fmt.Fprintf(console, tr("错误: %v\n"), err)
+			exitWith(1, exitReasonInvalidArgs, err.Error())
+		}
+	}
+
+	// 输出目录在解析前创建，无法创建时立即退出，不浪费解析时间
+	if *outDir != "" {
+		if *outputPrefix == atopparse.StdoutPath {
+			fmt.Fprintln(console, tr("错误: --outdir 不能与 --output - 同时使用"))
+			flag.Usage()
+			exitWith(1, exitReasonInvalidArgs, tr("--outdir 不能与 --output - 同时使用"))
+		}
+		*outputPrefix = filepath.Join(*outDir, *outputPrefix)
+		if err := os.MkdirAll(filepath.Dir(*outputPrefix), 0755); err != nil {
+			fmt.Fprintf(console, tr("错误: 无法创建输出目录 %s: %v\n"), *outDir, err)
+			exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("无法创建输出目录 %s: %v"), *outDir, err))
+		}
+	}
+
+	// 拒绝会覆盖输入文件的输出路径，避免误删原始日志
+	inputs := inputFiles(*logFile, *dirPath, *recursive)
+	if *seedFrom != "" {
+		inputs = append(inputs, *seedFrom)
+	}
+	if *compare != "" {
+		inputs = append(inputs, compareInputs(*compare, *recursive)...)
+	}
+	var outputs []string
+	switch {
+	case *serveAddr != "":
+	case *breachesOnly:
+		outputs = append(outputs, atopparse.OutputName(*outputPrefix+"_breaches."+*breachesFormat, *gzipOutput))
+	default:
+		outputs = reportOutputs(*outputPrefix, report)
+		if *perFileReports {
+			used := make(map[string]bool)
+			for _, input := range inputs {
+				outputs = append(outputs, reportOutputs(atopparse.PerFilePrefix(*outputPrefix, input, used), report)...)
+			}
+		}
+		if *compare != "" {
+			outputs = append(outputs, compareOutputs(*outputPrefix, report)...)
+		}
+	}
+	if *cachePath != "" {
+		outputs = append(outputs, *cachePath)
+	}
+	if *sqlitePath != "" {
+		outputs = append(outputs, *sqlitePath)
+	}
+	if *prometheusPath != "" {
+		outputs = append(outputs, *prometheusPath)
+	}
+	if *checksum {
+		outputs = append(outputs, *outputPrefix+"_inputs.json")
+	}
+	if input, output, found := findOutputCollision(inputs, outputs); found {
+		fmt.Fprintf(console, tr("错误: 输出文件 %s 与输入文件 %s 相同，请修改 --output 前缀\n"), output, input)
+		flag.Usage()
+		exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("输出文件 %s 会覆盖输入文件 %s"), output, input))
+	}
+
+	if *assumeSorted {
+		report.Chart = atopparse.ChartOptions{RelativeAxis: *relativeAxis}
+		if !*noProvenance {
+			report.Provenance = provenanceText()
+		}
+		runStream(console, *dirPath, *outputPrefix, opts, report, *quiet)
+		return
+	}
+
+	if *serveAddr != "" && *logFile == "" && *dirPath == "" {
+		report.Chart = atopparse.ChartOptions{MaxFillGap: *interpolateGaps, MaxGap: *maxGap, RelativeAxis: *relativeAxis, Smooth: *smooth, MaxPoints: *maxPoints, Colors: colors, HideMemAvail: *noMemAvail}
+		runServe(console, *serveAddr, *serveRoot, nil, opts, report)
+		return
+	}
+
+	var data []atopparse.MemoryRecord
+	var err error
+	info := atopparse.NewDetectionInfo()
+
+	try := func() {
+		// 根据输入类型选择解析方法
+		if *logFile != "" {
+			fmt.Fprintf(console, tr("解析单个日志文件: %s\n"), *logFile)
+			data, err = opts.Cache.Parse(*logFile, opts, info)
+			if err != nil {
+				fmt.Fprintf(console, tr("错误: %v\n"), err)
+				exitWith(1, exitReasonParseError, err.Error())
+			}
+		} else {
+			fmt.Fprintf(console, tr("解析目录中的所有日志文件: %s\n"), *dirPath)
+			data, err = parseDirectoryInterruptible(*dirPath, opts, info, *timeout)
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				if !*partial {
+					fmt.Fprintf(console, tr("错误: 解析未完成（%v），已解析 %d 条记录；指定 --partial 可用这些记录生成报告\n"), err, len(data))
+					exitWith(1, exitReasonInterrupted, err.Error())
+				}
+				fmt.Fprintf(console, tr("警告: 解析未完成（%v），使用已解析的 %d 条记录生成报告\n"), err, len(data))
+				err = nil
+			}
+			if err != nil {
+				fmt.Fprintf(console, tr("错误: %v\n"), err)
+				exitWith(1, exitReasonParseError, err.Error())
+			}
+		}
+		// 识别摘要反映解析出的原始数据，在过滤、合并和聚合之前输出
+		if !*quiet {
+			atopparse.PrintDetectionSummary(console, info)
+		}
+		atopparse.PrintIntervalChanges(console, data)
+
+		var compareData []atopparse.MemoryRecord
+		if *compare != "" {
+			fmt.Fprintf(console, tr("解析对比数据: %s\n"), *compare)
+			compareData, err = parseComparePeriod(*compare, opts)
+			if err != nil {
+				fmt.Fprintf(console, tr("错误: %v\n"), err)
+				exitWith(1, exitReasonParseError, err.Error())
+			}
+			if len(compareData) == 0 {
+				fmt.Fprintf(console, tr("对比数据 %s 中没有找到有效的内存数据\n"), *compare)
+				exitWith(1, exitReasonNoData, fmt.Sprintf(tr("对比数据 %s 中没有找到有效的内存数据"), *compare))
+			}
+		}
+		if err := opts.Cache.Save(); err != nil {
+			fmt.Fprintf(console, tr("警告: 无法写入缓存 %s: %v\n"), *cachePath, err)
+		}
+
+		// 记录输入文件的校验和，便于日后确认报告对应的原始日志
+		var checksums []inputChecksum
+		if *checksum {
+			files := inputFiles(*logFile, *dirPath, *recursive)
+			if *logFile != "" {
+				files = []string{*logFile}
+			}
+			checksums, err = inputChecksums(files, data)
+			if err != nil {
+				fmt.Fprintf(console, tr("错误: 无法计算输入文件校验和: %v\n"), err)
+				exitWith(1, exitReasonParseError, err.Error())
+			}
+			for _, c := range checksums {
+				if c.SHA256 == "" {
+					fmt.Fprintf(console, tr("输入 %s: 远程地址不计算校验和，%d 条记录\n"), c.File, c.Records)
+					continue
+				}
+				fmt.Fprintf(console, tr("输入 %s: sha256=%s，%d 条记录\n"), c.File, c.SHA256, c.Records)
+			}
+			inputsFile := *outputPrefix + "_inputs.json"
+			if err := writeInputsJSON(checksums, inputsFile); err != nil {
+				fmt.Fprintf(console, tr("错误: 无法写入 %s: %v\n"), inputsFile, err)
+				exitWith(1, exitReasonReportError, err.Error())
+			}
+			fmt.Fprintf(console, tr("已保存输入校验和: %s\n"), inputsFile)
+		}
+
+		// 估计并校正各日志文件之间的时钟偏移
+		if *clockSkew || *alignClocksFlag {
+			reference, offsets := estimateClockOffsets(data, *maxClockSkew)
+			printClockOffsets(console, reference, offsets)
+			if *alignClocksFlag && len(offsets) > 0 {
+				data = alignClocks(data, offsets)
+				fmt.Fprintln(console, tr("已按估计偏移校正时间戳"))
+			}
+		}
+
+		// 合并历史CSV中的记录
+		if *seedFrom != "" {
+			seed, err := atopparse.ReadRecordsCSV(*seedFrom)
+			if err != nil {
+				fmt.Fprintf(console, tr("警告: 忽略历史数据: %v\n"), err)
+			} else {
+				var duplicates int
+				data, duplicates = atopparse.MergeRecords(seed, data)
+				fmt.Fprintf(console, tr("从 %s 载入 %d 条历史记录，合并后共 %d 条（重复 %d 条）\n"), *seedFrom, len(seed), len(data), duplicates)
+			}
+		}
+
+		if len(data) == 0 {
+			fmt.Fprintln(console, tr("没有找到有效的内存数据"))
+			exitWith(1, exitReasonNoData, tr("没有找到有效的内存数据"))
+		}
+
+		// 按--start/--end截取时间范围（两端包含）
+		if !rangeStart.IsZero() || !rangeEnd.IsZero() {
+			data = atopparse.FilterRecords(data, func(record atopparse.MemoryRecord) bool {
+				return (rangeStart.IsZero() || !record.Timestamp.Before(rangeStart)) &&
+					(rangeEnd.IsZero() || !record.Timestamp.After(rangeEnd))
+			})
+			fmt.Fprintf(console, tr("按时间范围过滤后剩余 %d 条记录\n"), len(data))
+			if len(data) == 0 {
+				fmt.Fprintln(console, tr("没有落在指定时间范围内的内存数据"))
+				exitWith(1, exitReasonNoData, tr("没有落在指定时间范围内的内存数据"))
+			}
+		}
+
+		// 按每日时段和星期过滤
+		if window.HasHours || window.Weekdays != nil {
+			data = atopparse.FilterRecords(data, func(record atopparse.MemoryRecord) bool {
+				return window.contains(record.Timestamp)
+			})
+			fmt.Fprintf(console, tr("按时段过滤后剩余 %d 条记录\n"), len(data))
+			if len(data) == 0 {
+				fmt.Fprintln(console, tr("没有落在指定时段内的内存数据"))
+				exitWith(1, exitReasonNoData, tr("没有落在指定时段内的内存数据"))
+			}
+		}
+
+		// 按主机和时间戳聚合多个来源
+		if *aggregate == "mean" {
+			before := len(data)
+			data = aggregateMean(data)
+			fmt.Fprintf(console, tr("按主机和时间戳取平均: %d 条记录合并为 %d 条\n"), before, len(data))
+		}
+
+		// 追加到已有的主CSV：放在时间范围、时段过滤和聚合之后，这些只作用于本次解析的记录。
+		// 文件不存在时相当于第一次写出，读取失败时退出而不覆盖已有的数据
+		if *appendCSV {
+			csvPath := atopparse.OutputName(*outputPrefix+".csv", *gzipOutput)
+			if _, err := os.Stat(csvPath); err == nil {
+				existing, err := atopparse.ReadRecordsCSV(csvPath)
+				if err != nil {
+					fmt.Fprintf(console, tr("错误: 无法读取要追加的CSV: %v\n"), err)
+					exitWith(1, exitReasonParseError, err.Error())
+				}
+				var duplicates int
+				data, duplicates = atopparse.MergeRecords(existing, data)
+				fmt.Fprintf(console, tr("追加到 %s：已有 %d 条记录，合并后共 %d 条（重复 %d 条）\n"), csvPath, len(existing), len(data), duplicates)
+			}
+			if retainWindow > 0 {
+				var dropped int
+				data, dropped = atopparse.RetainRecords(data, retainWindow)
+				fmt.Fprintf(console, tr("按 --retain %s 丢弃了 %d 条较早的记录，保留 %d 条\n"), *retain, dropped, len(data))
+			}
+		}
+
+		chart := atopparse.ChartOptions{MaxFillGap: *interpolateGaps, MaxGap: *maxGap, RelativeAxis: *relativeAxis, Smooth: *smooth, MaxPoints: *maxPoints, Colors: colors, HideMemAvail: *noMemAvail}
+		if limit := atopparse.GapLimit(data, chart); limit > 0 {
+			_, gaps := atopparse.GapSegments(data, chart)
+			atopparse.PrintDataGaps(console, gaps, limit)
+		}
+
+		if *detectRebootsFlag {
+			chart.Reboots = detectReboots(data, *rebootGap, *rebootFreeJump)
+			printReboots(console, chart.Reboots)
+		}
+
+		if *showSparkline {
+			printSparklines(console, data)
+		}
+
+		if *topFiles > 0 {
+			printTopFiles(console, data, *topFiles)
+		}
+
+		if *showTrend {
+			printTrend(console, data, *trendSlope, *trendR2)
+		}
+
+		if *showTransitions {
+			printTransitions(console, data, transitionConditions(*transitionMemFree, *transitionSwapUsed))
+		}
+
+		if *serveAddr != "" {
+			report.Chart = chart
+			runServe(console, *serveAddr, *serveRoot, data, opts, report)
+			return
+		}
+
+		// 只输出越界窗口
+		if *breachesOnly {
+			conditions := transitionConditions(*transitionMemFree, *transitionSwapUsed)
+			if rules != nil {
+				conditions = ruleConditions(rules)
+			}
+			breaches := findBreaches(data, conditions)
+			breachFile := atopparse.OutputName(*outputPrefix+"_breaches."+*breachesFormat, *gzipOutput)
+			if *breachesFormat == "json" {
+				err = writeBreachesJSON(breaches, breachFile, *precision, *gzipOutput)
+			} else {
+				err = writeBreachesCSV(breaches, breachFile, *precision, *gzipOutput)
+			}
+			if err != nil {
+				fmt.Fprintf(console, tr("生成报告时出错: %v\n"), err)
+				exitWith(1, exitReasonReportError, err.Error())
+			}
+			fmt.Fprintf(console, tr("已保存越界摘要: %s（共 %d 个越界窗口）\n"), breachFile, len(breaches))
+			// --transition-* 阈值的越界窗口级别为warning，退出码为2
+			if code := ruleExitCode(breaches); code != 0 {
+				exitWith(code, exitReasonThresholdBreached, fmt.Sprintf(tr("%d 个越界窗口"), len(breaches)))
+			}
+			return
+		}
+
+		// 主机名解析后才能确定，写出报告前再检查 --group-by-host 的单独报告
+		if *groupByHost {
+			hostOutputs := hostReportOutputs(data, *outputPrefix, report)
+			if input, output, found := findOutputCollision(inputs, hostOutputs); found {
+				fmt.Fprintf(console, tr("错误: 输出文件 %s 与输入文件 %s 相同，请修改 --output 前缀\n"), output, input)
+				exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("输出文件 %s 会覆盖输入文件 %s"), output, input))
+			}
+			if output, found := findDuplicateOutput(outputs, hostOutputs); found {
+				fmt.Fprintf(console, tr("错误: --group-by-host 的输出文件 %s 与本次运行的其他输出文件相同，请修改 --output 前缀\n"), output)
+				exitWith(1, exitReasonInvalidArgs, fmt.Sprintf(tr("输出文件 %s 会被写出多次"), output))
+			}
+		}
+
+		provenance := ""
+		if !*noProvenance {
+			provenance = provenanceText()
+		}
+		if checksums != nil && !*noProvenance {
+			provenance += "\n" + checksumProvenance(checksums)
+		}
+
+		// 按规则文件检查阈值，报告生成后再按最高级别退出
+		var ruleBreaches []breachWindow
+		if rules != nil {
+			ruleBreaches = findBreaches(data, ruleConditions(rules))
+			printRuleBreaches(console, ruleBreaches, rules)
+		}
+
+		// 检查空闲内存/交换空间阈值，报告生成后有越界时以退出码2退出
+		thresholdBreaches := printThresholdResults(console, checkThresholds(data, freeThresholds(*memFreeThreshold, *swapFreeThreshold)))
+
+		report.Chart = chart
+		report.Provenance = provenance
+		err = atopparse.GenerateReport(data, *outputPrefix, report)
+		if err == nil && *perFileReports {
+			err = atopparse.GeneratePerFileReports(data, *outputPrefix, report)
+		}
+		if err == nil && *groupByHost {
+			err = atopparse.GenerateHostReports(data, *outputPrefix, report)
+		}
+		if err == nil && *compare != "" {
+			err = atopparse.GenerateComparison(
+				atopparse.ComparePeriod{Label: periodLabel(*logFile, *dirPath), Data: data},
+				atopparse.ComparePeriod{Label: periodLabel(*compare, ""), Data: compareData},
+				*outputPrefix, report)
+		}
+		if err == nil && *sqlitePath != "" {
+			if err = atopparse.WriteSQLite(data, *sqlitePath); err == nil {
+				fmt.Fprintf(console, tr("已将 %d 条记录写入 SQLite 数据库: %s\n"), len(data), *sqlitePath)
+			}
+		}
+		if err == nil && *prometheusPath != "" {
+			if err = atopparse.WritePrometheus(data, *prometheusPath, *prometheusAll, *precision); err == nil {
+				fmt.Fprintf(console, tr("已保存Prometheus指标: %s\n"), *prometheusPath)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(console, tr("生成报告时出错: %v\n"), err)
+			exitWith(1, exitReasonReportError, err.Error())
+		}
+
+		fmt.Fprintln(console, tr("报告生成完成！"))
+
+		if code := ruleExitCode(ruleBreaches); code != 0 {
+			exitWith(code, exitReasonThresholdBreached, fmt.Sprintf(tr("%d 个规则越界窗口"), len(ruleBreaches)))
+		}
+		if thresholdBreaches > 0 {
+			exitWith(2, exitReasonThresholdBreached, fmt.Sprintf(tr("%d 个样本低于阈值"), thresholdBreaches))
+		}
+	}
+
+	try()
+}
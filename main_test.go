@@ -0,0 +1,531 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"atop_parser/atopparse"
+)
+
+// runMainEnv 设置时测试二进制不运行测试，而是把参数交给main，用于在子进程中运行命令行
+const runMainEnv = "ATOP_PARSER_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		var args []string
+		if encoded := os.Getenv(runMainEnv + "_ARGS"); encoded != "" {
+			if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+		}
+		os.Args = append([]string{"atop_parser"}, args...)
+		main()
+		os.Exit(0)
+	}
+	// 测试按中文消息断言，不受运行测试的终端的语言设置影响
+	atopparse.Locale = "zh"
+	os.Exit(m.Run())
+}
+
+// cliResult 是一次命令行运行的输出和退出码
+type cliResult struct {
+	Stdout string
+	Stderr string
+	Code   int
+}
+
+// runCLI 在dir中以子进程运行命令行，分别捕获标准输出和标准错误；参数以JSON数组传给子进程，可以含有空格
+func runCLI(t *testing.T, dir string, args ...string) cliResult {
+	t.Helper()
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), runMainEnv+"=1", runMainEnv+"_ARGS="+string(encoded), "LC_ALL=", "LC_MESSAGES=", "LANG=zh_CN.UTF-8")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	result := cliResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.Code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("运行命令行失败: %v", err)
+	}
+	return result
+}
+
+// writeFile 在dir中写入测试用的文件并返回其路径
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sampleLog 三个采样块的atop日志，每块带有DSK行和NET接口行
+const sampleLog = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G | cache 2.0G | buff 0.5G |
+SWP | tot 2.0G | free 1.5G |
+DSK | sda | busy 5% | read 100 | write 200 |
+NET | eth0 ---- | pcki 2000 | pcko 2100 | sp 1000 Mbps | si 123 Kbps | so 456 Kbps |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+MEM | tot 16.0G | free 3.5G | cache 2.0G | buff 0.5G |
+SWP | tot 2.0G | free 1.5G |
+DSK | sda | busy 7% | read 120 | write 210 |
+NET | eth0 ---- | pcki 2001 | pcko 2101 | sp 1000 Mbps | si 123 Kbps | so 456 Kbps |
+ATOP - web1  2024/06/11  10:00:20  --------  10s elapsed
+MEM | tot 16.0G | free 3.0G | cache 2.0G | buff 0.5G |
+SWP | tot 2.0G | free 1.0G |
+DSK | sda | busy 9% | read 140 | write 220 |
+NET | eth0 ---- | pcki 2002 | pcko 2102 | sp 1000 Mbps | si 123 Kbps | so 456 Kbps |
+`
+
+func TestStdoutCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantFiles []string // 写出的文件：--stdout 时磁盘和网络统计CSV照常按前缀生成
+	}{
+		{"output dash", []string{"-o", "-"}, nil},
+		{"stdout flag", []string{"--stdout", "-o", "report"}, []string{"report_disk.csv", "report_net.csv"}},
+		{"stdout with html", []string{"--stdout", "-o", "report", "--html"}, []string{"report_disk.csv", "report_memory_swap.html", "report_net.csv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", sampleLog)
+
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt", "--no-provenance"}, tt.args...)...)
+			if result.Code != 0 {
+				t.Fatalf("退出码 %d，标准错误:\n%s", result.Code, result.Stderr)
+			}
+			lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+			if len(lines) != 4 {
+				t.Fatalf("标准输出应为表头加3行记录，实际 %d 行:\n%s", len(lines), result.Stdout)
+			}
+			if !strings.HasPrefix(lines[0], "timestamp,mem_tot,mem_free,") {
+				t.Errorf("第一行不是CSV表头: %q", lines[0])
+			}
+			if !strings.HasPrefix(lines[3], "2024-06-11 10:00:20,16.00,3.00,") {
+				t.Errorf("最后一行记录不正确: %q", lines[3])
+			}
+			// 诊断信息全部写到标准错误
+			if strings.Contains(result.Stdout, "已将CSV写入标准输出") || !strings.Contains(result.Stderr, "已将CSV写入标准输出") {
+				t.Errorf("诊断信息没有写到标准错误:\n%s", result.Stderr)
+			}
+			if strings.Contains(result.Stdout, "已保存CSV文件") {
+				t.Errorf("诊断信息混入了标准输出")
+			}
+
+			// 不写主CSV和PNG文件
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, entry := range entries {
+				if entry.Name() != "atop.txt" {
+					files = append(files, entry.Name())
+				}
+			}
+			if strings.Join(files, ",") != strings.Join(tt.wantFiles, ",") {
+				t.Errorf("写出的文件为 %v，期望 %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestStdoutDashRejectsOtherOutputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "atop.txt", sampleLog)
+	result := runCLI(t, dir, "-f", "atop.txt", "-o", "-", "--html")
+	if result.Code != 1 || result.Stdout != "" {
+		t.Fatalf("退出码 %d，标准输出:\n%s", result.Code, result.Stdout)
+	}
+	if !strings.Contains(result.Stderr, "--output - 只能输出CSV") {
+		t.Errorf("标准错误中没有说明:\n%s", result.Stderr)
+	}
+}
+
+func TestDetectionSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantSummary bool
+		wantCode    int
+	}{
+		{"before filtering", []string{"--weekdays", "sun"}, true, 1},
+		{"quiet", []string{"--weekdays", "sun", "--quiet"}, false, 1},
+		{"full run", []string{"--no-png"}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", "Version: 2.7.1 - 2022/01/08 09:53:19\n"+sampleLog)
+
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt"}, tt.args...)...)
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，标准错误:\n%s", result.Code, tt.wantCode, result.Stderr)
+			}
+			summary := strings.Index(result.Stdout, "自动识别摘要:")
+			if (summary >= 0) != tt.wantSummary {
+				t.Fatalf("是否输出识别摘要: %t，期望 %t:\n%s", summary >= 0, tt.wantSummary, result.Stdout)
+			}
+			if !tt.wantSummary {
+				return
+			}
+			for _, want := range []string{"atop版本: 2.7.1", "主机数量: 1 (web1)", "采样间隔: 10s=3"} {
+				if !strings.Contains(result.Stdout, want) {
+					t.Errorf("识别摘要中没有 %q:\n%s", want, result.Stdout)
+				}
+			}
+			if filtered := strings.Index(result.Stdout, "按时段过滤后剩余"); filtered >= 0 && filtered < summary {
+				t.Errorf("识别摘要应在过滤之前输出:\n%s", result.Stdout)
+			}
+		})
+	}
+}
+
+func TestCompareMetric(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+		wantTable  string
+	}{
+		{"default used", []string{"--compare", "before.txt"}, 0, "", "mem_used mean"},
+		{"swap used", []string{"--compare", "before.txt", "--compare-metric", "swap_used"}, 0, "", "swp_used max"},
+		{"unknown metric", []string{"--compare", "before.txt", "--compare-metric", "cached"}, 1, "未知的对比指标", ""},
+		{"without compare", []string{"--compare-metric", "free"}, 1, "--compare-metric 需要同时指定 --compare", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", sampleLog)
+			writeFile(t, dir, "before.txt", sampleLog)
+
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt", "--no-png", "--quiet"}, tt.args...)...)
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，标准错误:\n%s", result.Code, tt.wantCode, result.Stderr)
+			}
+			if !strings.Contains(result.Stdout+result.Stderr, tt.wantStderr) {
+				t.Errorf("输出中没有 %q:\n%s", tt.wantStderr, result.Stdout+result.Stderr)
+			}
+			if tt.wantTable == "" {
+				return
+			}
+			table, err := os.ReadFile(filepath.Join(dir, "memory_report_compare.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(table), tt.wantTable) {
+				t.Errorf("对比表中没有 %q:\n%s", tt.wantTable, table)
+			}
+		})
+	}
+}
+
+func TestAppendRetain(t *testing.T) {
+	later := strings.ReplaceAll(sampleLog, "2024/06/11", "2024/06/20")
+
+	tests := []struct {
+		name      string
+		second    string
+		args      []string
+		wantCode  int
+		wantRows  []string // 第二次运行后CSV中各行的时间戳
+		wantInOut string
+	}{
+		{
+			name:     "append keeps both days",
+			second:   later,
+			args:     []string{"--append"},
+			wantRows: []string{"2024-06-11 10:00:00", "2024-06-11 10:00:10", "2024-06-11 10:00:20", "2024-06-20 10:00:00", "2024-06-20 10:00:10", "2024-06-20 10:00:20"},
+		},
+		{
+			name:      "retain ages out older rows",
+			second:    later,
+			args:      []string{"--append", "--retain", "7d"},
+			wantRows:  []string{"2024-06-20 10:00:00", "2024-06-20 10:00:10", "2024-06-20 10:00:20"},
+			wantInOut: "丢弃了 3 条较早的记录",
+		},
+		{
+			name:      "same log appended twice",
+			second:    sampleLog,
+			args:      []string{"--append"},
+			wantRows:  []string{"2024-06-11 10:00:00", "2024-06-11 10:00:10", "2024-06-11 10:00:20"},
+			wantInOut: "重复 3 条",
+		},
+		{
+			name:     "without append overwrites",
+			second:   later,
+			wantRows: []string{"2024-06-20 10:00:00", "2024-06-20 10:00:10", "2024-06-20 10:00:20"},
+		},
+		{
+			name:      "retain without append",
+			second:    later,
+			args:      []string{"--retain", "7d"},
+			wantCode:  1,
+			wantInOut: "--retain 需要同时指定 --append",
+		},
+		{
+			name:      "invalid retain",
+			second:    later,
+			args:      []string{"--append", "--retain", "0d"},
+			wantCode:  1,
+			wantInOut: "保留时长必须大于0",
+		},
+		{
+			name:      "append to stdout",
+			second:    later,
+			args:      []string{"--append", "--stdout"},
+			wantCode:  1,
+			wantInOut: "--append 不能与",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "first.txt", sampleLog)
+			writeFile(t, dir, "second.txt", tt.second)
+			if result := runCLI(t, dir, "-f", "first.txt", "--no-png", "--quiet"); result.Code != 0 {
+				t.Fatalf("第一次运行退出码 %d，标准错误:\n%s", result.Code, result.Stderr)
+			}
+
+			result := runCLI(t, dir, append([]string{"-f", "second.txt", "--no-png", "--quiet"}, tt.args...)...)
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，标准错误:\n%s", result.Code, tt.wantCode, result.Stderr)
+			}
+			if !strings.Contains(result.Stdout+result.Stderr, tt.wantInOut) {
+				t.Errorf("输出中没有 %q:\n%s", tt.wantInOut, result.Stdout+result.Stderr)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, "memory_report.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+			if !strings.HasPrefix(lines[0], "timestamp,") {
+				t.Errorf("第一行应为表头: %s", lines[0])
+			}
+			var rows []string
+			for _, line := range lines[1:] {
+				if strings.HasPrefix(line, "#") { // 末尾的来源注释
+					continue
+				}
+				rows = append(rows, strings.SplitN(line, ",", 2)[0])
+			}
+			if strings.Join(rows, ";") != strings.Join(tt.wantRows, ";") {
+				t.Errorf("CSV中的记录为 %v，期望 %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestCPUHeatmap(t *testing.T) {
+	withCores := strings.ReplaceAll(sampleLog, "SWP | tot 2.0G | free 1.5G |\n",
+		"SWP | tot 2.0G | free 1.5G |\ncpu | sys 5% | user 20% | irq 0% | idle 75% | cpu000 w 0% |\ncpu | sys 1% | user 2% | irq 0% | idle 97% | cpu001 w 0% |\n")
+
+	tests := []struct {
+		name      string
+		log       string
+		wantFile  bool
+		wantInOut string
+	}{
+		{"per core lines", withCores, true, "已保存CPU核心热力图"},
+		{"no per core lines", sampleLog, false, "日志中没有单核cpu行"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", tt.log)
+			result := runCLI(t, dir, "-f", "atop.txt", "--quiet", "--cpu-heatmap")
+			if result.Code != 0 {
+				t.Fatalf("退出码 %d，标准错误:\n%s", result.Code, result.Stderr)
+			}
+			if !strings.Contains(result.Stdout+result.Stderr, tt.wantInOut) {
+				t.Errorf("输出中没有 %q:\n%s", tt.wantInOut, result.Stdout+result.Stderr)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "memory_report_cpu_heatmap.png")); (err == nil) != tt.wantFile {
+				t.Errorf("热力图文件存在为 %t，期望 %t", err == nil, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestConfigInputOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		args      []string
+		wantCode  int
+		wantInOut string
+	}{
+		{"log file from config", "log_file: atop.txt\nno-png: true\n", nil, 0, "报告生成完成"},
+		{"short name in config", "f: atop.txt\nno-png: true\n", nil, 0, "报告生成完成"},
+		{"dir from config", "dir: logs\nno-png: true\n", nil, 0, "报告生成完成"},
+		{"json config", `{"log_file": "atop.txt", "no-png": true}`, nil, 0, "报告生成完成"},
+		{"command line input wins", "dir: missing\nno-png: true\n", []string{"-f", "atop.txt"}, 0, "报告生成完成"},
+		{"no input anywhere", "no-png: true\n", nil, 1, "必须指定 --log_file (-f) 或 --dir (-d) 参数"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", sampleLog)
+			if err := os.Mkdir(filepath.Join(dir, "logs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filepath.Join(dir, "logs"), "atop.txt", sampleLog)
+			writeFile(t, dir, "config.yaml", tt.config)
+
+			result := runCLI(t, dir, append([]string{"--config", "config.yaml", "--quiet"}, tt.args...)...)
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，输出:\n%s%s", result.Code, tt.wantCode, result.Stdout, result.Stderr)
+			}
+			if !strings.Contains(result.Stdout+result.Stderr, tt.wantInOut) {
+				t.Errorf("输出中没有 %q:\n%s", tt.wantInOut, result.Stdout+result.Stderr)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "memory_report.csv")); (err == nil) != (tt.wantCode == 0) {
+				t.Errorf("CSV存在为 %t，期望 %t", err == nil, tt.wantCode == 0)
+			}
+		})
+	}
+}
+
+func TestSwapLinesDefault(t *testing.T) {
+	const log = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+SWP | tot 512.0M | free 256.0M |
+`
+	tests := []struct {
+		name string
+		args []string
+		want string // CSV第一行数据的 swp_tot,swp_free
+	}{
+		{"summed by default", nil, "2.50,1.75"},
+		{"first device", []string{"--swap-lines", "first"}, "2.00,1.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", log)
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt", "--no-png", "--quiet"}, tt.args...)...)
+			if result.Code != 0 {
+				t.Fatalf("退出码 %d，输出:\n%s", result.Code, result.Stdout)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "memory_report.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := strings.Split(strings.Split(string(content), "\n")[1], ",")
+			if got := fields[3] + "," + fields[4]; got != tt.want {
+				t.Errorf("swp_tot,swp_free为 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	const header = "timestamp,mem_tot,mem_free,swp_tot,swp_free\n"
+	tests := []struct {
+		name       string
+		csv        string
+		wantCode   int
+		wantStdout string
+		wantReason string
+	}{
+		{"valid", header + "2024-06-11 10:00:00,16.00,4.00,2.00,1.50\n2024-06-11 10:00:10,16.00,3.50,2.00,1.50\n", 0, "共 2 条记录", ""},
+		{"missing column", "timestamp,mem_tot,swp_tot,swp_free\n2024-06-11 10:00:00,16.00,2.00,1.50\n", 1, "mem_free", "schema_error"},
+		{"non-numeric value", header + "2024-06-11 10:00:00,16.00,4.00,2.00,1.50\n2024-06-11 10:00:10,16.00,abc,2.00,1.50\n", 1, "第 3 行", "schema_error"},
+		{"invalid timestamp", header + "2024/06/11 10:00,16.00,4.00,2.00,1.50\n", 1, "第 2 行", "schema_error"},
+		{"short row", header + "2024-06-11 10:00:00,16.00,4.00\n", 1, "line 2", "schema_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "prior.csv", tt.csv)
+			result := runCLI(t, dir, "--validate-schema", "prior.csv")
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，输出:\n%s%s", result.Code, tt.wantCode, result.Stdout, result.Stderr)
+			}
+			if !strings.Contains(result.Stdout, tt.wantStdout) {
+				t.Errorf("输出中没有 %q:\n%s", tt.wantStdout, result.Stdout)
+			}
+			if !strings.Contains(result.Stderr, tt.wantReason) {
+				t.Errorf("标准错误中没有退出原因 %q:\n%s", tt.wantReason, result.Stderr)
+			}
+		})
+	}
+}
+
+func TestCLIArgsWithSpaces(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "my logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "my logs/atop 1.txt", sampleLog)
+
+	result := runCLI(t, dir, "-f", "my logs/atop 1.txt", "--no-png", "--quiet", "--no-provenance", "--derive", "used_pct = mem_used / mem_tot * 100")
+	if result.Code != 0 {
+		t.Fatalf("退出码 %d，输出:\n%s%s", result.Code, result.Stdout, result.Stderr)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "memory_report.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if !strings.HasSuffix(lines[0], ",used_pct") || !strings.HasSuffix(lines[1], ",75.00") {
+		t.Errorf("派生指标列不正确:\n%s", content)
+	}
+}
+
+func TestDateLayoutFlag(t *testing.T) {
+	const log = `ATOP - web1  03-04-2024  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+`
+	tests := []struct {
+		name    string
+		args    []string
+		wantRow string
+	}{
+		{"detected day first", nil, "2024-04-03 10:00:00,"},
+		{"month first layout", []string{"--date-layout", "01-02-2006 15:04:05"}, "2024-03-04 10:00:00,"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", log)
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt", "--no-png", "--quiet", "--no-provenance"}, tt.args...)...)
+			if result.Code != 0 {
+				t.Fatalf("退出码 %d，输出:\n%s", result.Code, result.Stdout)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "memory_report.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := strings.Split(string(content), "\n"); len(lines) < 2 || !strings.HasPrefix(lines[1], tt.wantRow) {
+				t.Errorf("CSV记录不以 %q 开头:\n%s", tt.wantRow, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"atop_parser/atopparse"
+)
+
+// tr 按当前语言翻译命令行的消息，对照表与库共用（见atopparse.MessagesEN）
+func tr(message string) string {
+	return atopparse.Tr(message)
+}
+
+// translateFlagUsage 将所有参数的帮助文本替换为当前语言
+func translateFlagUsage() {
+	flag.VisitAll(func(f *flag.Flag) {
+		f.Usage = tr(f.Usage)
+	})
+}
+
+// printUsage 按当前语言输出参数帮助，用作flag.Usage
+func printUsage() {
+	translateFlagUsage()
+	fmt.Fprintf(flag.CommandLine.Output(), tr("用法: %s [参数]\n"), os.Args[0])
+	flag.PrintDefaults()
+}
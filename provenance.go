@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"atop_parser/atopparse"
+)
+
+// toolVersion 工具版本，发布时可通过 -ldflags "-X main.toolVersion=x.y.z" 设置
+var toolVersion = "dev"
+
+// provenanceText 返回记录工具版本和完整命令行的来源说明，用于写入报告页脚
+func provenanceText() string {
+	args := make([]string, len(os.Args))
+	for i, arg := range os.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args[i] = arg
+	}
+	return fmt.Sprintf("generated by atop_parser_mem %s: %s", toolVersion, strings.Join(args, " "))
+}
+
+// inputChecksum 一个输入文件的SHA-256和解析出的记录数
+type inputChecksum struct {
+	File    string `json:"file"`
+	SHA256  string `json:"sha256,omitempty"` // 远程地址不计算，为空
+	Records int    `json:"records"`
+}
+
+// fileSHA256 计算本地文件内容的SHA-256
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// inputChecksums 计算各输入文件的SHA-256，并按来源统计解析出的记录数
+func inputChecksums(files []string, data []atopparse.MemoryRecord) ([]inputChecksum, error) {
+	counts := make(map[string]int)
+	for _, record := range data {
+		counts[record.Source]++
+	}
+
+	checksums := make([]inputChecksum, 0, len(files))
+	for _, file := range files {
+		item := inputChecksum{File: file, Records: counts[file]}
+		if !atopparse.IsRemoteLog(file) {
+			sum, err := fileSHA256(file)
+			if err != nil {
+				return nil, err
+			}
+			item.SHA256 = sum
+		}
+		checksums = append(checksums, item)
+	}
+	return checksums, nil
+}
+
+// checksumProvenance 返回附加在来源说明后的输入校验和，每个文件一行
+func checksumProvenance(checksums []inputChecksum) string {
+	lines := make([]string, len(checksums))
+	for i, c := range checksums {
+		sum := c.SHA256
+		if sum == "" {
+			sum = "-"
+		}
+		lines[i] = fmt.Sprintf("input %s sha256=%s records=%d", c.File, sum, c.Records)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeInputsJSON 将输入校验和写为JSON数组
+func writeInputsJSON(checksums []inputChecksum, outputFile string) error {
+	content, err := json.MarshalIndent(checksums, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputFile, append(content, '\n'), 0644)
+}
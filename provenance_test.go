@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// oneBlockLog 只有一个采样块的atop日志，SHA-256为oneBlockSHA256
+const oneBlockLog = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 4.0G |
+SWP | tot 2.0G | free 1.5G |
+`
+
+const (
+	oneBlockSHA256  = "0e8a9a5293196e4ce72b0c6ab03cbe2c262bbceee98475c6ddf810e0dd09acbf"
+	sampleLogSHA256 = "b631c82b39557e997ac83e9da6f6f1e0564de4d7a5d8a2aa4aaf9a68b168d53b"
+)
+
+func TestChecksumCLI(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []inputChecksum
+	}{
+		{"single file", []string{"-f", "logs/a.txt"}, []inputChecksum{{File: "logs/a.txt", SHA256: sampleLogSHA256, Records: 3}}},
+		{"directory", []string{"-d", "logs"}, []inputChecksum{
+			{File: filepath.Join("logs", "a.txt"), SHA256: sampleLogSHA256, Records: 3},
+			{File: filepath.Join("logs", "b.txt"), SHA256: oneBlockSHA256, Records: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "logs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, dir, "logs/a.txt", sampleLog)
+			writeFile(t, dir, "logs/b.txt", oneBlockLog)
+
+			result := runCLI(t, dir, append(tt.args, "--checksum", "--no-png", "--quiet")...)
+			if result.Code != 0 {
+				t.Fatalf("退出码 %d，输出:\n%s", result.Code, result.Stdout)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, "memory_report_inputs.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []inputChecksum
+			if err := json.Unmarshal(content, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("输入校验和为 %+v，期望 %+v", got, tt.want)
+			}
+
+			// 校验和同时写入CSV末尾的来源说明
+			csv, err := os.ReadFile(filepath.Join(dir, "memory_report.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tt.want {
+				line := "# input " + c.File + " sha256=" + c.SHA256
+				if !strings.Contains(string(csv), line) {
+					t.Errorf("CSV的来源说明中没有 %q:\n%s", line, csv)
+				}
+			}
+		})
+	}
+}
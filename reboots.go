@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// detectReboots 返回疑似重启后的第一个样本时间：
+// 与上一个样本的间隔不小于minGap，且空闲内存回升至少minFreeJump(GB)
+func detectReboots(data []atopparse.MemoryRecord, minGap time.Duration, minFreeJump float64) []time.Time {
+	var reboots []time.Time
+	for i := 1; i < len(data); i++ {
+		gap := data[i].Timestamp.Sub(data[i-1].Timestamp)
+		jump := data[i].MemFree - data[i-1].MemFree
+		if gap >= minGap && jump >= minFreeJump {
+			reboots = append(reboots, data[i].Timestamp)
+		}
+	}
+	return reboots
+}
+
+// printReboots 输出检测到的疑似重启
+func printReboots(w io.Writer, reboots []time.Time) {
+	fmt.Fprintf(w, tr("检测到 %d 次疑似重启\n"), len(reboots))
+	for _, t := range reboots {
+		fmt.Fprintf(w, "  %s\n", t.Format("2006-01-02 15:04:05"))
+	}
+}
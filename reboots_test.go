@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+func TestDetectReboots(t *testing.T) {
+	ts := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	record := func(offset time.Duration, free float64) atopparse.MemoryRecord {
+		return atopparse.MemoryRecord{Timestamp: ts.Add(offset), MemTotal: 16, MemFree: free}
+	}
+	// 内存逐渐耗尽，30分钟无数据后空闲内存回升到14G
+	reboot := []atopparse.MemoryRecord{record(0, 4), record(10*time.Second, 2), record(20*time.Second, 0.5),
+		record(30*time.Minute, 14), record(30*time.Minute+10*time.Second, 13.9)}
+
+	tests := []struct {
+		name     string
+		data     []atopparse.MemoryRecord
+		minGap   time.Duration
+		minJump  float64
+		wantTime []time.Time
+	}{
+		{"synthetic reboot", reboot, 10 * time.Minute, 1, []time.Time{ts.Add(30 * time.Minute)}},
+		{"gap longer than threshold required", reboot, time.Hour, 1, nil},
+		{"free jump larger than threshold required", reboot, 10 * time.Minute, 20, nil},
+		{"gap without free jump", []atopparse.MemoryRecord{record(0, 4), record(time.Hour, 4.5)}, 10 * time.Minute, 1, nil},
+		{"free jump without gap", []atopparse.MemoryRecord{record(0, 1), record(10*time.Second, 10)}, 10 * time.Minute, 1, nil},
+		{"two reboots", []atopparse.MemoryRecord{record(0, 1), record(time.Hour, 12), record(time.Hour+10*time.Second, 2), record(3*time.Hour, 12)},
+			10 * time.Minute, 1, []time.Time{ts.Add(time.Hour), ts.Add(3 * time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectReboots(tt.data, tt.minGap, tt.minJump); !reflect.DeepEqual(got, tt.wantTime) {
+				t.Errorf("检测到的重启为 %v，期望 %v", got, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestRebootsCLI(t *testing.T) {
+	const log = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 2.0G |
+SWP | tot 2.0G | free 0.5G |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+MEM | tot 16.0G | free 0.5G |
+SWP | tot 2.0G | free 0.1G |
+ATOP - web1  2024/06/11  10:40:00  --------  2370s elapsed
+MEM | tot 16.0G | free 14.0G |
+SWP | tot 2.0G | free 2.0G |
+ATOP - web1  2024/06/11  10:40:10  --------  10s elapsed
+MEM | tot 16.0G | free 13.9G |
+SWP | tot 2.0G | free 2.0G |
+`
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"default heuristic", []string{"--reboots"}, "检测到 1 次疑似重启\n  2024-06-11 10:40:00"},
+		{"tuned gap", []string{"--reboots", "--reboot-gap", "1h"}, "检测到 0 次疑似重启"},
+		{"tuned free jump", []string{"--reboots", "--reboot-free-jump", "15"}, "检测到 0 次疑似重启"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", log)
+			result := runCLI(t, dir, append([]string{"-f", "atop.txt", "--quiet"}, tt.args...)...)
+			if result.Code != 0 {
+				t.Fatalf("退出码 %d，输出:\n%s", result.Code, result.Stdout)
+			}
+			if !strings.Contains(result.Stdout, tt.want) {
+				t.Errorf("输出中没有 %q:\n%s", tt.want, result.Stdout)
+			}
+			// 标注了重启的图表照常生成
+			if info, err := os.Stat(filepath.Join(dir, "memory_report_memory_swap.png")); err != nil || info.Size() == 0 {
+				t.Errorf("没有生成内存图表: %v", err)
+			}
+		})
+	}
+}
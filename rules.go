@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"atop_parser/atopparse"
+	"gopkg.in/yaml.v3"
+)
+
+// 规则的严重级别，越界时按命中的最高级别决定退出码
+const (
+	severityWarning  = "warning"  // 退出码2
+	severityCritical = "critical" // 退出码3
+)
+
+// rule 规则文件中的一条阈值规则
+type rule struct {
+	Name       string  `yaml:"name"`
+	Metric     string  `yaml:"metric"`     // atopparse.MetricNames中的指标，或mem_used、swp_used
+	Comparator string  `yaml:"comparator"` // <、<=、>、>=
+	Value      float64 `yaml:"value"`      // 阈值(GB)
+	Severity   string  `yaml:"severity"`   // warning或critical
+}
+
+// rulesFile 规则文件的顶层结构
+type rulesFile struct {
+	Rules []rule `yaml:"rules"`
+}
+
+// loadRules 读取并校验YAML规则文件
+func loadRules(path string) ([]rule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file rulesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf(tr("解析规则文件 %s 失败: %v"), path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf(tr("规则文件 %s 中没有规则"), path)
+	}
+
+	names := make(map[string]bool)
+	for i, r := range file.Rules {
+		switch {
+		case r.Name == "":
+			return nil, fmt.Errorf(tr("规则文件 %s 第 %d 条规则缺少 name"), path, i+1)
+		case names[r.Name]:
+			return nil, fmt.Errorf(tr("规则文件 %s 中规则名 %s 重复"), path, r.Name)
+		case !atopparse.ValidRuleMetric(r.Metric):
+			return nil, fmt.Errorf(tr("规则 %s 的指标 %q 不受支持，可选 mem_tot、mem_free、mem_used、mem_cache、mem_buff、swp_tot、swp_free、swp_used"), r.Name, r.Metric)
+		case r.Comparator != "<" && r.Comparator != "<=" && r.Comparator != ">" && r.Comparator != ">=":
+			return nil, fmt.Errorf(tr("规则 %s 的比较符 %q 不受支持，可选 <、<=、>、>="), r.Name, r.Comparator)
+		case r.Severity != severityWarning && r.Severity != severityCritical:
+			return nil, fmt.Errorf(tr("规则 %s 的级别 %q 不受支持，可选 warning 或 critical"), r.Name, r.Severity)
+		}
+		names[r.Name] = true
+	}
+	return file.Rules, nil
+}
+
+// condition 将规则转换为状态条件，条件标签即规则名
+func (r rule) condition() stateCondition {
+	metric := r.Metric
+	return stateCondition{
+		Label:     r.Name,
+		Value:     func(record atopparse.MemoryRecord) float64 { return atopparse.RuleMetricValue(record, metric) },
+		Threshold: r.Value,
+		Below:     r.Comparator == "<" || r.Comparator == "<=",
+		OrEqual:   r.Comparator == "<=" || r.Comparator == ">=",
+		Severity:  r.Severity,
+	}
+}
+
+// ruleConditions 返回所有规则对应的状态条件
+func ruleConditions(rules []rule) []stateCondition {
+	conditions := make([]stateCondition, len(rules))
+	for i, r := range rules {
+		conditions[i] = r.condition()
+	}
+	return conditions
+}
+
+// ruleExitCode 按越界窗口命中的最高级别返回退出码：没有越界为0，warning为2，critical为3
+func ruleExitCode(breaches []breachWindow) int {
+	code := 0
+	for _, b := range breaches {
+		switch b.Severity {
+		case severityCritical:
+			return 3
+		case severityWarning:
+			code = 2
+		}
+	}
+	return code
+}
+
+// printRuleBreaches 输出各规则的越界窗口
+func printRuleBreaches(w io.Writer, breaches []breachWindow, rules []rule) {
+	fmt.Fprintf(w, tr("规则检查: %d 条规则，%d 个越界窗口\n"), len(rules), len(breaches))
+	for _, b := range breaches {
+		recovered := tr("直到数据结束仍未恢复")
+		if b.Recovered != nil {
+			recovered = tr("恢复于 ") + b.Recovered.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, tr("  [%s] %s: %s - %s，最严重 %.2fG，%s\n"), b.Severity, b.Condition,
+			b.Start.Format("2006-01-02 15:04:05"), b.End.Format("2006-01-02 15:04:05"), b.Peak, recovered)
+	}
+}
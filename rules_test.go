@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+const testRules = `rules:
+  - name: mem-free-warn
+    metric: mem_free
+    comparator: "<"
+    value: 1
+    severity: warning
+  - name: mem-free-critical
+    metric: mem_free
+    comparator: "<="
+    value: 0.5
+    severity: critical
+  - name: swap-used-critical
+    metric: swp_used
+    comparator: ">"
+    value: 2
+    severity: critical
+`
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr string
+	}{
+		{"valid", testRules, 3, ""},
+		{"empty", "rules: []\n", 0, "没有规则"},
+		{"unknown field", "rules:\n  - name: a\n    metric: mem_free\n    comparator: \"<\"\n    value: 1\n    severity: warning\n    level: 2\n", 0, "level"},
+		{"missing name", "rules:\n  - metric: mem_free\n    comparator: \"<\"\n    value: 1\n    severity: warning\n", 0, "缺少 name"},
+		{"duplicate name", "rules:\n  - {name: a, metric: mem_free, comparator: \"<\", value: 1, severity: warning}\n  - {name: a, metric: swp_used, comparator: \">\", value: 1, severity: critical}\n", 0, "重复"},
+		{"unknown metric", "rules:\n  - {name: a, metric: cpu_busy, comparator: \"<\", value: 1, severity: warning}\n", 0, "cpu_busy"},
+		{"unknown comparator", "rules:\n  - {name: a, metric: mem_free, comparator: \"==\", value: 1, severity: warning}\n", 0, "=="},
+		{"unknown severity", "rules:\n  - {name: a, metric: mem_free, comparator: \"<\", value: 1, severity: info}\n", 0, "info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "rules.yaml", tt.content)
+			rules, err := loadRules(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误为 %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != tt.want {
+				t.Errorf("读取到 %d 条规则，期望 %d 条", len(rules), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	start := time.Date(2024, 6, 11, 10, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * 10 * time.Second) }
+	var data []atopparse.MemoryRecord
+	for i, sample := range [][2]float64{{2, 0.5}, {0.75, 1}, {0.5, 2.5}, {2, 2.5}} {
+		data = append(data, atopparse.MemoryRecord{Timestamp: at(i), MemTotal: 16, MemFree: sample[0], SwapTotal: 3, SwapFree: 3 - sample[1]})
+	}
+	rules, err := loadRules(writeFile(t, t.TempDir(), "rules.yaml", testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 条件名 -> [开始, 结束, 恢复] 的样本序号，恢复为-1表示直到数据结束仍未恢复
+	want := map[string][3]int{
+		"mem-free-warn":      {1, 2, 3},
+		"mem-free-critical":  {2, 2, 3},
+		"swap-used-critical": {2, 3, -1},
+	}
+	breaches := findBreaches(data, ruleConditions(rules))
+	got := make(map[string][3]int)
+	for _, b := range breaches {
+		recovered := -1
+		if b.Recovered != nil {
+			recovered = int(b.Recovered.Sub(start) / (10 * time.Second))
+		}
+		got[b.Condition] = [3]int{int(b.Start.Sub(start) / (10 * time.Second)), int(b.End.Sub(start) / (10 * time.Second)), recovered}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("越界窗口为 %v，期望 %v", got, want)
+	}
+	for _, b := range breaches {
+		if b.Condition == "swap-used-critical" && b.Peak != 2.5 {
+			t.Errorf("swap-used-critical 的最严重值为 %v，期望 2.5", b.Peak)
+		}
+	}
+	if code := ruleExitCode(breaches); code != 3 {
+		t.Errorf("退出码 %d，期望 3", code)
+	}
+}
+
+func TestRulesCLI(t *testing.T) {
+	const log = `ATOP - web1  2024/06/11  10:00:00  --------  10s elapsed
+MEM | tot 16.0G | free 2.0G |
+SWP | tot 3.0G | free 2.5G |
+ATOP - web1  2024/06/11  10:00:10  --------  10s elapsed
+MEM | tot 16.0G | free 0.8G |
+SWP | tot 3.0G | free 2.0G |
+`
+	tests := []struct {
+		name     string
+		rules    string
+		wantCode int
+		want     string
+	}{
+		{"warning only", testRules, 2, "[warning] mem-free-warn: 2024-06-11 10:00:10 - 2024-06-11 10:00:10"},
+		{"critical wins", testRules + "  - {name: swap-used-any, metric: swp_used, comparator: \">\", value: 0.1, severity: critical}\n", 3, "[critical] swap-used-any"},
+		{"no breach", "rules:\n  - {name: mem-free-low, metric: mem_free, comparator: \"<\", value: 0.1, severity: critical}\n", 0, "规则检查: 1 条规则，0 个越界窗口"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "atop.txt", log)
+			writeFile(t, dir, "rules.yaml", tt.rules)
+			result := runCLI(t, dir, "-f", "atop.txt", "--no-png", "--quiet", "--rules", "rules.yaml")
+			if result.Code != tt.wantCode {
+				t.Fatalf("退出码 %d，期望 %d，输出:\n%s", result.Code, tt.wantCode, result.Stdout)
+			}
+			if !strings.Contains(result.Stdout, tt.want) {
+				t.Errorf("输出中没有 %q:\n%s", tt.want, result.Stdout)
+			}
+			// 有越界时报告照常生成
+			if _, err := os.Stat(filepath.Join(dir, "memory_report.csv")); err != nil {
+				t.Errorf("没有生成CSV: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// serveReports 启动--serve的HTTP服务。Grafana SimpleJSON数据源接口使用启动时解析的data；
+// /report 返回交互式HTML报告，/data.json 返回JSON记录，两者带dir参数时按请求解析root下的该目录，否则使用data。
+// 每个请求使用自己的解析结果和输出缓冲，可以同时处理多个请求
+func serveReports(console io.Writer, addr, root string, data []atopparse.MemoryRecord, opts atopparse.ParseOptions, report atopparse.ReportOptions) error {
+	mux := http.NewServeMux()
+	addGrafanaHandlers(mux, data)
+
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		records, status, err := requestRecords(r, root, data, opts)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		// 先写到缓冲区，生成失败时仍可返回错误状态码
+		var buf bytes.Buffer
+		if err := atopparse.WriteHTMLReport(&buf, records, report); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		records, status, err := requestRecords(r, root, data, opts)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		var buf bytes.Buffer
+		if err := atopparse.WriteJSON(&buf, records, report); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(buf.Bytes())
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(console, tr("HTTP服务已启动: http://%s（/report、/data.json 以及 Grafana SimpleJSON 数据源接口）\n"), addr)
+	return server.ListenAndServe()
+}
+
+// requestRecords 返回请求对应的记录：带dir参数时解析root下的该目录，否则为启动时解析的data。
+// 出错时同时返回应答的HTTP状态码
+func requestRecords(r *http.Request, root string, data []atopparse.MemoryRecord, opts atopparse.ParseOptions) ([]atopparse.MemoryRecord, int, error) {
+	dir := r.URL.Query().Get("dir")
+	if dir == "" {
+		if len(data) == 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf(tr("缺少 dir 参数"))
+		}
+		return data, http.StatusOK, nil
+	}
+	path, status, err := resolveServeDir(root, dir)
+	if err != nil {
+		return nil, status, err
+	}
+
+	// 客户端断开时停止解析
+	records, err := atopparse.ParseDirectoryContext(r.Context(), path, opts, atopparse.NewDetectionInfo())
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if len(records) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf(tr("目录 %s 中没有找到有效的内存数据"), dir)
+	}
+	return records, http.StatusOK, nil
+}
+
+// resolveServeDir 将请求中的dir解析为root下的目录。dir必须是相对路径且不含..，
+// 解析符号链接后仍须位于root之内；root为空（没有指定--serve-root）时不接受dir参数。
+// 出错时同时返回应答的HTTP状态码
+func resolveServeDir(root, dir string) (string, int, error) {
+	if root == "" {
+		return "", http.StatusForbidden, fmt.Errorf(tr("没有指定 --serve-root，不接受 dir 参数"))
+	}
+	if filepath.IsAbs(dir) || strings.HasPrefix(dir, "/") || slices.Contains(strings.FieldsFunc(dir, isPathSeparator), "..") {
+		return "", http.StatusForbidden, fmt.Errorf(tr("dir 必须是 --serve-root 下的相对路径且不能包含 ..: %s"), dir)
+	}
+	path := filepath.Join(root, dir)
+	// 目录本身或其中的符号链接可能指向root之外
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", http.StatusNotFound, fmt.Errorf(tr("目录 %s 不存在"), dir)
+	}
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", http.StatusForbidden, fmt.Errorf(tr("dir 必须是 --serve-root 下的相对路径且不能包含 ..: %s"), dir)
+	}
+	return path, http.StatusOK, nil
+}
+
+// isPathSeparator 判断r是否为路径分隔符，请求中的dir在Windows上也可能使用/
+func isPathSeparator(r rune) bool {
+	return r == '/' || os.IsPathSeparator(uint8(r))
+}
+
+// runServe 运行HTTP服务，服务异常退出时以serve_error退出
+func runServe(w io.Writer, addr, root string, data []atopparse.MemoryRecord, opts atopparse.ParseOptions, report atopparse.ReportOptions) {
+	if err := serveReports(w, addr, root, data, opts, report); err != nil {
+		fmt.Fprintf(w, tr("HTTP服务出错: %v\n"), err)
+		exitWith(1, exitReasonServeError, err.Error())
+	}
+}
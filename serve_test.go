@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"atop_parser/atopparse"
+)
+
+func TestRequestRecordsServeRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "web1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "web1"), "atop.txt", sampleLog)
+	writeFile(t, outside, "atop.txt", sampleLog)
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		root   string
+		dir    string
+		status int
+	}{
+		{"relative dir", root, "web1", http.StatusOK},
+		{"current dir", root, ".", http.StatusNotFound},
+		{"parent dir", root, "../" + filepath.Base(outside), http.StatusForbidden},
+		{"nested parent", root, "web1/../..", http.StatusForbidden},
+		{"absolute path", root, outside, http.StatusForbidden},
+		{"symlink outside root", root, "escape", http.StatusForbidden},
+		{"missing dir", root, "web2", http.StatusNotFound},
+		{"no serve root", "", "web1", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/report?dir="+url.QueryEscape(tt.dir), nil)
+			records, status, err := requestRecords(r, tt.root, nil, atopparse.ParseOptions{Log: io.Discard})
+			if status != tt.status {
+				t.Fatalf("状态码 %d，期望 %d（%v）", status, tt.status, err)
+			}
+			if tt.status == http.StatusOK && len(records) != 3 {
+				t.Errorf("应解析出3条记录，实际 %d 条", len(records))
+			}
+		})
+	}
+}
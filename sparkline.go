@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"atop_parser/atopparse"
+	"golang.org/x/term"
+)
+
+// sparkBlocks 从低到高的方块字符
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// defaultSparklineWidth 非终端输出或无法获取终端宽度时使用的固定宽度
+const defaultSparklineWidth = 80
+
+// terminalWidth 返回w所在终端的宽度，w不是终端时返回默认宽度
+func terminalWidth(w io.Writer) int {
+	file, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return defaultSparklineWidth
+	}
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil || width <= 0 {
+		return defaultSparklineWidth
+	}
+	return width
+}
+
+// sparkline 将序列按桶取平均压缩到width个字符，并映射为方块字符
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if width > len(values) {
+		width = len(values)
+	}
+
+	buckets := make([]float64, width)
+	for i := range buckets {
+		start := i * len(values) / width
+		end := (i + 1) * len(values) / width
+		sum := 0.0
+		for _, v := range values[start:end] {
+			sum += v
+		}
+		buckets[i] = sum / float64(end-start)
+	}
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, v := range buckets {
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range buckets {
+		level := 0
+		if maxValue > minValue {
+			level = int((v - minValue) / (maxValue - minValue) * float64(len(sparkBlocks)-1))
+		}
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
+
+// printSparklines 在终端输出空闲内存和空闲交换空间的迷你趋势图
+func printSparklines(w io.Writer, data []atopparse.MemoryRecord) {
+	series := []struct {
+		label  string
+		values []float64
+	}{
+		{label: "MEM Free"},
+		{label: "SWAP Free"},
+	}
+	for _, record := range data {
+		series[0].values = append(series[0].values, record.MemFree)
+		series[1].values = append(series[1].values, record.SwapFree)
+	}
+
+	width := terminalWidth(w)
+	for _, s := range series {
+		minValue, maxValue := math.Inf(1), math.Inf(-1)
+		for _, v := range s.values {
+			minValue = math.Min(minValue, v)
+			maxValue = math.Max(maxValue, v)
+		}
+
+		prefix := fmt.Sprintf("%-10s", s.label)
+		suffix := fmt.Sprintf(" min %.2fG max %.2fG", minValue, maxValue)
+		lineWidth := width - utf8.RuneCountInString(prefix) - utf8.RuneCountInString(suffix) - 1
+		fmt.Fprintf(w, "%s%s%s\n", prefix, sparkline(s.values, lineWidth), suffix)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"atop_parser/atopparse"
+)
+
+// streamFlags 是--assume-sorted流式模式支持的参数；其他参数（图表、统计、过滤、规则等）
+// 需要先取得全部记录，不能在流式模式下使用
+var streamFlags = map[string]bool{
+	"assume-sorted": true,
+	"config":        true,
+	"dir":           true,
+	"d":             true,
+	"recursive":     true,
+	"output":        true,
+	"o":             true,
+	"stdout":        true,
+	"fail-fast":     true,
+	"strict":        true,
+	"skip-empty":    true,
+	"verbose":       true,
+	"quiet":         true,
+	"locale":        true,
+	"mem-lines":     true,
+	"swap-lines":    true,
+	"date-layout":   true,
+	"trim-warmup":   true,
+	"journald":      true,
+	"derive":        true,
+	"relative-axis": true,
+	"precision":     true,
+	"columns":       true,
+	"delimiter":     true,
+	"gzip-output":   true,
+	"no-provenance": true,
+	"no-png":        true,
+}
+
+// unsupportedStreamFlag 返回命令行中第一个流式模式不支持的参数
+func unsupportedStreamFlag() (string, bool) {
+	var name string
+	flag.Visit(func(f *flag.Flag) {
+		if name == "" && !streamFlags[f.Name] {
+			name = f.Name
+		}
+	})
+	return name, name != ""
+}
+
+// runStream 以流式模式解析目录，逐个文件写出CSV，不生成其他报告
+func runStream(w io.Writer, dirPath, outputPrefix string, opts atopparse.ParseOptions, report atopparse.ReportOptions, quiet bool) {
+	outputFile := atopparse.OutputName(outputPrefix+".csv", report.Gzip)
+	if report.Stdout {
+		outputFile = atopparse.StdoutPath
+	}
+
+	fmt.Fprintf(w, tr("流式解析目录中的所有日志文件: %s\n"), dirPath)
+	info := atopparse.NewDetectionInfo()
+	written, err := atopparse.StreamDirectoryCSV(dirPath, outputFile, opts, report, info)
+	if err != nil {
+		fmt.Fprintf(w, tr("错误: %v\n"), err)
+		exitWith(1, exitReasonParseError, err.Error())
+	}
+	if written == 0 {
+		fmt.Fprintln(w, tr("没有找到有效的内存数据"))
+		exitWith(1, exitReasonNoData, tr("没有找到有效的内存数据"))
+	}
+
+	if !quiet {
+		atopparse.PrintDetectionSummary(w, info)
+	}
+	if report.Stdout {
+		fmt.Fprintln(w, tr("已将CSV写入标准输出"))
+	} else {
+		fmt.Fprintf(w, tr("已保存CSV文件: %s\n"), outputFile)
+	}
+	fmt.Fprintln(w, tr("报告生成完成！"))
+}
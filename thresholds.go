@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// freeThreshold 空闲内存或空闲交换空间的下限，样本低于该值视为越界
+type freeThreshold struct {
+	Label     string                               // 条件描述，例如 "空闲内存低于 1.00G"
+	Value     func(atopparse.MemoryRecord) float64 // 判断所用的值
+	Threshold float64
+	Swap      bool // 交换空间条件，跳过未启用交换空间（总量为0）的样本
+}
+
+// thresholdResult 一个阈值条件的检查结果
+type thresholdResult struct {
+	Threshold freeThreshold
+	Breaches  []time.Time // 低于阈值的样本时间
+	Worst     float64     // 越界样本中的最低值
+	WorstAt   time.Time
+}
+
+// freeThresholds 根据空闲内存和空闲交换空间的阈值(GB)构造条件，阈值小于等于0的条件不启用
+func freeThresholds(memFree, swapFree float64) []freeThreshold {
+	var thresholds []freeThreshold
+	if memFree > 0 {
+		thresholds = append(thresholds, freeThreshold{
+			Label:     fmt.Sprintf(tr("空闲内存低于 %.2fG"), memFree),
+			Value:     func(r atopparse.MemoryRecord) float64 { return r.MemFree },
+			Threshold: memFree,
+		})
+	}
+	if swapFree > 0 {
+		thresholds = append(thresholds, freeThreshold{
+			Label:     fmt.Sprintf(tr("空闲交换空间低于 %.2fG"), swapFree),
+			Value:     func(r atopparse.MemoryRecord) float64 { return r.SwapFree },
+			Threshold: swapFree,
+			Swap:      true,
+		})
+	}
+	return thresholds
+}
+
+// checkThresholds 找出每个条件下低于阈值的样本及其中的最低值
+func checkThresholds(data []atopparse.MemoryRecord, thresholds []freeThreshold) []thresholdResult {
+	results := make([]thresholdResult, len(thresholds))
+	for i, t := range thresholds {
+		result := &results[i]
+		result.Threshold = t
+		for _, record := range data {
+			if t.Swap && record.SwapTotal == 0 {
+				continue
+			}
+			value := t.Value(record)
+			if value >= t.Threshold {
+				continue
+			}
+			if len(result.Breaches) == 0 || value < result.Worst {
+				result.Worst = value
+				result.WorstAt = record.Timestamp
+			}
+			result.Breaches = append(result.Breaches, record.Timestamp)
+		}
+	}
+	return results
+}
+
+// printThresholdResults 输出每个条件的越界样本数、最低值和越界样本的时间，返回越界样本总数
+func printThresholdResults(w io.Writer, results []thresholdResult) int {
+	total := 0
+	for _, result := range results {
+		if len(result.Breaches) == 0 {
+			fmt.Fprintf(w, tr("阈值检查: %s: 没有越界样本\n"), result.Threshold.Label)
+			continue
+		}
+		total += len(result.Breaches)
+		fmt.Fprintf(w, tr("阈值检查: %s: %d 个越界样本，最低 %.2fG (%s)\n"), result.Threshold.Label,
+			len(result.Breaches), result.Worst, result.WorstAt.Format("2006-01-02 15:04:05"))
+		for _, timestamp := range result.Breaches {
+			fmt.Fprintf(w, "  %s\n", timestamp.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return total
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"atop_parser/atopparse"
+)
+
+// sourceSummary 某个源文件在最差样本中的统计
+type sourceSummary struct {
+	Source  string
+	Count   int                    // 属于最差样本的数量
+	Worst   atopparse.MemoryRecord // 其中空闲内存最低的样本
+	ordinal int                    // 首次出现的名次，用于排序
+}
+
+// worstSources 取空闲内存最低的n个样本，按其所在源文件汇总
+func worstSources(data []atopparse.MemoryRecord, n int) []sourceSummary {
+	sorted := make([]atopparse.MemoryRecord, len(data))
+	copy(sorted, data)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MemFree < sorted[j].MemFree
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+
+	bySource := make(map[string]*sourceSummary)
+	for i, record := range sorted {
+		summary, ok := bySource[record.Source]
+		if !ok {
+			summary = &sourceSummary{Source: record.Source, Worst: record, ordinal: i}
+			bySource[record.Source] = summary
+		}
+		summary.Count++
+	}
+
+	summaries := make([]sourceSummary, 0, len(bySource))
+	for _, summary := range bySource {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].ordinal < summaries[j].ordinal
+	})
+	return summaries
+}
+
+// printTopFiles 输出包含空闲内存最低样本的源文件
+func printTopFiles(w io.Writer, data []atopparse.MemoryRecord, n int) {
+	summaries := worstSources(data, n)
+	fmt.Fprintf(w, tr("空闲内存最低的 %d 个样本所在的文件:\n"), min(n, len(data)))
+	for _, s := range summaries {
+		source := s.Source
+		if source == "" {
+			source = tr("(来源未知)")
+		}
+		fmt.Fprintf(w, tr("  %s: %d 个样本，最低 %.2fG (%s)\n"),
+			source, s.Count, s.Worst.MemFree, s.Worst.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"atop_parser/atopparse"
+)
+
+// stateCondition 描述一个需要跟踪进入/退出的内存状态
+type stateCondition struct {
+	Label     string                               // 状态描述，例如 "空闲内存低于 1.00G"
+	Value     func(atopparse.MemoryRecord) float64 // 判断所用的值
+	Threshold float64
+	Below     bool   // true表示值低于阈值时处于该状态，否则为高于阈值
+	OrEqual   bool   // true表示等于阈值也处于该状态
+	Severity  string // 越界的级别，规则为其severity，--transition-* 阈值为warning
+}
+
+// active 判断记录是否处于该状态
+func (c stateCondition) active(record atopparse.MemoryRecord) bool {
+	value := c.Value(record)
+	if c.OrEqual && value == c.Threshold {
+		return true
+	}
+	if c.Below {
+		return value < c.Threshold
+	}
+	return value > c.Threshold
+}
+
+// worse 判断a是否比b更严重
+func (c stateCondition) worse(a, b float64) bool {
+	if c.Below {
+		return a < b
+	}
+	return a > b
+}
+
+// stateWindow 一段持续处于某状态的时间，连续满足条件的样本合并为一个窗口
+type stateWindow struct {
+	Condition stateCondition
+	Start     time.Time // 第一个满足条件的样本
+	End       time.Time // 最后一个满足条件的样本
+	ExitAt    time.Time // 第一个不再满足条件的样本，直到数据结束仍处于该状态时为零值
+	Peak      float64   // 窗口内最严重的值
+	ExitValue float64   // 退出时的值
+}
+
+// findStateWindows 扫描序列，返回条件持续成立的时间窗口
+func findStateWindows(data []atopparse.MemoryRecord, cond stateCondition) []stateWindow {
+	var windows []stateWindow
+	var current *stateWindow
+
+	for _, record := range data {
+		value := cond.Value(record)
+		if cond.active(record) {
+			if current == nil {
+				current = &stateWindow{Condition: cond, Start: record.Timestamp, Peak: value}
+			}
+			current.End = record.Timestamp
+			if cond.worse(value, current.Peak) {
+				current.Peak = value
+			}
+			continue
+		}
+
+		if current != nil {
+			current.ExitAt = record.Timestamp
+			current.ExitValue = value
+			windows = append(windows, *current)
+			current = nil
+		}
+	}
+
+	if current != nil {
+		windows = append(windows, *current)
+	}
+	return windows
+}
+
+// transitionConditions 根据阈值构造需要跟踪的状态，阈值小于等于0的条件不启用
+func transitionConditions(memFreeBelow, swapUsedAbove float64) []stateCondition {
+	var conditions []stateCondition
+	if memFreeBelow > 0 {
+		conditions = append(conditions, stateCondition{
+			Label:     fmt.Sprintf(tr("空闲内存低于 %.2fG"), memFreeBelow),
+			Value:     func(r atopparse.MemoryRecord) float64 { return r.MemFree },
+			Threshold: memFreeBelow,
+			Below:     true,
+			Severity:  severityWarning,
+		})
+	}
+	if swapUsedAbove > 0 {
+		conditions = append(conditions, stateCondition{
+			Label:     fmt.Sprintf(tr("交换空间使用超过 %.2fG"), swapUsedAbove),
+			Value:     func(r atopparse.MemoryRecord) float64 { return r.SwapTotal - r.SwapFree },
+			Threshold: swapUsedAbove,
+			Severity:  severityWarning,
+		})
+	}
+	return conditions
+}
+
+// transitionEvent 一次进入或退出状态的事件
+type transitionEvent struct {
+	Timestamp time.Time
+	Text      string
+}
+
+// printTransitions 按时间顺序输出所有状态的进入/退出事件
+func printTransitions(w io.Writer, data []atopparse.MemoryRecord, conditions []stateCondition) {
+	var events []transitionEvent
+	for _, cond := range conditions {
+		for _, w := range findStateWindows(data, cond) {
+			events = append(events, transitionEvent{
+				Timestamp: w.Start,
+				Text:      fmt.Sprintf(tr("进入: %s"), cond.Label),
+			})
+			if !w.ExitAt.IsZero() {
+				events = append(events, transitionEvent{
+					Timestamp: w.ExitAt,
+					Text: fmt.Sprintf(tr("恢复: %s (持续 %s，最严重 %.2fG，当前 %.2fG)"),
+						cond.Label, w.ExitAt.Sub(w.Start), w.Peak, w.ExitValue),
+				})
+			}
+		}
+	}
+
+	// 稳定排序，保证同一时间点的事件按条件顺序输出
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	fmt.Fprintf(w, tr("内存状态变化（共 %d 个事件）:\n"), len(events))
+	for _, event := range events {
+		fmt.Fprintf(w, "  %s %s\n", event.Timestamp.Format("2006-01-02 15:04:05"), event.Text)
+	}
+}
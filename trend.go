@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"atop_parser/atopparse"
+)
+
+// usedMemoryTrend 已用内存随时间变化的线性回归结果
+type usedMemoryTrend struct {
+	Slope   float64 // GB/小时
+	R2      float64 // 拟合优度
+	Samples int
+}
+
+// fitUsedMemoryTrend 对已用内存（MemTotal-MemFree）做最小二乘线性回归，
+// 自变量与绘图相同，为距第一个样本的小时数。样本少于2个或时间跨度为0时ok为false
+func fitUsedMemoryTrend(data []atopparse.MemoryRecord) (usedMemoryTrend, bool) {
+	if len(data) < 2 {
+		return usedMemoryTrend{Samples: len(data)}, false
+	}
+
+	baseTime := data[0].Timestamp
+	n := float64(len(data))
+	var sumX, sumY float64
+	for _, record := range data {
+		sumX += record.Timestamp.Sub(baseTime).Hours()
+		sumY += record.MemTotal - record.MemFree
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for _, record := range data {
+		dx := record.Timestamp.Sub(baseTime).Hours() - meanX
+		dy := record.MemTotal - record.MemFree - meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return usedMemoryTrend{Samples: len(data)}, false
+	}
+
+	trend := usedMemoryTrend{Slope: sxy / sxx, Samples: len(data)}
+	// 已用内存恒定时直线完全拟合
+	trend.R2 = 1
+	if syy > 0 {
+		trend.R2 = sxy * sxy / (sxx * syy)
+	}
+	return trend, true
+}
+
+// printTrend 输出已用内存的变化趋势，斜率和拟合优度都超过阈值时提示可能存在内存泄漏
+func printTrend(w io.Writer, data []atopparse.MemoryRecord, slopeThreshold, r2Threshold float64) {
+	trend, ok := fitUsedMemoryTrend(data)
+	if !ok {
+		fmt.Fprintf(w, tr("趋势分析: 需要至少2个不同时间的样本，当前 %d 个，跳过\n"), trend.Samples)
+		return
+	}
+	fmt.Fprintf(w, tr("趋势分析: 已用内存斜率 %+.4f GB/小时，R² = %.3f（%d 个样本）\n"), trend.Slope, trend.R2, trend.Samples)
+	if trend.Slope > slopeThreshold && trend.R2 >= r2Threshold {
+		fmt.Fprintf(w, tr("警告: 可能存在内存泄漏（斜率超过 %.4f GB/小时且 R² 不低于 %.2f）\n"), slopeThreshold, r2Threshold)
+	}
+}